@@ -0,0 +1,110 @@
+package querybm
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tecowl/querybm/statement"
+)
+
+// BuildAggregateSelect builds a query string selecting the aggregate expression such as "SUM(yr)"
+// with the current conditions. It returns the SQL query string and its arguments.
+// If the query groups or deduplicates rows, the expression aggregates the rows of the SELECT query
+// in a derived table, so it must refer to the columns selected by the fields of the query.
+func (q *Query[M]) BuildAggregateSelect(expression string) (string, []any) {
+	return q.aggregateStatement(expression).Build()
+}
+
+// aggregateStatement builds the statement for BuildAggregateSelect.
+func (q *Query[M]) aggregateStatement(expression string) *statement.Statement {
+	return q.aggregatingStatement("querybm_aggregate", statement.NewSimpleFields(expression))
+}
+
+// BuildCountBySelect builds a query string counting the rows per value of the column
+// with the current conditions. It returns the SQL query string and its arguments.
+// If the query groups or deduplicates rows, it counts the rows of the SELECT query in a derived table,
+// so the column must be selected by the fields of the query.
+func (q *Query[M]) BuildCountBySelect(column string) (string, []any) {
+	return q.countByStatement(column).Build()
+}
+
+// countByStatement builds the statement for BuildCountBySelect.
+func (q *Query[M]) countByStatement(column string) *statement.Statement {
+	st := q.aggregatingStatement("querybm_count_by", statement.NewSimpleFields(column, "COUNT(*) AS count"))
+	st.GroupBy.Add(column)
+	return st
+}
+
+// Aggregate executes the aggregate expression such as "SUM(yr)" or "MAX(created_at)"
+// on the table of the query with its condition, and returns the result as T.
+// It returns the zero value of T if the result is NULL, for example when no rows match.
+// If the query groups or deduplicates rows, it aggregates the groups or the distinct rows. See BuildAggregateSelect.
+func Aggregate[T, M any](ctx context.Context, q *Query[M], expression string) (T, error) {
+	queryStr, args, err := q.build(q.aggregateStatement(expression))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	row, err := q.queryRow(ctx, QueryKindAggregate, queryStr, args)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	var result sql.Null[T]
+	if err := row.Scan(&result); err != nil {
+		var zero T
+		return zero, err
+	}
+	return result.V, nil
+}
+
+// Sum returns SUM(column) of the rows matching the condition of the query.
+func Sum[T, M any](ctx context.Context, q *Query[M], column string) (T, error) {
+	return Aggregate[T](ctx, q, "SUM("+column+")")
+}
+
+// Avg returns AVG(column) of the rows matching the condition of the query.
+func Avg[T, M any](ctx context.Context, q *Query[M], column string) (T, error) {
+	return Aggregate[T](ctx, q, "AVG("+column+")")
+}
+
+// Min returns MIN(column) of the rows matching the condition of the query.
+func Min[T, M any](ctx context.Context, q *Query[M], column string) (T, error) {
+	return Aggregate[T](ctx, q, "MIN("+column+")")
+}
+
+// Max returns MAX(column) of the rows matching the condition of the query.
+func Max[T, M any](ctx context.Context, q *Query[M], column string) (T, error) {
+	return Aggregate[T](ctx, q, "MAX("+column+")")
+}
+
+// CountBy counts the rows matching the condition of the query per value of the column.
+// The values of the column are scanned into K, so use a nullable type such as sql.NullString
+// as K if the column can be NULL. If the query groups or deduplicates rows, it counts the groups or the distinct rows.
+// See BuildCountBySelect.
+func CountBy[K comparable, M any](ctx context.Context, q *Query[M], column string) (map[K]int64, error) {
+	queryStr, args, err := q.build(q.countByStatement(column))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.query(ctx, QueryKindAggregate, queryStr, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[K]int64{}
+	for rows.Next() {
+		var key K
+		var count int64
+		if err := rows.Scan(&key, &count); err != nil {
+			return nil, err
+		}
+		result[key] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,238 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/statement"
+)
+
+func TestQuery_BuildAggregateSelect(t *testing.T) {
+	t.Parallel()
+	q := newTestQuery(nil, "books", WithDialect(PostgreSQL))
+	gotSQL, gotValues := q.BuildAggregateSelect("SUM(yr)")
+	if want := "SELECT SUM(yr) FROM books WHERE status = $1"; gotSQL != want {
+		t.Errorf("BuildAggregateSelect() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{"active"}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("BuildAggregateSelect() values = %v, want %v", gotValues, want)
+	}
+}
+
+func TestQuery_BuildCountBySelect(t *testing.T) {
+	t.Parallel()
+	q := newTestQuery(nil, "books", WithDialect(PostgreSQL))
+	gotSQL, gotValues := q.BuildCountBySelect("book_type")
+	if want := "SELECT book_type, COUNT(*) AS count FROM books WHERE status = $1 GROUP BY book_type"; gotSQL != want {
+		t.Errorf("BuildCountBySelect() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{"active"}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("BuildCountBySelect() values = %v, want %v", gotValues, want)
+	}
+}
+
+func TestQuery_BuildAggregateSelect_Grouped(t *testing.T) {
+	t.Parallel()
+	condition := NewBuilder(func(st *statement.Statement) {
+		st.Where.Add(expr.Field("status", expr.Eq("active")))
+		st.GroupBy.Add("author_id")
+		st.Having.Add(expr.Field("COUNT(*)", expr.Gte(2)))
+	})
+	fields := NewFields[TestModel]([]string{"author_id", "COUNT(*) AS books"}, nil)
+	q := NewWithDB(nil, "books", fields, condition, &TestSort{}, NewLimitOffset(10, 0), WithDialect(PostgreSQL))
+	wantValues := []any{"active", 2}
+
+	gotSQL, gotValues := q.BuildAggregateSelect("SUM(books)")
+	if want := "SELECT SUM(books) FROM (SELECT author_id, COUNT(*) AS books FROM books WHERE status = $1 GROUP BY author_id HAVING COUNT(*) >= $2) AS querybm_aggregate"; gotSQL != want {
+		t.Errorf("BuildAggregateSelect() SQL = %v, want %v", gotSQL, want)
+	}
+	if !reflect.DeepEqual(gotValues, wantValues) {
+		t.Errorf("BuildAggregateSelect() values = %v, want %v", gotValues, wantValues)
+	}
+
+	gotSQL, gotValues = q.BuildCountBySelect("books")
+	if want := "SELECT books, COUNT(*) AS count FROM (SELECT author_id, COUNT(*) AS books FROM books WHERE status = $1 GROUP BY author_id HAVING COUNT(*) >= $2) AS querybm_count_by GROUP BY books"; gotSQL != want {
+		t.Errorf("BuildCountBySelect() SQL = %v, want %v", gotSQL, want)
+	}
+	if !reflect.DeepEqual(gotValues, wantValues) {
+		t.Errorf("BuildCountBySelect() values = %v, want %v", gotValues, wantValues)
+	}
+}
+
+func TestAggregate_Distinct(t *testing.T) {
+	t.Parallel()
+	condition := NewBuilder(func(st *statement.Statement) {
+		st.Distinct = true
+		st.Locking.ForUpdate()
+	})
+	fields := NewFields[TestModel]([]string{"author_id", "yr"}, nil)
+	db := &RecordingDB{row: []any{int64(4000)}}
+	q := NewWithDB(db, "books", fields, condition, nil, nil, WithDialect(PostgreSQL))
+	got, err := Sum[int64](t.Context(), q, "yr")
+	if err != nil {
+		t.Fatalf("Sum() error = %v", err)
+	}
+	if got != 4000 {
+		t.Errorf("Sum() = %v, want 4000", got)
+	}
+	if want := []string{"SELECT SUM(yr) FROM (SELECT DISTINCT author_id, yr FROM books) AS querybm_aggregate"}; !reflect.DeepEqual(db.prepared, want) {
+		t.Errorf("Sum() queries = %v, want %v", db.prepared, want)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		aggregate func(ctx context.Context, q *Query[TestModel]) (any, error)
+		value     any
+		wantSQL   string
+		want      any
+	}{
+		{
+			name: "Aggregate",
+			aggregate: func(ctx context.Context, q *Query[TestModel]) (any, error) {
+				return Aggregate[int64](ctx, q, "COUNT(DISTINCT author_id)")
+			},
+			value:   int64(3),
+			wantSQL: "SELECT COUNT(DISTINCT author_id) FROM books WHERE status = $1",
+			want:    int64(3),
+		},
+		{
+			name: "Sum",
+			aggregate: func(ctx context.Context, q *Query[TestModel]) (any, error) {
+				return Sum[int64](ctx, q, "yr")
+			},
+			value:   int64(6000),
+			wantSQL: "SELECT SUM(yr) FROM books WHERE status = $1",
+			want:    int64(6000),
+		},
+		{
+			name: "Sum of no rows",
+			aggregate: func(ctx context.Context, q *Query[TestModel]) (any, error) {
+				return Sum[int64](ctx, q, "yr")
+			},
+			value:   nil,
+			wantSQL: "SELECT SUM(yr) FROM books WHERE status = $1",
+			want:    int64(0),
+		},
+		{
+			name: "Avg",
+			aggregate: func(ctx context.Context, q *Query[TestModel]) (any, error) {
+				return Avg[float64](ctx, q, "yr")
+			},
+			value:   []byte("2000.5000"),
+			wantSQL: "SELECT AVG(yr) FROM books WHERE status = $1",
+			want:    2000.5,
+		},
+		{
+			name: "Min",
+			aggregate: func(ctx context.Context, q *Query[TestModel]) (any, error) {
+				return Min[string](ctx, q, "title")
+			},
+			value:   "Alpha",
+			wantSQL: "SELECT MIN(title) FROM books WHERE status = $1",
+			want:    "Alpha",
+		},
+		{
+			name: "Max",
+			aggregate: func(ctx context.Context, q *Query[TestModel]) (any, error) {
+				return Max[int32](ctx, q, "yr")
+			},
+			value:   int64(2024),
+			wantSQL: "SELECT MAX(yr) FROM books WHERE status = $1",
+			want:    int32(2024),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db := &RecordingDB{row: []any{tt.value}}
+			q := newTestQuery(db, "books", WithDialect(PostgreSQL))
+			got, err := tt.aggregate(t.Context(), q)
+			if err != nil {
+				t.Fatalf("aggregate error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("aggregate = %#v, want %#v", got, tt.want)
+			}
+			if want := []string{tt.wantSQL}; !reflect.DeepEqual(db.prepared, want) {
+				t.Errorf("aggregate queries = %v, want %v", db.prepared, want)
+			}
+		})
+	}
+}
+
+func TestAggregateError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prepare error", func(t *testing.T) {
+		t.Parallel()
+		db := &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return nil, errConditionError }}
+		if _, err := Sum[int64](t.Context(), newTestQuery(db, "books", WithDialect(PostgreSQL)), "yr"); !errors.Is(err, errConditionError) {
+			t.Errorf("Sum() error = %v, want %v", err, errConditionError)
+		}
+	})
+
+	t.Run("scan error", func(t *testing.T) {
+		t.Parallel()
+		db := &RecordingDB{row: []any{"not a number"}}
+		if _, err := Sum[int64](t.Context(), newTestQuery(db, "books", WithDialect(PostgreSQL)), "yr"); err == nil {
+			t.Errorf("Sum() error = nil, want error")
+		}
+	})
+}
+
+func TestCountBy(t *testing.T) {
+	t.Parallel()
+	db := &RecordingDB{rows: [][]any{{"novel", int64(3)}, {"essay", int64(2)}}}
+
+	got, err := CountBy[string](t.Context(), newTestQuery(db, "books", WithDialect(PostgreSQL)), "book_type")
+	if err != nil {
+		t.Fatalf("CountBy() error = %v", err)
+	}
+	if want := map[string]int64{"novel": 3, "essay": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CountBy() = %v, want %v", got, want)
+	}
+	if want := []string{"SELECT book_type, COUNT(*) AS count FROM books WHERE status = $1 GROUP BY book_type"}; !reflect.DeepEqual(db.prepared, want) {
+		t.Errorf("CountBy() queries = %v, want %v", db.prepared, want)
+	}
+}
+
+func TestCountByError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		db      DB
+		wantErr error
+	}{
+		{
+			name:    "prepare error",
+			db:      &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return nil, errConditionError }},
+			wantErr: errConditionError,
+		},
+		{
+			name:    "scan error",
+			db:      &RecordingDB{rows: [][]any{{errSortError}}},
+			wantErr: errSortError,
+		},
+		{
+			name:    "rows error",
+			db:      &RecordingDB{err: errSortError},
+			wantErr: errSortError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := CountBy[string](t.Context(), newTestQuery(tt.db, "books", WithDialect(PostgreSQL)), "book_type"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CountBy() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package querybm
+
+import "github.com/tecowl/querybm/statement"
+
+// DebugOption configures Query.DebugString.
+type DebugOption func(*debugOptions)
+
+type debugOptions struct {
+	pretty bool
+	count  bool
+}
+
+// DebugPretty makes Query.DebugString format the SQL in multiple lines with statement.Pretty.
+func DebugPretty() DebugOption {
+	return func(o *debugOptions) {
+		o.pretty = true
+	}
+}
+
+// DebugCount makes Query.DebugString render the COUNT query instead of the SELECT query.
+func DebugCount() DebugOption {
+	return func(o *debugOptions) {
+		o.count = true
+	}
+}
+
+// DebugString returns the SELECT query built like BuildRowsSelect with the arguments inlined as literals
+// of the query's dialect by statement.Interpolate.
+//
+// It is for debugging only, such as running the SQL by hand to investigate the results of a condition.
+// Never execute the result: use BuildRowsSelect and bind the arguments instead.
+func (q *Query[M]) DebugString(opts ...DebugOption) string {
+	o := &debugOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var queryStr string
+	var args []any
+	if o.count {
+		queryStr, args = q.BuildCountSelect()
+	} else {
+		queryStr, args = q.BuildRowsSelect()
+	}
+	s := statement.Interpolate(statement.DialectOrDefault(q.Dialect), queryStr, args)
+	if o.pretty {
+		s = statement.Pretty(s)
+	}
+	return s
+}
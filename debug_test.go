@@ -0,0 +1,67 @@
+package querybm
+
+import (
+	"testing"
+
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/statement"
+)
+
+func TestQuery_DebugString(t *testing.T) {
+	t.Parallel()
+	fields := NewFields[TestModel]([]string{"id", "name"}, nil)
+	condition := NewBuilder(func(st *statement.Statement) {
+		st.Where.Add(expr.Field("name", expr.Eq("O'Reilly")))
+		st.Where.Add(expr.Field("deleted_at", expr.IsNull()))
+	})
+	tests := []struct {
+		name    string
+		dialect Dialect
+		opts    []DebugOption
+		want    string
+	}{
+		{
+			name:    "default dialect",
+			dialect: nil,
+			want:    "SELECT id, name FROM books WHERE name = 'O''Reilly' AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 10 OFFSET 20",
+		},
+		{
+			name:    "PostgreSQL",
+			dialect: PostgreSQL,
+			want:    "SELECT id, name FROM books WHERE name = 'O''Reilly' AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 10 OFFSET 20",
+		},
+		{
+			name:    "SQL Server",
+			dialect: SQLServer,
+			want:    "SELECT id, name FROM books WHERE name = 'O''Reilly' AND deleted_at IS NULL ORDER BY created_at DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+		{
+			name:    "pretty",
+			dialect: PostgreSQL,
+			opts:    []DebugOption{DebugPretty()},
+			want: `SELECT id, name
+FROM books
+WHERE name = 'O''Reilly'
+  AND deleted_at IS NULL
+ORDER BY created_at DESC
+LIMIT 10
+OFFSET 20`,
+		},
+		{
+			name:    "count",
+			dialect: PostgreSQL,
+			opts:    []DebugOption{DebugCount()},
+			want:    "SELECT COUNT(*) AS count FROM books WHERE name = 'O''Reilly' AND deleted_at IS NULL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			q := New(nil, "books", fields, condition, &TestSort{}, NewLimitOffset(10, 20), WithDialect(tt.dialect))
+			if got := q.DebugString(tt.opts...); got != tt.want {
+				t.Errorf("DebugString() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
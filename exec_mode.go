@@ -0,0 +1,72 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+)
+
+// ExecMode is the strategy to execute the built SQL on the DB of a query.
+type ExecMode int
+
+const (
+	// ExecPrepared prepares a statement for each execution and closes it after use. It is the default.
+	ExecPrepared ExecMode = iota
+	// ExecDirect runs the SQL directly with QueryContext and QueryRowContext of the DB without preparing it.
+	// Use it behind a proxy such as ProxySQL or pgbouncer in transaction mode where server-side
+	// prepared statements break or cost an extra round trip. The DB must implement DirectDB.
+	ExecDirect
+	// ExecCachedPrepared reuses the prepared statements in the StmtCache of the query prepared on the same DB.
+	// In a transaction it prepares a statement for each execution like ExecPrepared
+	// because statements prepared outside the transaction don't run in it.
+	ExecCachedPrepared
+)
+
+var (
+	// ErrDirectExecUnsupported is returned when ExecDirect is used on a DB which doesn't implement DirectDB.
+	ErrDirectExecUnsupported = errors.New("direct execution requires a DB implementing DirectDB")
+	// ErrNoStmtCache is returned when ExecCachedPrepared is used without a StmtCache.
+	ErrNoStmtCache = errors.New("cached prepared execution requires a StmtCache")
+)
+
+// directStmt is a Stmt which runs the query directly on the DB every time it is executed.
+type directStmt struct {
+	db    DirectDB
+	query string
+}
+
+var _ Stmt = (*directStmt)(nil)
+
+// Close implements Stmt. It does nothing because nothing is prepared.
+func (*directStmt) Close() error { return nil }
+
+// QueryRowContext implements Stmt.
+func (s *directStmt) QueryRowContext(ctx context.Context, args ...any) Row { // nolint:ireturn
+	return s.db.QueryRowContext(ctx, s.query, args...)
+}
+
+// QueryContext implements Stmt.
+func (s *directStmt) QueryContext(ctx context.Context, args ...any) (Rows, error) { // nolint:ireturn
+	return s.db.QueryContext(ctx, s.query, args...)
+}
+
+// statement returns the statement executing the query string in the exec mode of the query.
+func (q *Query[M]) statement(ctx context.Context, queryStr string) (Stmt, error) { // nolint:ireturn
+	switch q.ExecMode {
+	case ExecDirect:
+		db, ok := q.db.(DirectDB)
+		if !ok {
+			return nil, ErrDirectExecUnsupported
+		}
+		return &directStmt{db: db, query: queryStr}, nil
+	case ExecCachedPrepared:
+		if q.StmtCache == nil {
+			return nil, ErrNoStmtCache
+		}
+		if q.inTx() {
+			return q.db.PrepareContext(ctx, queryStr)
+		}
+		return q.StmtCache.prepare(ctx, q.db, queryStr)
+	default:
+		return q.db.PrepareContext(ctx, queryStr)
+	}
+}
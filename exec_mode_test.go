@@ -0,0 +1,93 @@
+package querybm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQuery_ExecMode(t *testing.T) {
+	t.Parallel()
+	const (
+		countSQL = "SELECT COUNT(*) AS count FROM users WHERE status = $1"
+		listSQL  = "SELECT id, name FROM users WHERE status = $1 ORDER BY created_at DESC LIMIT $2"
+	)
+	tests := []struct {
+		name         string
+		opt          func() Option
+		inTx         bool
+		wantPrepared []string
+		wantDirect   []string
+	}{
+		{
+			name:         "prepared",
+			opt:          func() Option { return WithExecMode(ExecPrepared) },
+			wantPrepared: []string{countSQL, listSQL, countSQL, listSQL},
+		},
+		{
+			name:       "direct",
+			opt:        func() Option { return WithExecMode(ExecDirect) },
+			wantDirect: []string{countSQL, listSQL, countSQL, listSQL},
+		},
+		{
+			name:         "cached prepared",
+			opt:          func() Option { return WithStmtCache(NewStmtCache(10)) },
+			wantPrepared: []string{countSQL, listSQL},
+		},
+		{
+			name:         "cached prepared in transaction",
+			opt:          func() Option { return WithStmtCache(NewStmtCache(10)) },
+			inTx:         true,
+			wantPrepared: []string{countSQL, listSQL, countSQL, listSQL},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db := &RecordingDB{row: []any{int64(1)}, inTx: tt.inTx}
+			q := newTestQuery(db, "users", WithDialect(PostgreSQL), tt.opt())
+			for range 2 {
+				if _, err := q.Page(t.Context()); err != nil {
+					t.Fatalf("Page() error = %v", err)
+				}
+			}
+			if !reflect.DeepEqual(db.prepared, tt.wantPrepared) {
+				t.Errorf("prepared = %v, want %v", db.prepared, tt.wantPrepared)
+			}
+			if !reflect.DeepEqual(db.direct, tt.wantDirect) {
+				t.Errorf("direct = %v, want %v", db.direct, tt.wantDirect)
+			}
+		})
+	}
+}
+
+func TestQuery_ExecModeError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		opt     Option
+		wantErr error
+	}{
+		{
+			name:    "direct without DirectDB",
+			opt:     WithExecMode(ExecDirect),
+			wantErr: ErrDirectExecUnsupported,
+		},
+		{
+			name:    "cached prepared without cache",
+			opt:     WithExecMode(ExecCachedPrepared),
+			wantErr: ErrNoStmtCache,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			q := newTestQuery(&MockDB{}, "users", tt.opt)
+			if _, err := q.Count(t.Context()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Count() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
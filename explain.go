@@ -0,0 +1,310 @@
+package querybm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tecowl/querybm/statement"
+)
+
+// ErrExplainUnsupported is returned when the dialect of the query doesn't support the EXPLAIN requested.
+var ErrExplainUnsupported = errors.New("explain is not supported by the dialect")
+
+// ExplainPlan is the execution plan of a query parsed from the output of EXPLAIN.
+type ExplainPlan struct {
+	// Tables are the accesses to the tables in the order of the plan.
+	Tables []ExplainTable
+	// Analyzed is true if the query was executed by ExplainAnalyze, so ActualRows of the tables are set.
+	Analyzed bool
+	// Raw is the output of EXPLAIN, such as the JSON of MySQL and PostgreSQL, to be logged as it is.
+	Raw string
+}
+
+// ExplainTable is an access to a table in ExplainPlan.
+// The values are dialect-specific as they are reported by the database.
+type ExplainTable struct {
+	// Table is the table name, or its alias if the database reports only the alias.
+	Table string
+	// AccessType is how the table is accessed, such as "ALL" and "ref" of MySQL,
+	// "Table scan" and "Index lookup" of MySQL EXPLAIN ANALYZE, "Seq Scan" and "Index Scan" of PostgreSQL,
+	// and "SCAN" and "SEARCH" of SQLite.
+	AccessType string
+	// Index is the chosen index. It is empty if no index is used.
+	Index string
+	// EstimatedRows is the number of rows estimated by the planner. SQLite doesn't estimate it.
+	EstimatedRows int64
+	// ActualRows is the number of rows actually returned by the access. It is set by ExplainAnalyze.
+	ActualRows int64
+}
+
+// Table returns the first access to the table in the plan.
+func (p *ExplainPlan) Table(name string) (ExplainTable, bool) {
+	for _, t := range p.Tables {
+		if t.Table == name {
+			return t, true
+		}
+	}
+	return ExplainTable{}, false
+}
+
+// ExplainOption configures Query.Explain and Query.ExplainAnalyze.
+type ExplainOption func(*explainOptions)
+
+type explainOptions struct {
+	count bool
+}
+
+// ExplainCount makes Query.Explain and Query.ExplainAnalyze explain the COUNT query instead of the SELECT query.
+func ExplainCount() ExplainOption {
+	return func(o *explainOptions) {
+		o.count = true
+	}
+}
+
+// explainer runs EXPLAIN for a dialect.
+type explainer struct {
+	// prefix and analyzePrefix are prepended to the query. analyzePrefix is empty if ANALYZE is not supported.
+	prefix        string
+	analyzePrefix string
+	parse         func(rows Rows, analyzed bool) (*ExplainPlan, error)
+}
+
+// explainers are the explainers of the built-in dialects by their names.
+// SQL Server is not supported because its plans are retrieved with session settings like SET SHOWPLAN_XML.
+var explainers = map[string]*explainer{
+	"mysql":    {prefix: "EXPLAIN FORMAT=JSON ", analyzePrefix: "EXPLAIN ANALYZE ", parse: parseMySQLPlan},
+	"postgres": {prefix: "EXPLAIN (FORMAT JSON) ", analyzePrefix: "EXPLAIN (ANALYZE, FORMAT JSON) ", parse: parsePostgreSQLPlan},
+	"sqlite":   {prefix: "EXPLAIN QUERY PLAN ", parse: parseSQLitePlan},
+}
+
+// Explain runs EXPLAIN for the SELECT query built like BuildRowsSelect, or the COUNT query with ExplainCount,
+// and returns the parsed plan. It supports MySQL, PostgreSQL and SQLite.
+func (q *Query[M]) Explain(ctx context.Context, opts ...ExplainOption) (*ExplainPlan, error) {
+	return q.explain(ctx, false, opts)
+}
+
+// ExplainAnalyze runs EXPLAIN ANALYZE like Explain, so the plan has the actual rows.
+// Note that the query is actually executed. It supports MySQL 8.0.18 or later and PostgreSQL.
+func (q *Query[M]) ExplainAnalyze(ctx context.Context, opts ...ExplainOption) (*ExplainPlan, error) {
+	return q.explain(ctx, true, opts)
+}
+
+// explain runs EXPLAIN with the explainer of the dialect of the query.
+func (q *Query[M]) explain(ctx context.Context, analyze bool, opts []ExplainOption) (*ExplainPlan, error) {
+	o := &explainOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	e, ok := explainers[statement.DialectOrDefault(q.Dialect).Name()]
+	prefix := ""
+	if ok {
+		prefix = e.prefix
+		if analyze {
+			prefix = e.analyzePrefix
+		}
+	}
+	if prefix == "" {
+		return nil, fmt.Errorf("%w: %s", ErrExplainUnsupported, statement.DialectOrDefault(q.Dialect).Name())
+	}
+
+	st := q.rowsStatement()
+	if o.count {
+		st = q.countStatement()
+	}
+	queryStr, args, err := q.build(st)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.query(ctx, QueryKindExplain, prefix+queryStr, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return e.parse(rows, analyze)
+}
+
+// scanExplainLines scans the rows of a single text column and returns them as lines.
+func scanExplainLines(rows Rows) ([]string, error) {
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseMySQLPlan parses the output of EXPLAIN FORMAT=JSON or EXPLAIN ANALYZE of MySQL.
+func parseMySQLPlan(rows Rows, analyzed bool) (*ExplainPlan, error) {
+	lines, err := scanExplainLines(rows)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ExplainPlan{Analyzed: analyzed, Raw: strings.Join(lines, "\n")}
+	if analyzed {
+		plan.Tables = parseMySQLTree(plan.Raw)
+		return plan, nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(plan.Raw), &v); err != nil {
+		return nil, fmt.Errorf("failed to parse explain output: %w", err)
+	}
+	walkMySQLPlan(v, func(t map[string]any) {
+		plan.Tables = append(plan.Tables, ExplainTable{
+			Table:         jsonString(t["table_name"]),
+			AccessType:    jsonString(t["access_type"]),
+			Index:         jsonString(t["key"]),
+			EstimatedRows: jsonInt(t["rows_examined_per_scan"]),
+		})
+	})
+	return plan, nil
+}
+
+// walkMySQLPlan calls fn with the table objects in the JSON plan of MySQL in the order of the plan.
+// The table of an object is visited before its other members, which are visited in the order of their keys
+// because the order of the members of a JSON object is not kept.
+func walkMySQLPlan(v any, fn func(table map[string]any)) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			walkMySQLPlan(e, fn)
+		}
+	case map[string]any:
+		if t, ok := v["table"].(map[string]any); ok && t["table_name"] != nil {
+			fn(t)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			walkMySQLPlan(v[k], fn)
+		}
+	}
+}
+
+// mysqlTreeAccess matches an access to a table in the tree output of EXPLAIN ANALYZE of MySQL like
+// "-> Index lookup on b using idx_author (author_id=1)  (cost=0.35 rows=1) (actual time=0.02..0.02 rows=1 loops=2)".
+var mysqlTreeAccess = regexp.MustCompile(`-> ((?:[\w-]+ )*?(?:scan|lookup)) on (\S+)(?: using (\S+))?.*?\(cost=[\d.e+]+ rows=([\d.e+]+)\)(?: \(actual time=[\d.e+]+\.\.[\d.e+]+ rows=([\d.e+]+) loops=(\d+)\))?`)
+
+// parseMySQLTree parses the tree output of EXPLAIN ANALYZE of MySQL.
+func parseMySQLTree(tree string) []ExplainTable {
+	var tables []ExplainTable
+	for _, m := range mysqlTreeAccess.FindAllStringSubmatch(tree, -1) {
+		t := ExplainTable{Table: m[2], AccessType: m[1], Index: m[3], EstimatedRows: parseRows(m[4])}
+		if m[5] != "" {
+			loops, _ := strconv.ParseFloat(m[6], 64)
+			rows, _ := strconv.ParseFloat(m[5], 64)
+			t.ActualRows = int64(math.Round(rows * loops))
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
+
+// parsePostgreSQLPlan parses the output of EXPLAIN (FORMAT JSON) of PostgreSQL.
+func parsePostgreSQLPlan(rows Rows, analyzed bool) (*ExplainPlan, error) {
+	lines, err := scanExplainLines(rows)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ExplainPlan{Analyzed: analyzed, Raw: strings.Join(lines, "\n")}
+	var v []struct {
+		Plan map[string]any `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan.Raw), &v); err != nil {
+		return nil, fmt.Errorf("failed to parse explain output: %w", err)
+	}
+	var walk func(node map[string]any)
+	walk = func(node map[string]any) {
+		if name := jsonString(node["Relation Name"]); name != "" {
+			t := ExplainTable{
+				Table:         name,
+				AccessType:    jsonString(node["Node Type"]),
+				Index:         jsonString(node["Index Name"]),
+				EstimatedRows: jsonInt(node["Plan Rows"]),
+			}
+			if analyzed {
+				t.ActualRows = int64(math.Round(jsonFloat(node["Actual Rows"]) * jsonFloat(node["Actual Loops"])))
+			}
+			plan.Tables = append(plan.Tables, t)
+		}
+		children, _ := node["Plans"].([]any)
+		for _, child := range children {
+			if c, ok := child.(map[string]any); ok {
+				walk(c)
+			}
+		}
+	}
+	for _, p := range v {
+		walk(p.Plan)
+	}
+	return plan, nil
+}
+
+// sqliteAccess matches the detail of EXPLAIN QUERY PLAN of SQLite like
+// "SEARCH b USING INDEX idx_author (author_id=?)" and "SCAN TABLE books".
+var sqliteAccess = regexp.MustCompile(`^(SCAN|SEARCH) (?:TABLE )?(\S+)(?: AS \S+)?(?: USING (?:(?:AUTOMATIC )?(?:PARTIAL )?(?:COVERING )?INDEX (\S+)|((?:INTEGER )?PRIMARY KEY)))?`)
+
+// parseSQLitePlan parses the output of EXPLAIN QUERY PLAN of SQLite.
+func parseSQLitePlan(rows Rows, _ bool) (*ExplainPlan, error) {
+	plan := &ExplainPlan{}
+	var details []string
+	for rows.Next() {
+		var id, parent, notUsed int64
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+		if m := sqliteAccess.FindStringSubmatch(detail); m != nil {
+			plan.Tables = append(plan.Tables, ExplainTable{Table: m[2], AccessType: m[1], Index: m[3] + m[4]})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	plan.Raw = strings.Join(details, "\n")
+	return plan, nil
+}
+
+// jsonString returns v if it is a string, or an empty string.
+func jsonString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+// jsonFloat returns v if it is a number, or 0.
+func jsonFloat(v any) float64 {
+	f, _ := v.(float64)
+	return f
+}
+
+// jsonInt returns v as an integer if it is a number or a numeric string, or 0.
+func jsonInt(v any) int64 {
+	if s, ok := v.(string); ok {
+		return parseRows(s)
+	}
+	return int64(math.Round(jsonFloat(v)))
+}
+
+// parseRows parses the number of rows which may be a float like "1.5e+06".
+func parseRows(s string) int64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return int64(math.Round(f))
+}
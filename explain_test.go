@@ -0,0 +1,267 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const mysqlExplainJSON = `{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "2.10"},
+    "ordering_operation": {
+      "using_filesort": true,
+      "nested_loop": [
+        {"table": {"table_name": "b", "access_type": "ALL", "possible_keys": ["idx_author"], "rows_examined_per_scan": 12, "filtered": "10.00"}},
+        {"table": {"table_name": "a", "access_type": "eq_ref", "key": "PRIMARY", "rows_examined_per_scan": 1,
+          "attached_subqueries": [
+            {"query_block": {"select_id": 2, "table": {"table_name": "reviews", "access_type": "ref", "key": "idx_book", "rows_examined_per_scan": "3"}}}
+          ]}}
+      ]
+    }
+  }
+}`
+
+const mysqlExplainTree = `-> Sort: b.title  (actual time=0.1..0.1 rows=4 loops=1)
+    -> Nested loop inner join  (cost=2.1 rows=1.2) (actual time=0.05..0.08 rows=4 loops=1)
+        -> Filter: (b.yr > 2000)  (cost=1.45 rows=1.2) (actual time=0.03..0.05 rows=4 loops=1)
+            -> Table scan on b  (cost=1.45 rows=12) (actual time=0.03..0.04 rows=12 loops=1)
+        -> Single-row index lookup on a using PRIMARY (author_id=b.author_id)  (cost=0.28 rows=1) (actual time=0.01..0.01 rows=1 loops=4)
+`
+
+const postgresExplainJSON = `[
+  {
+    "Plan": {
+      "Node Type": "Hash Join",
+      "Plan Rows": 10,
+      "Actual Rows": 4,
+      "Actual Loops": 1,
+      "Plans": [
+        {"Node Type": "Seq Scan", "Relation Name": "books", "Alias": "b", "Plan Rows": 1200, "Actual Rows": 1100, "Actual Loops": 1},
+        {"Node Type": "Hash", "Plan Rows": 5, "Plans": [
+          {"Node Type": "Index Scan", "Relation Name": "authors", "Alias": "a", "Index Name": "authors_pkey", "Plan Rows": 5, "Actual Rows": 2.5, "Actual Loops": 2}
+        ]}
+      ]
+    }
+  }
+]`
+
+func TestQuery_Explain(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		dialect   Dialect
+		analyze   bool
+		opts      []ExplainOption
+		values    [][]any
+		wantQuery string
+		want      *ExplainPlan
+	}{
+		{
+			name:      "MySQL",
+			dialect:   MySQL,
+			values:    [][]any{{mysqlExplainJSON}},
+			wantQuery: "EXPLAIN FORMAT=JSON SELECT id, name FROM books WHERE status = ? ORDER BY created_at DESC LIMIT ?",
+			want: &ExplainPlan{
+				Tables: []ExplainTable{
+					{Table: "b", AccessType: "ALL", EstimatedRows: 12},
+					{Table: "a", AccessType: "eq_ref", Index: "PRIMARY", EstimatedRows: 1},
+					{Table: "reviews", AccessType: "ref", Index: "idx_book", EstimatedRows: 3},
+				},
+				Raw: mysqlExplainJSON,
+			},
+		},
+		{
+			name:      "MySQL analyze",
+			dialect:   MySQL,
+			analyze:   true,
+			values:    [][]any{{mysqlExplainTree}},
+			wantQuery: "EXPLAIN ANALYZE SELECT id, name FROM books WHERE status = ? ORDER BY created_at DESC LIMIT ?",
+			want: &ExplainPlan{
+				Tables: []ExplainTable{
+					{Table: "b", AccessType: "Table scan", EstimatedRows: 12, ActualRows: 12},
+					{Table: "a", AccessType: "Single-row index lookup", Index: "PRIMARY", EstimatedRows: 1, ActualRows: 4},
+				},
+				Analyzed: true,
+				Raw:      mysqlExplainTree,
+			},
+		},
+		{
+			name:      "PostgreSQL count",
+			dialect:   PostgreSQL,
+			opts:      []ExplainOption{ExplainCount()},
+			values:    [][]any{{postgresExplainJSON}},
+			wantQuery: "EXPLAIN (FORMAT JSON) SELECT COUNT(*) AS count FROM books WHERE status = $1",
+			want: &ExplainPlan{
+				Tables: []ExplainTable{
+					{Table: "books", AccessType: "Seq Scan", EstimatedRows: 1200},
+					{Table: "authors", AccessType: "Index Scan", Index: "authors_pkey", EstimatedRows: 5},
+				},
+				Raw: postgresExplainJSON,
+			},
+		},
+		{
+			name:      "PostgreSQL analyze",
+			dialect:   PostgreSQL,
+			analyze:   true,
+			values:    [][]any{{postgresExplainJSON}},
+			wantQuery: "EXPLAIN (ANALYZE, FORMAT JSON) SELECT id, name FROM books WHERE status = $1 ORDER BY created_at DESC LIMIT $2",
+			want: &ExplainPlan{
+				Tables: []ExplainTable{
+					{Table: "books", AccessType: "Seq Scan", EstimatedRows: 1200, ActualRows: 1100},
+					{Table: "authors", AccessType: "Index Scan", Index: "authors_pkey", EstimatedRows: 5, ActualRows: 5},
+				},
+				Analyzed: true,
+				Raw:      postgresExplainJSON,
+			},
+		},
+		{
+			name:    "SQLite",
+			dialect: SQLite,
+			values: [][]any{
+				{int64(3), int64(0), int64(0), "SCAN b"},
+				{int64(5), int64(0), int64(0), "SEARCH a USING INTEGER PRIMARY KEY (rowid=?)"},
+				{int64(7), int64(0), int64(0), "SEARCH TABLE reviews AS r USING COVERING INDEX idx_book (book_id=?)"},
+				{int64(9), int64(0), int64(0), "USE TEMP B-TREE FOR ORDER BY"},
+			},
+			wantQuery: "EXPLAIN QUERY PLAN SELECT id, name FROM books WHERE status = ? ORDER BY created_at DESC LIMIT ?",
+			want: &ExplainPlan{
+				Tables: []ExplainTable{
+					{Table: "b", AccessType: "SCAN"},
+					{Table: "a", AccessType: "SEARCH", Index: "INTEGER PRIMARY KEY"},
+					{Table: "reviews", AccessType: "SEARCH", Index: "idx_book"},
+				},
+				Raw: "SCAN b\nSEARCH a USING INTEGER PRIMARY KEY (rowid=?)\nSEARCH TABLE reviews AS r USING COVERING INDEX idx_book (book_id=?)\nUSE TEMP B-TREE FOR ORDER BY",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db := &RecordingDB{rows: tt.values}
+			q := newTestQuery(db, "books", WithDialect(tt.dialect))
+			explain := q.Explain
+			if tt.analyze {
+				explain = q.ExplainAnalyze
+			}
+			got, err := explain(t.Context(), tt.opts...)
+			if err != nil {
+				t.Fatalf("explain error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("explain = %+v, want %+v", got, tt.want)
+			}
+			if want := []string{tt.wantQuery}; !reflect.DeepEqual(db.prepared, want) {
+				t.Errorf("explain queries = %v, want %v", db.prepared, want)
+			}
+		})
+	}
+}
+
+func TestExplainPlan_Table(t *testing.T) {
+	t.Parallel()
+	plan := &ExplainPlan{Tables: []ExplainTable{{Table: "books", AccessType: "ALL"}, {Table: "authors", AccessType: "ref"}}}
+	if got, ok := plan.Table("authors"); !ok || got.AccessType != "ref" {
+		t.Errorf("Table(authors) = %+v, %v, want ref table", got, ok)
+	}
+	if _, ok := plan.Table("reviews"); ok {
+		t.Errorf("Table(reviews) found, want not found")
+	}
+}
+
+func TestQuery_ExplainError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		dialect Dialect
+		analyze bool
+		db      DB
+		wantErr error
+	}{
+		{
+			name:    "unsupported dialect",
+			dialect: SQLServer,
+			db:      &MockDB{},
+			wantErr: ErrExplainUnsupported,
+		},
+		{
+			name:    "unsupported analyze",
+			dialect: SQLite,
+			analyze: true,
+			db:      &MockDB{},
+			wantErr: ErrExplainUnsupported,
+		},
+		{
+			name:    "prepare error",
+			dialect: MySQL,
+			db:      &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return nil, errConditionError }},
+			wantErr: errConditionError,
+		},
+		{
+			name:    "MySQL scan error",
+			dialect: MySQL,
+			db:      &RecordingDB{rows: [][]any{{errSortError}}},
+			wantErr: errSortError,
+		},
+		{
+			name:    "SQLite scan error",
+			dialect: SQLite,
+			db:      &RecordingDB{rows: [][]any{{errSortError}}},
+			wantErr: errSortError,
+		},
+		{
+			name:    "PostgreSQL scan error",
+			dialect: PostgreSQL,
+			db:      &RecordingDB{rows: [][]any{{errSortError}}},
+			wantErr: errSortError,
+		},
+		{
+			name:    "PostgreSQL rows error",
+			dialect: PostgreSQL,
+			db:      &RecordingDB{err: errSortError},
+			wantErr: errSortError,
+		},
+		{
+			name:    "SQLite rows error",
+			dialect: SQLite,
+			db:      &RecordingDB{err: errSortError},
+			wantErr: errSortError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			q := NewWithDB(tt.db, "books", NewFields[TestModel]([]string{"id"}, nil), &TestCondition{}, nil, nil, WithDialect(tt.dialect))
+			explain := q.Explain
+			if tt.analyze {
+				explain = q.ExplainAnalyze
+			}
+			if _, err := explain(t.Context()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("explain error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, dialect := range []Dialect{MySQL, PostgreSQL} {
+		t.Run("invalid JSON of "+dialect.Name(), func(t *testing.T) {
+			t.Parallel()
+			q := NewWithDB(&RecordingDB{rows: [][]any{{"{"}}}, "books", NewFields[TestModel]([]string{"id"}, nil), nil, nil, nil, WithDialect(dialect))
+			if _, err := q.Explain(t.Context()); err == nil {
+				t.Errorf("Explain() error = nil, want error")
+			}
+		})
+	}
+
+	t.Run("statement error", func(t *testing.T) {
+		t.Parallel()
+		q := newTestQuery(&MockDB{}, "jobs", WithDialect(MySQL))
+		q.Condition = lockingTestCondition
+		if _, err := q.Explain(t.Context()); !errors.Is(err, ErrLockingWithoutTx) {
+			t.Errorf("Explain() error = %v, want %v", err, ErrLockingWithoutTx)
+		}
+	})
+}
@@ -1,4 +1,4 @@
-// Package expr provides expression types and builders for SQL WHERE clause conditions.
+// Package expr provides expression types and builders for SQL WHERE clause conditions, and evaluates them in memory.
 package expr
 
 // ConditionExpr represents a SQL condition expression that can be converted to a string
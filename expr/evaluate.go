@@ -0,0 +1,342 @@
+package expr
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnsupportedCondition is returned by Compile for the conditions which can't be evaluated in memory
+	// such as subqueries and row comparisons.
+	ErrUnsupportedCondition = errors.New("condition is not supported by in-memory evaluation")
+	// ErrUnknownField is returned by Compile for the fields which have no accessor.
+	ErrUnknownField = errors.New("unknown field")
+)
+
+// Accessors maps the field names used in conditions to the functions returning the values of the fields of a model.
+// The names must be the same as the conditions, including the table qualifiers if any.
+type Accessors[M any] map[string]func(m *M) any
+
+// Predicate reports whether a model matches a condition compiled by Compile.
+type Predicate[M any] func(m *M) bool
+
+// Filter returns the models matching the predicate in their order.
+func (p Predicate[M]) Filter(models []*M) []*M {
+	result := []*M{}
+	for _, m := range models {
+		if p(m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Compile compiles the condition into a Predicate evaluating it against the models with the accessors
+// in the same way as the SQL built from it. It supports FieldCondition with the bodies of Eq, NotEq, Gt, Gte,
+// Lt, Lte, Like, In, Between, InRange, IsNull and IsNotNull, and Conditions joined by AND or OR.
+//
+// The values of the fields and the conditions are NULL if they are nil, nil pointers, or driver.Valuer
+// returning nil such as invalid sql.NullString. Comparisons with NULL never match like SQL, so only IsNull
+// matches NULL. Pointers and driver.Valuer are dereferenced, and the values are compared as numbers, strings,
+// byte slices, booleans or time.Time. Values of different kinds don't match. Strings are compared by their bytes,
+// so the results may differ from case-insensitive collations of the database.
+// LIKE patterns are matched like PostgreSQL with a deterministic collation: case-sensitively, with % and _
+// wildcards escaped by a backslash. They may match different rows on the other databases, such as MySQL and
+// SQL Server matching case-insensitively with their default collations, SQLite matching ASCII letters
+// case-insensitively without the backslash escape, and SQL Server treating [ and ] as wildcards.
+// Conditions without items and FieldCondition without a body match any model like the empty WHERE clause,
+// while In without values matches no models.
+func Compile[M any](condition ConditionExpr, accessors Accessors[M]) (Predicate[M], error) {
+	return compileCondition(condition, accessors)
+}
+
+// compileCondition compiles the condition recursively.
+func compileCondition[M any](condition ConditionExpr, accessors Accessors[M]) (Predicate[M], error) {
+	switch c := condition.(type) {
+	case nil:
+		return matchAll[M], nil
+	case *Conditions:
+		return compileConditions(c, accessors)
+	case *FieldCondition:
+		if c.Body == nil {
+			return matchAll[M], nil
+		}
+		accessor, ok := accessors[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, c.Name)
+		}
+		match, err := compileBody(c.Body)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *M) bool { return match(accessor(m)) }, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedCondition, condition)
+	}
+}
+
+// matchAll is the predicate of empty conditions.
+func matchAll[M any](*M) bool { return true }
+
+// compileConditions compiles the items of the conditions joined by AND or OR.
+func compileConditions[M any](c *Conditions, accessors Accessors[M]) (Predicate[M], error) {
+	if len(c.items) == 0 {
+		return matchAll[M], nil
+	}
+	predicates := make([]Predicate[M], 0, len(c.items))
+	for _, item := range c.items {
+		p, err := compileCondition(item, accessors)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, p)
+	}
+	switch strings.ToUpper(strings.TrimSpace(c.connective)) {
+	case "AND":
+		return func(m *M) bool {
+			for _, p := range predicates {
+				if !p(m) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "OR":
+		return func(m *M) bool {
+			for _, p := range predicates {
+				if p(m) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: connective %q", ErrUnsupportedCondition, c.connective)
+	}
+}
+
+// compileBody compiles the condition body into a function matching a value of the field.
+func compileBody(body FieldConditionBody) (func(v any) bool, error) {
+	switch b := body.(type) {
+	case *fieldComparison:
+		return compileComparison(b)
+	case *fieldInExpr:
+		return func(v any) bool {
+			for _, value := range b.values {
+				if c, ok := compareValues(v, value); ok && c == 0 {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *fieldBetweenExpr:
+		return func(v any) bool {
+			start, ok1 := compareValues(v, b.start)
+			end, ok2 := compareValues(v, b.end)
+			return ok1 && ok2 && start >= 0 && end <= 0
+		}, nil
+	case *inRangeExpr:
+		return func(v any) bool {
+			start, ok1 := compareValues(v, b.start)
+			end, ok2 := compareValues(v, b.end)
+			return ok1 && ok2 && start >= 0 && end < 0
+		}, nil
+	case *fieldStaticExpr:
+		switch b.value {
+		case "IS NULL":
+			return func(v any) bool { return isNullValue(v) }, nil
+		case "IS NOT NULL":
+			return func(v any) bool { return !isNullValue(v) }, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedCondition, body)
+}
+
+// compileComparison compiles the comparison with its operator.
+func compileComparison(c *fieldComparison) (func(v any) bool, error) {
+	var match func(int) bool
+	switch c.operator {
+	case "=":
+		match = func(r int) bool { return r == 0 }
+	case "<>":
+		match = func(r int) bool { return r != 0 }
+	case ">":
+		match = func(r int) bool { return r > 0 }
+	case ">=":
+		match = func(r int) bool { return r >= 0 }
+	case "<":
+		match = func(r int) bool { return r < 0 }
+	case "<=":
+		match = func(r int) bool { return r <= 0 }
+	case "LIKE":
+		return compileLike(c.value), nil
+	default:
+		return nil, fmt.Errorf("%w: operator %s", ErrUnsupportedCondition, c.operator)
+	}
+	return func(v any) bool {
+		r, ok := compareValues(v, c.value)
+		return ok && match(r)
+	}, nil
+}
+
+// compileLike compiles the LIKE pattern into a function matching strings case-sensitively like PostgreSQL.
+func compileLike(pattern any) func(v any) bool {
+	p, ok := normalizeValue(pattern).(string)
+	if !ok {
+		return func(any) bool { return false }
+	}
+	var sb strings.Builder
+	sb.WriteString(`^(?s:`)
+	escaped := false
+	for _, r := range p {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			sb.WriteString(".*")
+		case r == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		sb.WriteString(regexp.QuoteMeta(`\`))
+	}
+	sb.WriteString(`)$`)
+	re := regexp.MustCompile(sb.String())
+	return func(v any) bool {
+		s, ok := normalizeValue(v).(string)
+		return ok && re.MatchString(s)
+	}
+}
+
+// isNullValue reports whether the value is NULL.
+func isNullValue(v any) bool {
+	return normalizeValue(v) == nil
+}
+
+// compareValues compares a and b. It returns false if either is NULL or they are not comparable.
+func compareValues(a, b any) (int, bool) {
+	a, b = normalizeValue(a), normalizeValue(b)
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return cmp.Compare(x, y), true
+		case uint64:
+			if x < 0 {
+				return -1, true
+			}
+			return cmp.Compare(uint64(x), y), true
+		case float64:
+			return cmp.Compare(float64(x), y), true
+		}
+	case uint64:
+		switch y := b.(type) {
+		case int64:
+			if y < 0 {
+				return 1, true
+			}
+			return cmp.Compare(x, uint64(y)), true
+		case uint64:
+			return cmp.Compare(x, y), true
+		case float64:
+			return cmp.Compare(float64(x), y), true
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return cmp.Compare(x, float64(y)), true
+		case uint64:
+			return cmp.Compare(x, float64(y)), true
+		case float64:
+			return cmp.Compare(x, y), true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			return compareBool(x, y), true
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y), true
+		}
+	}
+	return 0, false
+}
+
+// compareBool compares booleans as FALSE < TRUE.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// normalizeValue converts the value into nil for NULL, int64, uint64, float64, string, bool or time.Time.
+// Byte slices are converted into strings. The other values are returned as they are.
+func normalizeValue(v any) any {
+	for {
+		switch x := v.(type) {
+		case nil:
+			return nil
+		case time.Time:
+			return x
+		case driver.Valuer:
+			rv := reflect.ValueOf(x)
+			if rv.Kind() == reflect.Pointer && rv.IsNil() {
+				return nil
+			}
+			value, err := x.Value()
+			if err != nil {
+				return nil
+			}
+			v = value
+			continue
+		}
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() { //nolint:exhaustive
+		case reflect.Pointer, reflect.Interface:
+			if rv.IsNil() {
+				return nil
+			}
+			v = rv.Elem().Interface()
+			continue
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return rv.Uint()
+		case reflect.Float32, reflect.Float64:
+			return rv.Float()
+		case reflect.String:
+			return rv.String()
+		case reflect.Bool:
+			return rv.Bool()
+		case reflect.Slice:
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				if rv.IsNil() {
+					return nil
+				}
+				return string(rv.Bytes())
+			}
+		}
+		return v
+	}
+}
@@ -0,0 +1,227 @@
+package expr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type evalBookType string
+
+type evalBook struct {
+	ID        int64
+	Title     string
+	Price     float64
+	Stock     uint32
+	Type      evalBookType
+	Note      sql.NullString
+	Published *time.Time
+	Code      []byte
+	OnSale    bool
+}
+
+var evalAccessors = Accessors[evalBook]{
+	"id":        func(b *evalBook) any { return b.ID },
+	"title":     func(b *evalBook) any { return b.Title },
+	"price":     func(b *evalBook) any { return b.Price },
+	"stock":     func(b *evalBook) any { return b.Stock },
+	"type":      func(b *evalBook) any { return b.Type },
+	"note":      func(b *evalBook) any { return b.Note },
+	"published": func(b *evalBook) any { return b.Published },
+	"code":      func(b *evalBook) any { return b.Code },
+	"on_sale":   func(b *evalBook) any { return b.OnSale },
+}
+
+func evalBooks() []*evalBook {
+	published := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	return []*evalBook{
+		{
+			ID: 1, Title: "Go 101", Price: 30, Stock: 5, Type: "BOOK",
+			Note: sql.NullString{String: "new", Valid: true}, Published: &published, Code: []byte("A-1"), OnSale: true,
+		},
+		{ID: 2, Title: "Learning Go", Price: 45.5, Stock: 0, Type: "BOOK", Code: []byte("B_2")},
+		{ID: 3, Title: "Monthly 50%", Price: 8, Stock: 12, Type: "MAGAZINE", Note: sql.NullString{String: "", Valid: true}},
+	}
+}
+
+func evalIDs(books []*evalBook) []int64 {
+	ids := []int64{}
+	for _, b := range books {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		condition ConditionExpr
+		want      []int64
+	}{
+		{name: "Eq", condition: Field("id", Eq(2)), want: []int64{2}},
+		{name: "Eq with named string", condition: Field("type", Eq(evalBookType("MAGAZINE"))), want: []int64{3}},
+		{name: "Eq with string for named string", condition: Field("type", Eq("BOOK")), want: []int64{1, 2}},
+		{name: "Eq with different kinds", condition: Field("id", Eq("2")), want: []int64{}},
+		{name: "Eq with bool", condition: Field("on_sale", Eq(true)), want: []int64{1}},
+		{name: "Eq with byte slice", condition: Field("code", Eq("A-1")), want: []int64{1}},
+		{name: "NotEq", condition: Field("type", NotEq("BOOK")), want: []int64{3}},
+		{name: "NotEq with NULL field", condition: Field("note", NotEq("new")), want: []int64{3}},
+		{name: "Gt", condition: Field("price", Gt(8)), want: []int64{1, 2}},
+		{name: "Gt with float", condition: Field("id", Gt(1.5)), want: []int64{2, 3}},
+		{name: "Gt with unsigned field", condition: Field("stock", Gt(int8(-1))), want: []int64{1, 2, 3}},
+		{name: "Gt with unsigned value", condition: Field("id", Gt(uint(2))), want: []int64{3}},
+		{name: "Gt with bool", condition: Field("on_sale", Gt(false)), want: []int64{1}},
+		{name: "Gte", condition: Field("price", Gte(int64(30))), want: []int64{1, 2}},
+		{name: "Lt", condition: Field("stock", Lt(uint64(5))), want: []int64{2}},
+		{name: "Lt with float", condition: Field("stock", Lt(5.5)), want: []int64{1, 2}},
+		{name: "Lt with negative", condition: Field("stock", Lt(-1)), want: []int64{}},
+		{name: "Lte", condition: Field("title", Lte("Learning Go")), want: []int64{1, 2}},
+		{name: "Lte with NULL value", condition: Field("id", Lte(nil)), want: []int64{}},
+		{name: "Gt with time", condition: Field("published", Gt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))), want: []int64{1}},
+		{name: "Like contains", condition: Field("title", LikeContains("Go")), want: []int64{1, 2}},
+		{name: "Like is case-sensitive", condition: Field("title", LikeContains("go")), want: []int64{}},
+		{name: "Like with underscore", condition: Field("title", Like("Go _01")), want: []int64{1}},
+		{name: "Like with escaped percent", condition: Field("title", LikeEndsWith(`50\%`)), want: []int64{3}},
+		{name: "Like with escaped underscore", condition: Field("code", Like(`_\_2`)), want: []int64{2}},
+		{name: "Like with trailing backslash", condition: Field("title", Like(`Go\`)), want: []int64{}},
+		{name: "Like with regexp characters", condition: Field("code", Like("A.1")), want: []int64{}},
+		{name: "Like on NULL", condition: Field("note", Like("%")), want: []int64{1, 3}},
+		{name: "Like on number", condition: Field("id", Like("%")), want: []int64{}},
+		{name: "Like with NULL pattern", condition: Field("title", newCompare("LIKE", nil)), want: []int64{}},
+		{name: "In", condition: Field("id", In(1, int32(3), 5)), want: []int64{1, 3}},
+		{name: "In with NULL", condition: Field("note", In("new", nil)), want: []int64{1}},
+		{name: "In without values", condition: Field("id", In()), want: []int64{}},
+		{name: "EqOrIn", condition: Field("id", EqOrIn(2)), want: []int64{2}},
+		{name: "Between", condition: Field("price", Between(8, 30)), want: []int64{1, 3}},
+		{name: "Between with pointers", condition: Field("id", Between(ptr(2), ptr(3))), want: []int64{2, 3}},
+		{name: "Between with NULL", condition: Field("id", Between(nil, 3)), want: []int64{}},
+		{name: "InRange", condition: Field("price", InRange(8, 30)), want: []int64{3}},
+		{name: "InRange with NULL", condition: Field("price", InRange(8, (*int)(nil))), want: []int64{}},
+		{name: "IsNull", condition: Field("note", IsNull()), want: []int64{2}},
+		{name: "IsNull with pointer", condition: Field("published", IsNull()), want: []int64{2, 3}},
+		{name: "IsNull with byte slice", condition: Field("code", IsNull()), want: []int64{3}},
+		{name: "IsNotNull", condition: Field("note", IsNotNull()), want: []int64{1, 3}},
+		{name: "Field without body", condition: &FieldCondition{Name: "unknown"}, want: []int64{1, 2, 3}},
+		{
+			name:      "And",
+			condition: And(Field("type", Eq("BOOK")), Field("price", Gt(40))),
+			want:      []int64{2},
+		},
+		{
+			name:      "Or",
+			condition: Or(Field("type", Eq("MAGAZINE")), Field("on_sale", Eq(true))),
+			want:      []int64{1, 3},
+		},
+		{
+			name: "nested",
+			condition: And(
+				Field("stock", Gt(0)),
+				Or(Field("title", LikeStartsWith("Go")), Field("note", IsNull()), Field("note", Eq(""))),
+			),
+			want: []int64{1, 3},
+		},
+		{name: "empty conditions", condition: And(), want: []int64{1, 2, 3}},
+		{name: "nil", condition: nil, want: []int64{1, 2, 3}},
+		{name: "lower case connective", condition: NewConditions(" and ", Field("id", Gt(1)), Field("id", Lt(3))), want: []int64{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := Compile(tt.condition, evalAccessors)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if got := evalIDs(p.Filter(evalBooks())); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errValuer is a driver.Valuer which fails.
+type errValuer struct{}
+
+func (errValuer) Value() (driver.Value, error) { return nil, errors.New("value error") }
+
+func TestCompile_Values(t *testing.T) {
+	t.Parallel()
+	type model struct{ V any }
+	accessors := Accessors[model]{"v": func(m *model) any { return m.V }}
+	tests := []struct {
+		name      string
+		value     any
+		condition FieldConditionBody
+		want      bool
+	}{
+		{name: "valid Valuer", value: sql.NullInt64{Int64: 3, Valid: true}, condition: Eq(3), want: true},
+		{name: "invalid Valuer", value: sql.NullInt64{}, condition: IsNull(), want: true},
+		{name: "nil Valuer pointer", value: (*sql.NullInt64)(nil), condition: IsNull(), want: true},
+		{name: "Valuer pointer", value: &sql.NullTime{Time: time.Unix(0, 0), Valid: true}, condition: Eq(time.Unix(0, 0)), want: true},
+		{name: "failing Valuer", value: errValuer{}, condition: IsNull(), want: true},
+		{name: "Valuer as value", value: int64(3), condition: Eq(sql.NullInt64{Int64: 3, Valid: true}), want: true},
+		{name: "pointer to pointer", value: ptr(ptr("a")), condition: Eq("a"), want: true},
+		{name: "interface pointer", value: ptr[any]("a"), condition: Eq("a"), want: true},
+		{name: "float32", value: float32(1.5), condition: Eq(1.5), want: true},
+		{name: "uint and float", value: uint(2), condition: Lt(2.5), want: true},
+		{name: "float and uint", value: 2.5, condition: Gt(uint(2)), want: true},
+		{name: "negative int and uint", value: -1, condition: Lt(uint(0)), want: true},
+		{name: "time and string", value: time.Unix(0, 0), condition: Eq("1970-01-01"), want: false},
+		{name: "bool and string", value: true, condition: Eq("true"), want: false},
+		{name: "string and number", value: "1", condition: Eq(1), want: false},
+		{name: "uint and string", value: uint(1), condition: Eq("1"), want: false},
+		{name: "float and string", value: 1.0, condition: Eq("1"), want: false},
+		{name: "struct", value: struct{}{}, condition: Eq(struct{}{}), want: false},
+		{name: "slice", value: []int{1}, condition: IsNotNull(), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := Compile(Field("v", tt.condition), accessors)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if got := p(&model{V: tt.value}); got != tt.want {
+				t.Errorf("predicate(%#v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+type evalSubquery struct{}
+
+func (evalSubquery) BuildRaw() (string, []any) { return "SELECT id FROM authors", nil }
+
+func TestCompile_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		condition ConditionExpr
+		wantErr   error
+	}{
+		{name: "unknown field", condition: Field("unknown", Eq(1)), wantErr: ErrUnknownField},
+		{name: "unknown field in conditions", condition: Or(Field("id", Eq(1)), Field("unknown", Eq(1))), wantErr: ErrUnknownField},
+		{name: "row comparison", condition: RowGt([]string{"id"}, 1), wantErr: ErrUnsupportedCondition},
+		{name: "exists", condition: Exists(evalSubquery{}), wantErr: ErrUnsupportedCondition},
+		{name: "in subquery", condition: Field("id", InSubquery(evalSubquery{})), wantErr: ErrUnsupportedCondition},
+		{name: "unknown operator", condition: Field("id", newCompare("<=>", 1)), wantErr: ErrUnsupportedCondition},
+		{name: "unknown static expression", condition: Field("id", &fieldStaticExpr{value: "IS TRUE"}), wantErr: ErrUnsupportedCondition},
+		{name: "unknown connective", condition: NewConditions(" XOR ", Field("id", Eq(1))), wantErr: ErrUnsupportedCondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := Compile(tt.condition, evalAccessors); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compile() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package expr
+
+import "strings"
+
+// rowComparison represents a comparison between row values such as (a, b) > (?, ?).
+type rowComparison struct {
+	fields   []string
+	operator string
+	values   []any
+}
+
+var _ ConditionExpr = (*rowComparison)(nil)
+
+// newRowCompare creates a new row value comparison with the specified operator.
+func newRowCompare(operator string, fields []string, values []any) *rowComparison {
+	return &rowComparison{fields: fields, operator: operator, values: values}
+}
+
+// String returns the SQL representation of the row value comparison.
+// Returns an empty string if no fields are provided.
+func (c *rowComparison) String() string {
+	if len(c.fields) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(c.fields, ", ") + ") " + c.operator + " (" + strings.Repeat("?, ", len(c.values)-1) + "?)"
+}
+
+// Values returns the values of the right hand side row.
+func (c *rowComparison) Values() []any {
+	if len(c.fields) == 0 {
+		return []any{}
+	}
+	return c.values
+}
+
+// RowGt creates a row value comparison (fields...) > (values...).
+func RowGt(fields []string, values ...any) ConditionExpr { return newRowCompare(">", fields, values) } //nolint:ireturn
+
+// RowGte creates a row value comparison (fields...) >= (values...).
+func RowGte(fields []string, values ...any) ConditionExpr { return newRowCompare(">=", fields, values) } //nolint:ireturn
+
+// RowLt creates a row value comparison (fields...) < (values...).
+func RowLt(fields []string, values ...any) ConditionExpr { return newRowCompare("<", fields, values) } //nolint:ireturn
+
+// RowLte creates a row value comparison (fields...) <= (values...).
+func RowLte(fields []string, values ...any) ConditionExpr { return newRowCompare("<=", fields, values) } //nolint:ireturn
@@ -0,0 +1,60 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowComparison(t *testing.T) {
+	t.Parallel()
+	fields := []string{"title", "book_id"}
+	tests := []struct {
+		name       string
+		condition  ConditionExpr
+		wantString string
+		wantValues []any
+	}{
+		{
+			name:       "RowGt",
+			condition:  RowGt(fields, "Go", 10),
+			wantString: "(title, book_id) > (?, ?)",
+			wantValues: []any{"Go", 10},
+		},
+		{
+			name:       "RowGte",
+			condition:  RowGte(fields, "Go", 10),
+			wantString: "(title, book_id) >= (?, ?)",
+			wantValues: []any{"Go", 10},
+		},
+		{
+			name:       "RowLt",
+			condition:  RowLt(fields, "Go", 10),
+			wantString: "(title, book_id) < (?, ?)",
+			wantValues: []any{"Go", 10},
+		},
+		{
+			name:       "RowLte",
+			condition:  RowLte([]string{"yr"}, 2020),
+			wantString: "(yr) <= (?)",
+			wantValues: []any{2020},
+		},
+		{
+			name:       "No fields",
+			condition:  RowGt(nil),
+			wantString: "",
+			wantValues: []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.condition.String(); got != tt.wantString {
+				t.Errorf("String() = %v, want %v", got, tt.wantString)
+			}
+			if got := tt.condition.Values(); !reflect.DeepEqual(got, tt.wantValues) {
+				t.Errorf("Values() = %v, want %v", got, tt.wantValues)
+			}
+		})
+	}
+}
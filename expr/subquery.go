@@ -0,0 +1,139 @@
+package expr
+
+// Subquery is a statement embedded into a condition.
+// *statement.Statement implements Subquery.
+type Subquery interface {
+	// BuildRaw returns the SQL with generic ? placeholders and the placeholder values.
+	BuildRaw() (string, []any)
+}
+
+// rawErrReporter is a Subquery which reports the error of the SQL built by BuildRaw,
+// such as an unknown join required by *statement.Statement.
+type rawErrReporter interface {
+	RawErr() error
+}
+
+// SubqueryErr returns the error of the subquery if it has a RawErr method like *statement.Statement.
+// It returns nil for the other subqueries.
+func SubqueryErr(sub Subquery) error {
+	if r, ok := sub.(rawErrReporter); ok {
+		return r.RawErr()
+	}
+	return nil
+}
+
+// Subqueries returns the subqueries embedded in the condition with Exists, NotExists,
+// InSubquery and NotInSubquery.
+func Subqueries(condition ConditionExpr) []Subquery {
+	switch c := condition.(type) {
+	case *Conditions:
+		var subs []Subquery
+		for _, item := range c.items {
+			subs = append(subs, Subqueries(item)...)
+		}
+		return subs
+	case *FieldCondition:
+		if body, ok := c.Body.(*fieldSubqueryExpr); ok {
+			return []Subquery{body.sub}
+		}
+	case *existsExpr:
+		return []Subquery{c.sub}
+	}
+	return nil
+}
+
+// MapSubqueries returns a copy of the condition whose subqueries embedded with Exists, NotExists,
+// InSubquery and NotInSubquery are replaced with the results of f. The condition itself is not modified.
+// The statements embedding the condition use it to build the subqueries with their own dialect.
+func MapSubqueries(condition ConditionExpr, f func(Subquery) Subquery) ConditionExpr { //nolint:ireturn
+	switch c := condition.(type) {
+	case *Conditions:
+		items := make([]ConditionExpr, len(c.items))
+		for i, item := range c.items {
+			items[i] = MapSubqueries(item, f)
+		}
+		return NewConditions(c.connective, items...)
+	case *FieldCondition:
+		if body, ok := c.Body.(*fieldSubqueryExpr); ok {
+			return &FieldCondition{Name: c.Name, Body: &fieldSubqueryExpr{sub: f(body.sub), operator: body.operator}}
+		}
+	case *existsExpr:
+		return &existsExpr{sub: f(c.sub), operator: c.operator}
+	}
+	return condition
+}
+
+// buildSubquery builds the subquery and returns the SQL and the placeholder values which are never nil.
+func buildSubquery(sub Subquery) (string, []any) {
+	query, values := sub.BuildRaw()
+	if values == nil {
+		values = []any{}
+	}
+	return query, values
+}
+
+// fieldSubqueryExpr represents an IN or NOT IN condition with a subquery.
+type fieldSubqueryExpr struct {
+	sub      Subquery
+	operator string
+}
+
+var _ FieldConditionBody = (*fieldSubqueryExpr)(nil)
+
+// Build constructs the IN SQL clause with the subquery for the given field.
+func (c *fieldSubqueryExpr) Build(field string) string {
+	query, _ := buildSubquery(c.sub)
+	return field + " " + c.operator + " (" + query + ")"
+}
+
+// Values returns the placeholder values of the subquery.
+func (c *fieldSubqueryExpr) Values() []any {
+	_, values := buildSubquery(c.sub)
+	return values
+}
+
+// InSubquery creates a field condition checking if the field value is in the result of the subquery
+// like `author_id IN (SELECT id FROM authors WHERE ...)`.
+// The subquery is built with the dialect of the statement when the statement embedding the condition is built.
+func InSubquery(sub Subquery) FieldConditionBody { //nolint:ireturn
+	return &fieldSubqueryExpr{sub: sub, operator: "IN"}
+}
+
+// NotInSubquery creates a field condition checking if the field value is not in the result of the subquery.
+// The subquery is built with the dialect of the statement when the statement embedding the condition is built.
+func NotInSubquery(sub Subquery) FieldConditionBody { //nolint:ireturn
+	return &fieldSubqueryExpr{sub: sub, operator: "NOT IN"}
+}
+
+// existsExpr represents an EXISTS or NOT EXISTS condition.
+type existsExpr struct {
+	sub      Subquery
+	operator string
+}
+
+var _ ConditionExpr = (*existsExpr)(nil)
+
+// String returns the EXISTS SQL clause with the subquery.
+func (c *existsExpr) String() string {
+	query, _ := buildSubquery(c.sub)
+	return c.operator + " (" + query + ")"
+}
+
+// Values returns the placeholder values of the subquery.
+func (c *existsExpr) Values() []any {
+	_, values := buildSubquery(c.sub)
+	return values
+}
+
+// Exists creates a condition which is true if the subquery returns any rows
+// like `EXISTS (SELECT 1 FROM books WHERE books.author_id = authors.id)`.
+// The subquery is built with the dialect of the statement when the statement embedding the condition is built.
+func Exists(sub Subquery) ConditionExpr { //nolint:ireturn
+	return &existsExpr{sub: sub, operator: "EXISTS"}
+}
+
+// NotExists creates a condition which is true if the subquery returns no rows.
+// The subquery is built with the dialect of the statement when the statement embedding the condition is built.
+func NotExists(sub Subquery) ConditionExpr { //nolint:ireturn
+	return &existsExpr{sub: sub, operator: "NOT EXISTS"}
+}
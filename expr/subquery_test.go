@@ -0,0 +1,179 @@
+package expr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testSubquery struct {
+	query  string
+	values []any
+}
+
+func (s *testSubquery) BuildRaw() (string, []any) { return s.query, s.values }
+
+func TestFieldSubqueryExpr(t *testing.T) {
+	t.Parallel()
+	field := "author_id"
+	sub := &testSubquery{query: "SELECT id FROM authors WHERE country = ?", values: []any{"JP"}}
+	tests := []struct {
+		name       string
+		condition  FieldConditionBody
+		wantString string
+		wantValues []any
+	}{
+		{
+			name:       "InSubquery",
+			condition:  InSubquery(sub),
+			wantString: "author_id IN (SELECT id FROM authors WHERE country = ?)",
+			wantValues: []any{"JP"},
+		},
+		{
+			name:       "NotInSubquery",
+			condition:  NotInSubquery(sub),
+			wantString: "author_id NOT IN (SELECT id FROM authors WHERE country = ?)",
+			wantValues: []any{"JP"},
+		},
+		{
+			name:       "InSubquery without values",
+			condition:  InSubquery(&testSubquery{query: "SELECT id FROM authors"}),
+			wantString: "author_id IN (SELECT id FROM authors)",
+			wantValues: []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.condition.Build(field); got != tt.wantString {
+				t.Errorf("Build() = %v, want %v", got, tt.wantString)
+			}
+			if got := tt.condition.Values(); !reflect.DeepEqual(got, tt.wantValues) {
+				t.Errorf("Values() = %v, want %v", got, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestExistsExpr(t *testing.T) {
+	t.Parallel()
+	sub := &testSubquery{query: "SELECT 1 FROM books WHERE books.author_id = authors.id AND books.yr >= ?", values: []any{2000}}
+	tests := []struct {
+		name       string
+		condition  ConditionExpr
+		wantString string
+		wantValues []any
+	}{
+		{
+			name:       "Exists",
+			condition:  Exists(sub),
+			wantString: "EXISTS (SELECT 1 FROM books WHERE books.author_id = authors.id AND books.yr >= ?)",
+			wantValues: []any{2000},
+		},
+		{
+			name:       "NotExists",
+			condition:  NotExists(sub),
+			wantString: "NOT EXISTS (SELECT 1 FROM books WHERE books.author_id = authors.id AND books.yr >= ?)",
+			wantValues: []any{2000},
+		},
+		{
+			name:       "Exists in Or",
+			condition:  Or(Field("status", Eq("active")), Exists(sub)),
+			wantString: "status = ? OR EXISTS (SELECT 1 FROM books WHERE books.author_id = authors.id AND books.yr >= ?)",
+			wantValues: []any{"active", 2000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.condition.String(); got != tt.wantString {
+				t.Errorf("String() = %v, want %v", got, tt.wantString)
+			}
+			if got := tt.condition.Values(); !reflect.DeepEqual(got, tt.wantValues) {
+				t.Errorf("Values() = %v, want %v", got, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestSubqueries(t *testing.T) {
+	t.Parallel()
+	authors := &testSubquery{query: "SELECT id FROM authors"}
+	books := &testSubquery{query: "SELECT 1 FROM books"}
+	tests := []struct {
+		name      string
+		condition ConditionExpr
+		want      []Subquery
+	}{
+		{name: "Exists", condition: Exists(books), want: []Subquery{books}},
+		{name: "NotExists", condition: NotExists(books), want: []Subquery{books}},
+		{name: "InSubquery", condition: Field("author_id", InSubquery(authors)), want: []Subquery{authors}},
+		{
+			name:      "NotInSubquery and Exists in Or",
+			condition: Or(Field("status", Eq("active")), Field("author_id", NotInSubquery(authors)), And(Exists(books))),
+			want:      []Subquery{authors, books},
+		},
+		{name: "field condition", condition: Field("id", Eq(1)), want: nil},
+		{name: "nil", condition: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Subqueries(tt.condition); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Subqueries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapSubqueries(t *testing.T) {
+	t.Parallel()
+	authors := &testSubquery{query: "SELECT id FROM authors WHERE country = ?", values: []any{"JP"}}
+	books := &testSubquery{query: "SELECT 1 FROM books"}
+	condition := And(
+		Field("status", Eq("active")),
+		Or(Field("author_id", InSubquery(authors)), NotExists(books)),
+	)
+	mapped := MapSubqueries(condition, func(sub Subquery) Subquery {
+		query, values := sub.BuildRaw()
+		return &testSubquery{query: query + " LIMIT ?", values: append(values, 1)}
+	})
+
+	wantString := "status = ? AND (author_id IN (SELECT id FROM authors WHERE country = ? LIMIT ?) OR NOT EXISTS (SELECT 1 FROM books LIMIT ?))"
+	if got := mapped.String(); got != wantString {
+		t.Errorf("String() = %v, want %v", got, wantString)
+	}
+	if got, want := mapped.Values(), []any{"active", "JP", 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %v, want %v", got, want)
+	}
+	// The original condition is not modified.
+	wantString = "status = ? AND (author_id IN (SELECT id FROM authors WHERE country = ?) OR NOT EXISTS (SELECT 1 FROM books))"
+	if got := condition.String(); got != wantString {
+		t.Errorf("String() = %v, want %v", got, wantString)
+	}
+}
+
+// failingSubquery is a Subquery reporting an error like *statement.Statement with an unknown join.
+type failingSubquery struct {
+	testSubquery
+	err error
+}
+
+func (s *failingSubquery) RawErr() error { return s.err }
+
+func TestSubqueryErr(t *testing.T) {
+	t.Parallel()
+	errSubquery := errors.New("subquery error")
+	if err := SubqueryErr(&failingSubquery{err: errSubquery}); !errors.Is(err, errSubquery) {
+		t.Errorf("SubqueryErr() = %v, want %v", err, errSubquery)
+	}
+	if err := SubqueryErr(&failingSubquery{}); err != nil {
+		t.Errorf("SubqueryErr() = %v, want nil", err)
+	}
+	if err := SubqueryErr(&testSubquery{query: "SELECT 1"}); err != nil {
+		t.Errorf("SubqueryErr() = %v, want nil", err)
+	}
+}
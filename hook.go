@@ -0,0 +1,258 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// QueryKind is the kind of the query executed by a Query.
+type QueryKind string
+
+const (
+	// QueryKindCount is the kind of the COUNT query executed by Count and Page.
+	QueryKindCount QueryKind = "count"
+	// QueryKindList is the kind of the SELECT query executed by List, Rows, All and Page.
+	QueryKindList QueryKind = "list"
+	// QueryKindFirst is the kind of the SELECT query executed by First and FirstRow.
+	QueryKindFirst QueryKind = "first"
+	// QueryKindAggregate is the kind of the aggregate query executed by Aggregate and CountBy.
+	QueryKindAggregate QueryKind = "aggregate"
+	// QueryKindExplain is the kind of the EXPLAIN query executed by Explain and ExplainAnalyze.
+	QueryKindExplain QueryKind = "explain"
+)
+
+// QueryEvent describes a query executed by a Query. It is passed to the hooks of the query.
+type QueryEvent struct {
+	// Kind is the kind of the query.
+	Kind QueryKind
+	// SQL is the executed SQL.
+	SQL string
+	// Args are the arguments of the SQL.
+	Args []any
+	// Duration is the time from BeforeQuery until the result is consumed,
+	// that is, the row is scanned or the rows are closed. It is set for AfterQuery.
+	Duration time.Duration
+	// Rows is the number of rows returned by the query. It is set for AfterQuery.
+	Rows int64
+	// Err is the error of the query, including sql.ErrNoRows of First. It is set for AfterQuery.
+	Err error
+}
+
+// Hook observes the queries executed by a Query, for logging, tracing and metrics.
+// Hooks must be safe for concurrent use because PageConcurrently runs queries concurrently.
+// Statements returned by RowsStatement and CountStatement are executed by the caller, so they are not observed.
+type Hook interface {
+	// BeforeQuery is called before the query is executed. The returned context is used to execute the query
+	// and passed to AfterQuery, so a tracer can start a span in it.
+	BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+	// AfterQuery is called after the result of the query is consumed or the query fails.
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// HookFuncs is a Hook calling the functions. Nil functions are skipped.
+type HookFuncs struct {
+	Before func(ctx context.Context, event *QueryEvent) context.Context
+	After  func(ctx context.Context, event *QueryEvent)
+}
+
+var _ Hook = HookFuncs{}
+
+// BeforeQuery implements Hook.
+func (h HookFuncs) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	if h.Before != nil {
+		return h.Before(ctx, event)
+	}
+	return ctx
+}
+
+// AfterQuery implements Hook.
+func (h HookFuncs) AfterQuery(ctx context.Context, event *QueryEvent) {
+	if h.After != nil {
+		h.After(ctx, event)
+	}
+}
+
+// registeredHook is a global hook. Its pointer identifies it to unregister.
+type registeredHook struct {
+	Hook
+}
+
+var globalHooks struct {
+	mu    sync.RWMutex
+	hooks []*registeredHook
+}
+
+// RegisterHook registers the hook called for the queries of all Query instances
+// before the hooks of each query. It returns the function to unregister the hook.
+func RegisterHook(h Hook) (unregister func()) {
+	r := &registeredHook{Hook: h}
+	globalHooks.mu.Lock()
+	defer globalHooks.mu.Unlock()
+	globalHooks.hooks = append(globalHooks.hooks, r)
+	return func() {
+		globalHooks.mu.Lock()
+		defer globalHooks.mu.Unlock()
+		for i, v := range globalHooks.hooks {
+			if v == r {
+				globalHooks.hooks = append(globalHooks.hooks[:i:i], globalHooks.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// hooks returns the global hooks followed by the hooks of the query.
+func (q *Query[M]) hooks() []Hook {
+	globalHooks.mu.RLock()
+	defer globalHooks.mu.RUnlock()
+	if len(globalHooks.hooks) == 0 {
+		return q.Hooks
+	}
+	hooks := make([]Hook, 0, len(globalHooks.hooks)+len(q.Hooks))
+	for _, h := range globalHooks.hooks {
+		hooks = append(hooks, h.Hook)
+	}
+	return append(hooks, q.Hooks...)
+}
+
+// queryRun notifies the hooks of a query.
+type queryRun struct {
+	ctx   context.Context //nolint:containedctx
+	hooks []Hook
+	event QueryEvent
+	start time.Time
+	once  sync.Once
+}
+
+// beforeQuery calls BeforeQuery of the hooks in order and returns the context to execute the query.
+// It returns nil run if the query has no hooks.
+func (q *Query[M]) beforeQuery(ctx context.Context, kind QueryKind, queryStr string, args []any) (context.Context, *queryRun) {
+	hooks := q.hooks()
+	if len(hooks) == 0 {
+		return ctx, nil
+	}
+	r := &queryRun{hooks: hooks, event: QueryEvent{Kind: kind, SQL: queryStr, Args: args}}
+	for _, h := range hooks {
+		ctx = h.BeforeQuery(ctx, &r.event)
+	}
+	r.ctx = ctx
+	r.start = time.Now()
+	return ctx, r
+}
+
+// after calls AfterQuery of the hooks in reverse order only once. It does nothing on nil run.
+func (r *queryRun) after(rows int64, err error) {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		r.event.Duration = time.Since(r.start)
+		r.event.Rows = rows
+		r.event.Err = err
+		for i := len(r.hooks) - 1; i >= 0; i-- {
+			r.hooks[i].AfterQuery(r.ctx, &r.event)
+		}
+	})
+}
+
+// hookRow is a Row which notifies the hooks when it is scanned.
+type hookRow struct {
+	Row
+	run *queryRun
+}
+
+// Err implements Row.
+func (r *hookRow) Err() error {
+	err := r.Row.Err()
+	if err != nil {
+		r.run.after(0, err)
+	}
+	return err
+}
+
+// Scan implements Row.
+func (r *hookRow) Scan(dest ...any) error {
+	err := r.Row.Scan(dest...)
+	if err != nil {
+		r.run.after(0, err)
+	} else {
+		r.run.after(1, nil)
+	}
+	return err
+}
+
+// hookRows is a Rows which counts the rows and notifies the hooks when it is closed.
+type hookRows struct {
+	Rows
+	run     *queryRun
+	count   int64
+	scanErr error
+}
+
+// Next implements Rows.
+func (r *hookRows) Next() bool {
+	ok := r.Rows.Next()
+	if ok {
+		r.count++
+	}
+	return ok
+}
+
+// Scan implements Rows.
+func (r *hookRows) Scan(dest ...any) error {
+	err := r.Rows.Scan(dest...)
+	if err != nil && r.scanErr == nil {
+		r.scanErr = err
+	}
+	return err
+}
+
+// Close implements Rows.
+func (r *hookRows) Close() error {
+	rowsErr := r.Rows.Err()
+	err := r.Rows.Close()
+	r.run.after(r.count, errors.Join(r.scanErr, rowsErr, err))
+	return err
+}
+
+// queryRow executes the query string expected to return a row.
+// The hooks are notified when the row is scanned.
+func (q *Query[M]) queryRow(ctx context.Context, kind QueryKind, queryStr string, args []any) (Row, error) { // nolint:ireturn
+	ctx, run := q.beforeQuery(ctx, kind, queryStr, args)
+	stmt, args, err := q.prepare(ctx, queryStr, args)
+	if err != nil {
+		run.after(0, err)
+		return nil, err
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, args...)
+	if run == nil {
+		return row, nil
+	}
+	return &hookRow{Row: row, run: run}, nil
+}
+
+// query executes the query string returning rows.
+// The hooks are notified when the rows are closed.
+func (q *Query[M]) query(ctx context.Context, kind QueryKind, queryStr string, args []any) (Rows, error) { // nolint:ireturn
+	ctx, run := q.beforeQuery(ctx, kind, queryStr, args)
+	stmt, args, err := q.prepare(ctx, queryStr, args)
+	if err != nil {
+		run.after(0, err)
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...) // nolint:sqlclosecheck
+	if err != nil {
+		run.after(0, err)
+		return nil, err
+	}
+	if run == nil {
+		return rows, nil
+	}
+	return &hookRows{Rows: rows, run: run}, nil
+}
@@ -0,0 +1,225 @@
+package querybm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// hookTestKey is the context key set by the hooks in tests.
+type hookTestKey struct{}
+
+// recordingHook records the events passed to AfterQuery and the calls in calls.
+type recordingHook struct {
+	name   string
+	mu     sync.Mutex
+	calls  *[]string
+	events []QueryEvent
+}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.calls != nil {
+		*h.calls = append(*h.calls, "before "+h.name)
+	}
+	return context.WithValue(ctx, hookTestKey{}, h.name)
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.calls != nil {
+		*h.calls = append(*h.calls, "after "+h.name+" in "+ctx.Value(hookTestKey{}).(string))
+	}
+	e := *event
+	e.Duration = 0
+	h.events = append(h.events, e)
+}
+
+func TestQuery_Hooks(t *testing.T) {
+	t.Parallel()
+	const (
+		countSQL = "SELECT COUNT(*) AS count FROM users WHERE status = $1"
+		listSQL  = "SELECT id, name FROM users WHERE status = $1 ORDER BY created_at DESC LIMIT $2"
+	)
+	tests := []struct {
+		name string
+		db   DB
+		run  func(ctx context.Context, q *Query[TestModel]) error
+		want []QueryEvent
+	}{
+		{
+			name: "Count",
+			db:   &RecordingDB{row: []any{int64(3)}},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				_, err := q.Count(ctx)
+				return err
+			},
+			want: []QueryEvent{{Kind: QueryKindCount, SQL: countSQL, Args: []any{"active"}, Rows: 1}},
+		},
+		{
+			name: "List",
+			db:   &RecordingDB{row: []any{int64(3)}, rows: [][]any{{1, "foo"}, {2, "bar"}}},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				_, err := q.List(ctx)
+				return err
+			},
+			want: []QueryEvent{{Kind: QueryKindList, SQL: listSQL, Args: []any{"active", int64(10)}, Rows: 2}},
+		},
+		{
+			name: "All",
+			db:   &RecordingDB{row: []any{int64(3)}, rows: [][]any{{1, "foo"}, {2, "bar"}, {3, "baz"}}},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				for _, err := range q.All(ctx) {
+					if err != nil {
+						return err
+					}
+					break
+				}
+				return nil
+			},
+			want: []QueryEvent{{Kind: QueryKindList, SQL: listSQL, Args: []any{"active", int64(10)}, Rows: 1}},
+		},
+		{
+			name: "Page",
+			db:   &RecordingDB{row: []any{int64(2)}, rows: [][]any{{1, "foo"}, {2, "bar"}}},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				_, err := q.Page(ctx)
+				return err
+			},
+			want: []QueryEvent{
+				{Kind: QueryKindCount, SQL: countSQL, Args: []any{"active"}, Rows: 1},
+				{Kind: QueryKindList, SQL: listSQL, Args: []any{"active", int64(10)}, Rows: 2},
+			},
+		},
+		{
+			name: "First",
+			db:   &RecordingDB{row: []any{int64(1)}},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				_, err := q.First(ctx)
+				return err
+			},
+			want: []QueryEvent{{Kind: QueryKindFirst, SQL: listSQL, Args: []any{"active", int64(10)}, Rows: 1}},
+		},
+		{
+			name: "First with no rows",
+			db:   &RecordingDB{},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				if _, err := q.First(ctx); !errors.Is(err, sql.ErrNoRows) {
+					return err
+				}
+				return nil
+			},
+			want: []QueryEvent{{Kind: QueryKindFirst, SQL: listSQL, Args: []any{"active", int64(10)}, Err: sql.ErrNoRows}},
+		},
+		{
+			name: "Sum",
+			db:   &RecordingDB{row: []any{int64(6)}},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				_, err := Sum[int](ctx, q, "yr")
+				return err
+			},
+			want: []QueryEvent{{Kind: QueryKindAggregate, SQL: "SELECT SUM(yr) FROM users WHERE status = $1", Args: []any{"active"}, Rows: 1}},
+		},
+		{
+			name: "FirstRow error",
+			db:   &RecordingDB{err: errSortError},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				if _, err := q.FirstRow(ctx); !errors.Is(err, errSortError) {
+					return err
+				}
+				return nil
+			},
+			want: []QueryEvent{{Kind: QueryKindFirst, SQL: listSQL, Args: []any{"active", int64(10)}, Err: errSortError}},
+		},
+		{
+			name: "prepare error",
+			db:   &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return nil, errConditionError }},
+			run: func(ctx context.Context, q *Query[TestModel]) error {
+				if _, err := q.List(ctx); !errors.Is(err, errConditionError) {
+					return err
+				}
+				return nil
+			},
+			want: []QueryEvent{{Kind: QueryKindList, SQL: listSQL, Args: []any{"active", int64(10)}, Err: errConditionError}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			hook := &recordingHook{name: "hook"}
+			q := newTestQuery(tt.db, "users", WithDialect(PostgreSQL), WithHooks(hook))
+			if err := tt.run(t.Context(), q); err != nil {
+				t.Fatalf("run error = %v", err)
+			}
+			if !reflect.DeepEqual(hook.events, tt.want) {
+				t.Errorf("events = %+v, want %+v", hook.events, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery_HooksRowsError(t *testing.T) {
+	t.Parallel()
+	hook := &recordingHook{name: "hook"}
+	q := newTestQuery(&RecordingDB{rows: [][]any{{errSortError}}}, "users", WithDialect(PostgreSQL), WithHooks(hook))
+	if _, err := q.List(t.Context()); !errors.Is(err, errSortError) {
+		t.Fatalf("List() error = %v, want %v", err, errSortError)
+	}
+	if len(hook.events) != 1 || !errors.Is(hook.events[0].Err, errSortError) || hook.events[0].Rows != 1 {
+		t.Errorf("events = %+v, want 1 row with %v", hook.events, errSortError)
+	}
+}
+
+func TestQuery_HooksOrder(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	q := newTestQuery(&RecordingDB{row: []any{int64(1)}}, "users", WithDialect(PostgreSQL), WithHooks(
+		&recordingHook{name: "first", calls: &calls},
+		HookFuncs{},
+		HookFuncs{Before: func(ctx context.Context, _ *QueryEvent) context.Context {
+			calls = append(calls, "before funcs")
+			return ctx
+		}},
+		&recordingHook{name: "second", calls: &calls},
+	))
+	if _, err := q.Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	want := []string{"before first", "before funcs", "before second", "after second in second", "after first in second"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestRegisterHook(t *testing.T) { //nolint:paralleltest // global hooks affect the other tests.
+	var events []QueryEvent
+	unregister := RegisterHook(HookFuncs{After: func(_ context.Context, event *QueryEvent) {
+		e := *event
+		e.Duration = 0
+		events = append(events, e)
+	}})
+	local := &recordingHook{name: "local"}
+	q := newTestQuery(&RecordingDB{row: []any{int64(1)}}, "users", WithDialect(PostgreSQL), WithHooks(local))
+	if _, err := q.Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	unregister()
+	unregister()
+	if _, err := q.Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+
+	want := QueryEvent{Kind: QueryKindCount, SQL: "SELECT COUNT(*) AS count FROM users WHERE status = $1", Args: []any{"active"}, Rows: 1}
+	if !reflect.DeepEqual(events, []QueryEvent{want}) {
+		t.Errorf("global events = %+v, want %+v", events, []QueryEvent{want})
+	}
+	if !reflect.DeepEqual(local.events, []QueryEvent{want, want}) {
+		t.Errorf("local events = %+v, want %+v", local.events, []QueryEvent{want, want})
+	}
+}
@@ -0,0 +1,233 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/statement"
+)
+
+// Keyset implements keyset (cursor) pagination as an alternative to SimpleLimitOffset.
+// Instead of skipping rows with OFFSET, it filters the rows after (or before) the cursor
+// with a predicate derived from the sort items, which keeps paging fast and stable on large tables.
+//
+// Keyset builds the ORDER BY clause from its sort items by itself, so the Sort of the Query should be nil.
+// The last sort item must be a unique column (e.g. the primary key) to make the order deterministic.
+// The sort items of the columns which can be NULL should be created with NewNullableSortItem.
+type Keyset[M any] struct {
+	sort   SortItems
+	limit  int64
+	keys   func(*M) []any
+	cursor *keysetCursor
+	err    error
+}
+
+var (
+	_ LimitOffset = (*Keyset[any])(nil)
+	_ Validatable = (*Keyset[any])(nil)
+)
+
+// ErrKeysetNoSort is returned when a Keyset has no sort items.
+var ErrKeysetNoSort = errors.New("keyset requires at least one sort item")
+
+// ErrInvalidCursor is returned when a cursor token cannot be decoded or does not match the sort items.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// NewKeyset creates a new Keyset paginating by the sort items.
+// limit is the number of rows per page. If limit is <= 0, it uses the default limit.
+// token is the cursor returned by KeysetPage.Next or KeysetPage.Prev. An empty token means the first page.
+// keys returns the values of the sort item columns of a model in the same order as sort.
+// An invalid token is reported by Validate.
+func NewKeyset[M any](sort SortItems, limit int64, token string, keys func(*M) []any) *Keyset[M] {
+	if limit <= 0 {
+		limit = DefaultLimitOffset.limit
+	}
+	k := &Keyset[M]{sort: sort, limit: limit, keys: keys}
+	if token != "" {
+		cursor, err := decodeCursor(token)
+		switch {
+		case err != nil:
+			k.err = fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		case len(cursor.Values) != len(sort):
+			k.err = fmt.Errorf("%w: %d values for %d sort items", ErrInvalidCursor, len(cursor.Values), len(sort))
+		default:
+			k.cursor = cursor
+		}
+	}
+	return k
+}
+
+// Validate checks the sort items and the cursor token.
+func (k *Keyset[M]) Validate() error {
+	if len(k.sort) == 0 {
+		return ErrKeysetNoSort
+	}
+	if err := k.sort.Validate(); err != nil {
+		return err
+	}
+	return k.err
+}
+
+// backward returns true if the keyset pages towards the beginning.
+func (k *Keyset[M]) backward() bool {
+	return k.cursor != nil && k.cursor.Backward
+}
+
+// Build adds the keyset predicate, the ORDER BY clause and the LIMIT clause to the statement.
+// One more row than the limit is fetched to detect whether another page exists.
+func (k *Keyset[M]) Build(st *statement.Statement) {
+	backward := k.backward()
+	if k.cursor != nil {
+		st.Where.Add(k.predicate(statement.DialectOrDefault(st.Dialect), backward))
+	}
+	for _, item := range k.sort {
+		NewSortItem(item.column, item.desc != backward).Build(st)
+	}
+	clause, values := statement.DialectOrDefault(st.Dialect).LimitOffset(k.limit+1, 0)
+	st.LimitOffset.Add(clause, values...)
+}
+
+// predicate builds the condition selecting the rows after the cursor in the paging direction.
+// It uses a row value comparison like (title, book_id) > (?, ?) when all sort items have the same
+// direction and the dialect supports it, otherwise the expanded form
+// title > ? OR (title = ? AND book_id > ?).
+// The columns of nullable sort items and the NULL values of the cursor are compared with
+// IS NULL and IS NOT NULL in the order the dialect sorts NULL values.
+func (k *Keyset[M]) predicate(d Dialect, backward bool) expr.ConditionExpr { //nolint:ireturn
+	columns := make([]string, len(k.sort))
+	for i, item := range k.sort {
+		columns[i] = item.column
+	}
+	values := k.cursor.Values
+
+	nullable := func(i int) bool { return k.sort[i].nullable || values[i] == nil }
+	sameDirection := !slices.ContainsFunc(k.sort, func(item *SortItem) bool { return item.desc != k.sort[0].desc })
+	hasNullable := slices.ContainsFunc(k.sort, func(item *SortItem) bool { return item.nullable }) || slices.Contains(values, nil)
+	if !hasNullable && (len(columns) == 1 || (sameDirection && d.Supports(statement.FeatureRowValues))) {
+		if k.sort[0].desc == backward {
+			if len(columns) == 1 {
+				return expr.Field(columns[0], expr.Gt(values[0]))
+			}
+			return expr.RowGt(columns, values...)
+		}
+		if len(columns) == 1 {
+			return expr.Field(columns[0], expr.Lt(values[0]))
+		}
+		return expr.RowLt(columns, values...)
+	}
+
+	terms := make([]expr.ConditionExpr, 0, len(columns))
+	for i := range columns {
+		// NULL values follow the others when the rows are fetched in the order the dialect sorts them last.
+		ascending := k.sort[i].desc == backward
+		nullsAfter := nullable(i) && ascending == d.Supports(statement.FeatureNullsLast)
+		var after expr.ConditionExpr
+		switch {
+		case values[i] == nil && nullsAfter:
+			// No row follows the NULL value in this column.
+			continue
+		case values[i] == nil:
+			after = expr.Field(columns[i], expr.IsNotNull())
+		case ascending:
+			after = expr.Field(columns[i], expr.Gt(values[i]))
+		default:
+			after = expr.Field(columns[i], expr.Lt(values[i]))
+		}
+		if values[i] != nil && nullsAfter {
+			after = expr.Or(after, expr.Field(columns[i], expr.IsNull()))
+		}
+
+		items := make([]expr.ConditionExpr, 0, i+1)
+		for j := range i {
+			if values[j] == nil {
+				items = append(items, expr.Field(columns[j], expr.IsNull()))
+			} else {
+				items = append(items, expr.Field(columns[j], expr.Eq(values[j])))
+			}
+		}
+		if len(items) == 0 {
+			terms = append(terms, after)
+		} else {
+			terms = append(terms, expr.And(append(items, after)...))
+		}
+	}
+	switch len(terms) {
+	case 0:
+		return noRowsCondition{}
+	case 1:
+		return terms[0]
+	}
+	return expr.Or(terms...)
+}
+
+// noRowsCondition is a condition matching no rows.
+// It is used when no row follows a cursor of NULL values.
+type noRowsCondition struct{}
+
+func (noRowsCondition) String() string { return "1 = 0" }
+func (noRowsCondition) Values() []any  { return []any{} }
+
+// KeysetPage is a page of items fetched with Keyset along with the cursors to the adjacent pages.
+type KeysetPage[M any] struct {
+	// Items are the models of the page in the order of the sort items.
+	Items []*M
+	// Next is the cursor token of the next page. It is empty if there is no next page.
+	Next string
+	// Prev is the cursor token of the previous page. It is empty if there is no previous page.
+	Prev string
+}
+
+// Page converts the rows fetched with the keyset into a KeysetPage.
+// items must be the result of a Query built with this Keyset.
+func (k *Keyset[M]) Page(items []*M) (*KeysetPage[M], error) {
+	hasMore := int64(len(items)) > k.limit
+	if hasMore {
+		items = items[:k.limit]
+	}
+	backward := k.backward()
+	if backward {
+		items = slices.Clone(items)
+		slices.Reverse(items)
+	}
+
+	page := &KeysetPage[M]{Items: items}
+	var err error
+	if (!backward && hasMore) || (backward && k.cursor != nil) {
+		if page.Next, err = k.token(items, len(items)-1, false); err != nil {
+			return nil, err
+		}
+	}
+	if (backward && hasMore) || (!backward && k.cursor != nil) {
+		if page.Prev, err = k.token(items, 0, true); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// token encodes the cursor pointing at items[idx].
+// If items is empty, the values of the current cursor are used.
+func (k *Keyset[M]) token(items []*M, idx int, backward bool) (string, error) {
+	var values []any
+	if len(items) == 0 {
+		values = k.cursor.Values
+	} else {
+		values = k.keys(items[idx])
+	}
+	if len(values) != len(k.sort) {
+		return "", fmt.Errorf("%w: %d keys for %d sort items", ErrInvalidCursor, len(values), len(k.sort))
+	}
+	return encodeCursor(&keysetCursor{Backward: backward, Values: values})
+}
+
+// List executes the query built with this Keyset and returns the page.
+func (k *Keyset[M]) List(ctx context.Context, q *Query[M]) (*KeysetPage[M], error) {
+	items, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return k.Page(items)
+}
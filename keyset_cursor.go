@@ -0,0 +1,149 @@
+package querybm
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// keysetCursor is the decoded content of a cursor token.
+type keysetCursor struct {
+	Backward bool
+	Values   []any
+}
+
+// cursorValue is the JSON representation of a cursor value with its type.
+// The type is kept so that decoded values are bound with the same type as the original ones.
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+// cursorToken is the JSON representation of a keysetCursor.
+type cursorToken struct {
+	Backward bool          `json:"b,omitempty"`
+	Values   []cursorValue `json:"v"`
+}
+
+// Type names of cursorValue.
+const (
+	cursorTypeNull   = "n"
+	cursorTypeBool   = "b"
+	cursorTypeInt    = "i"
+	cursorTypeUint   = "u"
+	cursorTypeFloat  = "f"
+	cursorTypeString = "s"
+	cursorTypeBytes  = "x"
+	cursorTypeTime   = "t"
+)
+
+// ErrUnsupportedCursorValue is returned when a key value cannot be encoded into a cursor.
+var ErrUnsupportedCursorValue = errors.New("unsupported cursor value")
+
+// encodeCursor encodes the cursor into an opaque URL safe token.
+func encodeCursor(c *keysetCursor) (string, error) {
+	token := cursorToken{Backward: c.Backward, Values: make([]cursorValue, len(c.Values))}
+	for i, v := range c.Values {
+		cv, err := encodeCursorValue(v)
+		if err != nil {
+			return "", err
+		}
+		token.Values[i] = cv
+	}
+	b, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor decodes a token created by encodeCursor.
+func decodeCursor(s string) (*keysetCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var token cursorToken
+	if err := json.Unmarshal(b, &token); err != nil {
+		return nil, err
+	}
+	c := &keysetCursor{Backward: token.Backward, Values: make([]any, len(token.Values))}
+	for i, cv := range token.Values {
+		v, err := decodeCursorValue(cv)
+		if err != nil {
+			return nil, err
+		}
+		c.Values[i] = v
+	}
+	return c, nil
+}
+
+func encodeCursorValue(v any) (cursorValue, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return cursorValue{Type: cursorTypeNull}, nil
+		}
+		dv, err := valuer.Value()
+		if err != nil {
+			return cursorValue{}, err
+		}
+		v = dv
+	}
+	switch val := v.(type) {
+	case nil:
+		return cursorValue{Type: cursorTypeNull}, nil
+	case time.Time:
+		return cursorValue{Type: cursorTypeTime, Value: val.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return cursorValue{Type: cursorTypeBytes, Value: base64.RawURLEncoding.EncodeToString(val)}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return cursorValue{Type: cursorTypeNull}, nil
+		}
+		return encodeCursorValue(rv.Elem().Interface())
+	case reflect.Bool:
+		return cursorValue{Type: cursorTypeBool, Value: strconv.FormatBool(rv.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{Type: cursorTypeInt, Value: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{Type: cursorTypeUint, Value: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{Type: cursorTypeFloat, Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.String:
+		return cursorValue{Type: cursorTypeString, Value: rv.String()}, nil
+	default:
+		return cursorValue{}, fmt.Errorf("%w: %T", ErrUnsupportedCursorValue, v)
+	}
+}
+
+func decodeCursorValue(cv cursorValue) (any, error) {
+	switch cv.Type {
+	case cursorTypeNull:
+		return nil, nil //nolint:nilnil
+	case cursorTypeBool:
+		return strconv.ParseBool(cv.Value)
+	case cursorTypeInt:
+		return strconv.ParseInt(cv.Value, 10, 64)
+	case cursorTypeUint:
+		return strconv.ParseUint(cv.Value, 10, 64)
+	case cursorTypeFloat:
+		return strconv.ParseFloat(cv.Value, 64)
+	case cursorTypeString:
+		return cv.Value, nil
+	case cursorTypeBytes:
+		return base64.RawURLEncoding.DecodeString(cv.Value)
+	case cursorTypeTime:
+		return time.Parse(time.RFC3339Nano, cv.Value)
+	default:
+		return nil, fmt.Errorf("%w: type %q", ErrUnsupportedCursorValue, cv.Type)
+	}
+}
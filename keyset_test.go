@@ -0,0 +1,469 @@
+package querybm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type keysetBook struct {
+	ID    int64
+	Title string
+}
+
+func keysetBookKeys(b *keysetBook) []any { return []any{b.Title, b.ID} }
+
+func keysetTestQuery(ks *Keyset[keysetBook], dialect Dialect) *Query[keysetBook] {
+	fields := NewFields([]string{"book_id", "title"}, func(s Scanner, b *keysetBook) error {
+		return s.Scan(&b.ID, &b.Title)
+	})
+	return New(&sql.DB{}, "books", fields, &TestCondition{}, nil, ks, WithDialect(dialect))
+}
+
+func mustEncodeCursor(t *testing.T, backward bool, values ...any) string {
+	t.Helper()
+	token, err := encodeCursor(&keysetCursor{Backward: backward, Values: values})
+	if err != nil {
+		t.Fatalf("encodeCursor() error = %v", err)
+	}
+	return token
+}
+
+func TestKeyset_Build(t *testing.T) {
+	t.Parallel()
+	asc := SortItems{NewSortItem("title", false), NewSortItem("book_id", false)}
+	desc := SortItems{NewSortItem("title", true), NewSortItem("book_id", true)}
+	mixed := SortItems{NewSortItem("title", true), NewSortItem("book_id", false)}
+
+	tests := []struct {
+		name       string
+		sort       SortItems
+		forward    bool
+		backward   bool
+		dialect    Dialect
+		wantSQL    string
+		wantValues []any
+	}{
+		{
+			name:       "first page",
+			sort:       asc,
+			dialect:    MySQL,
+			wantSQL:    "SELECT book_id, title FROM books WHERE status = ? ORDER BY title ASC, book_id ASC LIMIT ?",
+			wantValues: []any{"active", int64(11)},
+		},
+		{
+			name:       "forward ascending",
+			sort:       asc,
+			forward:    true,
+			dialect:    MySQL,
+			wantSQL:    "SELECT book_id, title FROM books WHERE status = ? AND (title, book_id) > (?, ?) ORDER BY title ASC, book_id ASC LIMIT ?",
+			wantValues: []any{"active", "Go", int64(3), int64(11)},
+		},
+		{
+			name:       "backward ascending",
+			sort:       asc,
+			backward:   true,
+			dialect:    PostgreSQL,
+			wantSQL:    "SELECT book_id, title FROM books WHERE status = $1 AND (title, book_id) < ($2, $3) ORDER BY title DESC, book_id DESC LIMIT $4",
+			wantValues: []any{"active", "Go", int64(3), int64(11)},
+		},
+		{
+			name:       "forward descending",
+			sort:       desc,
+			forward:    true,
+			dialect:    MySQL,
+			wantSQL:    "SELECT book_id, title FROM books WHERE status = ? AND (title, book_id) < (?, ?) ORDER BY title DESC, book_id DESC LIMIT ?",
+			wantValues: []any{"active", "Go", int64(3), int64(11)},
+		},
+		{
+			name:       "backward descending",
+			sort:       desc,
+			backward:   true,
+			dialect:    MySQL,
+			wantSQL:    "SELECT book_id, title FROM books WHERE status = ? AND (title, book_id) > (?, ?) ORDER BY title ASC, book_id ASC LIMIT ?",
+			wantValues: []any{"active", "Go", int64(3), int64(11)},
+		},
+		{
+			name:       "forward mixed directions",
+			sort:       mixed,
+			forward:    true,
+			dialect:    MySQL,
+			wantSQL:    "SELECT book_id, title FROM books WHERE status = ? AND (title < ? OR (title = ? AND book_id > ?)) ORDER BY title DESC, book_id ASC LIMIT ?",
+			wantValues: []any{"active", "Go", "Go", int64(3), int64(11)},
+		},
+		{
+			name:       "backward mixed directions",
+			sort:       mixed,
+			backward:   true,
+			dialect:    MySQL,
+			wantSQL:    "SELECT book_id, title FROM books WHERE status = ? AND (title > ? OR (title = ? AND book_id < ?)) ORDER BY title ASC, book_id DESC LIMIT ?",
+			wantValues: []any{"active", "Go", "Go", int64(3), int64(11)},
+		},
+		{
+			name:    "forward without row values support",
+			sort:    asc,
+			forward: true,
+			dialect: SQLServer,
+			wantSQL: "SELECT book_id, title FROM books WHERE status = @p1 AND (title > @p2 OR (title = @p3 AND book_id > @p4)) " +
+				"ORDER BY title ASC, book_id ASC OFFSET @p5 ROWS FETCH NEXT @p6 ROWS ONLY",
+			wantValues: []any{"active", "Go", "Go", int64(3), int64(0), int64(11)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			token := ""
+			if tt.forward || tt.backward {
+				token = mustEncodeCursor(t, tt.backward, "Go", int64(3))
+			}
+			ks := NewKeyset(tt.sort, 10, token, keysetBookKeys)
+			if err := ks.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			gotSQL, gotValues := keysetTestQuery(ks, tt.dialect).BuildRowsSelect()
+			if gotSQL != tt.wantSQL {
+				t.Errorf("BuildRowsSelect() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("BuildRowsSelect() values = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestKeyset_BuildSingleColumn(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		desc     bool
+		backward bool
+		wantSQL  string
+	}{
+		{name: "forward ascending", wantSQL: "SELECT book_id, title FROM books WHERE status = ? AND book_id > ? ORDER BY book_id ASC LIMIT ?"},
+		{name: "backward ascending", backward: true, wantSQL: "SELECT book_id, title FROM books WHERE status = ? AND book_id < ? ORDER BY book_id DESC LIMIT ?"},
+		{name: "forward descending", desc: true, wantSQL: "SELECT book_id, title FROM books WHERE status = ? AND book_id < ? ORDER BY book_id DESC LIMIT ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			token := mustEncodeCursor(t, tt.backward, int64(3))
+			ks := NewKeyset(SortItems{NewSortItem("book_id", tt.desc)}, 5, token, func(b *keysetBook) []any { return []any{b.ID} })
+			gotSQL, gotValues := keysetTestQuery(ks, MySQL).BuildRowsSelect()
+			if gotSQL != tt.wantSQL {
+				t.Errorf("BuildRowsSelect() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+			if want := []any{"active", int64(3), int64(6)}; !reflect.DeepEqual(gotValues, want) {
+				t.Errorf("BuildRowsSelect() values = %v, want %v", gotValues, want)
+			}
+		})
+	}
+}
+
+func TestKeyset_BuildNullable(t *testing.T) {
+	t.Parallel()
+	nullable := SortItems{NewNullableSortItem("title", false), NewSortItem("book_id", false)}
+	tests := []struct {
+		name       string
+		sort       SortItems
+		backward   bool
+		dialect    Dialect
+		cursor     []any
+		wantWhere  string
+		wantValues []any
+	}{
+		{
+			name:       "NULL values first",
+			sort:       nullable,
+			dialect:    MySQL,
+			cursor:     []any{"Go", int64(3)},
+			wantWhere:  "status = ? AND (title > ? OR (title = ? AND book_id > ?))",
+			wantValues: []any{"active", "Go", "Go", int64(3)},
+		},
+		{
+			name:       "NULL cursor with NULL values first",
+			sort:       nullable,
+			dialect:    MySQL,
+			cursor:     []any{nil, int64(3)},
+			wantWhere:  "status = ? AND (title IS NOT NULL OR (title IS NULL AND book_id > ?))",
+			wantValues: []any{"active", int64(3)},
+		},
+		{
+			name:       "NULL cursor of a sort item not marked as nullable",
+			sort:       SortItems{NewSortItem("title", false), NewSortItem("book_id", false)},
+			dialect:    MySQL,
+			cursor:     []any{nil, int64(3)},
+			wantWhere:  "status = ? AND (title IS NOT NULL OR (title IS NULL AND book_id > ?))",
+			wantValues: []any{"active", int64(3)},
+		},
+		{
+			name:       "NULL values last",
+			sort:       nullable,
+			dialect:    PostgreSQL,
+			cursor:     []any{"Go", int64(3)},
+			wantWhere:  "status = $1 AND (title > $2 OR title IS NULL OR (title = $3 AND book_id > $4))",
+			wantValues: []any{"active", "Go", "Go", int64(3)},
+		},
+		{
+			name:       "NULL cursor with NULL values last",
+			sort:       nullable,
+			dialect:    PostgreSQL,
+			cursor:     []any{nil, int64(3)},
+			wantWhere:  "status = $1 AND title IS NULL AND book_id > $2",
+			wantValues: []any{"active", int64(3)},
+		},
+		{
+			name:       "backward NULL cursor with NULL values last",
+			sort:       nullable,
+			backward:   true,
+			dialect:    PostgreSQL,
+			cursor:     []any{nil, int64(3)},
+			wantWhere:  "status = $1 AND (title IS NOT NULL OR (title IS NULL AND book_id < $2))",
+			wantValues: []any{"active", int64(3)},
+		},
+		{
+			name:       "descending NULL values first",
+			sort:       SortItems{NewNullableSortItem("title", true), NewSortItem("book_id", true)},
+			dialect:    MySQL,
+			cursor:     []any{"Go", int64(3)},
+			wantWhere:  "status = ? AND (title < ? OR title IS NULL OR (title = ? AND book_id < ?))",
+			wantValues: []any{"active", "Go", "Go", int64(3)},
+		},
+		{
+			name:       "no rows after NULL cursor",
+			sort:       SortItems{NewNullableSortItem("title", false)},
+			dialect:    PostgreSQL,
+			cursor:     []any{nil},
+			wantWhere:  "status = $1 AND 1 = 0",
+			wantValues: []any{"active"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ks := NewKeyset(tt.sort, 10, mustEncodeCursor(t, tt.backward, tt.cursor...), keysetBookKeys)
+			gotSQL, gotValues := keysetTestQuery(ks, tt.dialect).BuildRowsSelect()
+			where, _, _ := strings.Cut(strings.TrimPrefix(gotSQL, "SELECT book_id, title FROM books WHERE "), " ORDER BY ")
+			if where != tt.wantWhere {
+				t.Errorf("BuildRowsSelect() WHERE = %v, want %v", where, tt.wantWhere)
+			}
+			if gotValues = gotValues[:len(gotValues)-1]; !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("BuildRowsSelect() values = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestKeyset_Validate(t *testing.T) {
+	t.Parallel()
+	sort := SortItems{NewSortItem("title", false), NewSortItem("book_id", false)}
+	tests := []struct {
+		name    string
+		keyset  *Keyset[keysetBook]
+		wantErr error
+	}{
+		{name: "valid", keyset: NewKeyset(sort, 0, "", keysetBookKeys), wantErr: nil},
+		{name: "no sort items", keyset: NewKeyset(nil, 10, "", keysetBookKeys), wantErr: ErrKeysetNoSort},
+		{name: "empty sort item", keyset: NewKeyset(SortItems{NewSortItem("", false)}, 10, "", keysetBookKeys), wantErr: ErrEmptySortItem},
+		{name: "broken token", keyset: NewKeyset(sort, 10, "!!!", keysetBookKeys), wantErr: ErrInvalidCursor},
+		{name: "broken json", keyset: NewKeyset(sort, 10, "bm90IGpzb24", keysetBookKeys), wantErr: ErrInvalidCursor},
+		{name: "unknown value type", keyset: NewKeyset(sort, 10, "eyJ2IjpbeyJ0IjoieiJ9XX0", keysetBookKeys), wantErr: ErrInvalidCursor},
+		{name: "values mismatch", keyset: NewKeyset(sort, 10, mustEncodeCursor(t, false, "Go"), keysetBookKeys), wantErr: ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.keyset.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyset_Page(t *testing.T) {
+	t.Parallel()
+	sort := SortItems{NewSortItem("title", false), NewSortItem("book_id", false)}
+	books := []*keysetBook{{ID: 1, Title: "A"}, {ID: 2, Title: "B"}, {ID: 3, Title: "C"}}
+
+	t.Run("first page with more rows", func(t *testing.T) {
+		t.Parallel()
+		ks := NewKeyset(sort, 2, "", keysetBookKeys)
+		page, err := ks.Page(books)
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+		if !reflect.DeepEqual(page.Items, books[:2]) {
+			t.Errorf("Page() items = %v, want %v", page.Items, books[:2])
+		}
+		if want := mustEncodeCursor(t, false, "B", int64(2)); page.Next != want {
+			t.Errorf("Page() next = %v, want %v", page.Next, want)
+		}
+		if page.Prev != "" {
+			t.Errorf("Page() prev = %v, want empty", page.Prev)
+		}
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		t.Parallel()
+		ks := NewKeyset(sort, 2, mustEncodeCursor(t, false, "B", int64(2)), keysetBookKeys)
+		page, err := ks.Page(books[2:])
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+		if page.Next != "" {
+			t.Errorf("Page() next = %v, want empty", page.Next)
+		}
+		if want := mustEncodeCursor(t, true, "C", int64(3)); page.Prev != want {
+			t.Errorf("Page() prev = %v, want %v", page.Prev, want)
+		}
+	})
+
+	t.Run("backward page with more rows", func(t *testing.T) {
+		t.Parallel()
+		ks := NewKeyset(sort, 2, mustEncodeCursor(t, true, "D", int64(4)), keysetBookKeys)
+		// rows are fetched in reversed order
+		page, err := ks.Page([]*keysetBook{books[2], books[1], books[0]})
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+		if want := []*keysetBook{books[1], books[2]}; !reflect.DeepEqual(page.Items, want) {
+			t.Errorf("Page() items = %v, want %v", page.Items, want)
+		}
+		if want := mustEncodeCursor(t, false, "C", int64(3)); page.Next != want {
+			t.Errorf("Page() next = %v, want %v", page.Next, want)
+		}
+		if want := mustEncodeCursor(t, true, "B", int64(2)); page.Prev != want {
+			t.Errorf("Page() prev = %v, want %v", page.Prev, want)
+		}
+	})
+
+	t.Run("empty page after cursor", func(t *testing.T) {
+		t.Parallel()
+		ks := NewKeyset(sort, 2, mustEncodeCursor(t, false, "Z", int64(9)), keysetBookKeys)
+		page, err := ks.Page(nil)
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+		if page.Next != "" {
+			t.Errorf("Page() next = %v, want empty", page.Next)
+		}
+		if want := mustEncodeCursor(t, true, "Z", int64(9)); page.Prev != want {
+			t.Errorf("Page() prev = %v, want %v", page.Prev, want)
+		}
+	})
+
+	t.Run("keys mismatch", func(t *testing.T) {
+		t.Parallel()
+		ks := NewKeyset(sort, 2, "", func(b *keysetBook) []any { return []any{b.ID} })
+		if _, err := ks.Page(books); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Page() error = %v, want %v", err, ErrInvalidCursor)
+		}
+		ks = NewKeyset(sort, 2, mustEncodeCursor(t, false, "A", int64(1)), func(b *keysetBook) []any { return []any{b.ID} })
+		if _, err := ks.Page(books[:1]); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Page() error = %v, want %v", err, ErrInvalidCursor)
+		}
+	})
+
+	t.Run("unsupported key value", func(t *testing.T) {
+		t.Parallel()
+		ks := NewKeyset(sort, 2, "", func(*keysetBook) []any { return []any{struct{}{}, 1} })
+		if _, err := ks.Page(books); !errors.Is(err, ErrUnsupportedCursorValue) {
+			t.Errorf("Page() error = %v, want %v", err, ErrUnsupportedCursorValue)
+		}
+	})
+}
+
+func TestKeyset_List(t *testing.T) {
+	t.Parallel()
+	sort := SortItems{NewSortItem("title", false), NewSortItem("book_id", false)}
+	books := []*keysetBook{{ID: 1, Title: "A"}, {ID: 2, Title: "B"}, {ID: 3, Title: "C"}}
+
+	newQuery := func(ks *Keyset[keysetBook], err error) *Query[keysetBook] {
+		idx := -1
+		rows := &MockRows{
+			next: func() bool { idx++; return idx < len(books) },
+			scan: func(dest ...any) error {
+				*(dest[0].(*int64)) = books[idx].ID
+				*(dest[1].(*string)) = books[idx].Title
+				return nil
+			},
+		}
+		stmt := &MockStmt{queryContext: func(context.Context, ...any) (Rows, error) { return rows, err }}
+		q := keysetTestQuery(ks, MySQL)
+		q.db = &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return stmt, nil }}
+		return q
+	}
+
+	ks := NewKeyset(sort, 2, "", keysetBookKeys)
+	page, err := ks.List(t.Context(), newQuery(ks, nil))
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if !reflect.DeepEqual(page.Items, books[:2]) {
+		t.Errorf("List() items = %v, want %v", page.Items, books[:2])
+	}
+	if page.Next == "" {
+		t.Error("List() next should not be empty")
+	}
+
+	if _, err := ks.List(t.Context(), newQuery(ks, errConditionError)); !errors.Is(err, errConditionError) {
+		t.Errorf("List() error = %v, want %v", err, errConditionError)
+	}
+}
+
+type cursorValuer struct{ v any }
+
+func (c *cursorValuer) Value() (driver.Value, error) {
+	if err, ok := c.v.(error); ok {
+		return nil, err
+	}
+	return c.v, nil
+}
+
+func TestKeysetCursor_RoundTrip(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2024, 5, 6, 7, 8, 9, 123, time.UTC)
+	title := "Go"
+	var nilTitle *string
+	var nilValuer *cursorValuer
+
+	values := []any{
+		nil, true, 42, int32(-7), uint16(7), 1.5, "text", []byte{0x01, 0xff}, at,
+		&title, nilTitle, sql.NullString{String: "v", Valid: true}, sql.NullInt64{}, nilValuer,
+	}
+	want := []any{
+		nil, true, int64(42), int64(-7), uint64(7), 1.5, "text", []byte{0x01, 0xff}, at,
+		"Go", nil, "v", nil, nil,
+	}
+
+	token, err := encodeCursor(&keysetCursor{Backward: true, Values: values})
+	if err != nil {
+		t.Fatalf("encodeCursor() error = %v", err)
+	}
+	got, err := decodeCursor(token)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if !got.Backward {
+		t.Error("decodeCursor() backward = false, want true")
+	}
+	if !reflect.DeepEqual(got.Values, want) {
+		t.Errorf("decodeCursor() values = %#v, want %#v", got.Values, want)
+	}
+
+	if _, err := encodeCursor(&keysetCursor{Values: []any{&cursorValuer{v: errSortError}}}); !errors.Is(err, errSortError) {
+		t.Errorf("encodeCursor() error = %v, want %v", err, errSortError)
+	}
+	if _, err := encodeCursor(&keysetCursor{Values: []any{map[string]int{}}}); !errors.Is(err, ErrUnsupportedCursorValue) {
+		t.Errorf("encodeCursor() error = %v, want %v", err, ErrUnsupportedCursorValue)
+	}
+}
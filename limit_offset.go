@@ -46,6 +46,16 @@ func NewLimitOffset(limit, offset int64) *SimpleLimitOffset {
 	}
 }
 
+// Limit returns the maximum number of rows to return.
+func (p *SimpleLimitOffset) Limit() int64 {
+	return p.limit
+}
+
+// Offset returns the number of rows to skip.
+func (p *SimpleLimitOffset) Offset() int64 {
+	return p.offset
+}
+
 // Validate ensures the limitOffset parameters are valid.
 // It sets default values if the current values are invalid.
 func (p *SimpleLimitOffset) Validate() error {
@@ -59,11 +69,8 @@ func (p *SimpleLimitOffset) Validate() error {
 }
 
 // Build adds the LIMIT and OFFSET clauses to the SQL statement.
+// The clauses are rendered by the dialect of the statement.
 func (p *SimpleLimitOffset) Build(st *statement.Statement) {
-	if p.limit > 0 {
-		st.LimitOffset.Add("LIMIT ?", p.limit)
-		if p.offset > 0 {
-			st.LimitOffset.Add("OFFSET ?", p.offset)
-		}
-	}
+	clause, values := statement.DialectOrDefault(st.Dialect).LimitOffset(p.limit, p.offset)
+	st.LimitOffset.Add(clause, values...)
 }
@@ -233,3 +233,26 @@ func TestLimitOffset_Build(t *testing.T) {
 		})
 	}
 }
+
+func TestLimitOffset_BuildWithDialect(t *testing.T) {
+	t.Parallel()
+	stmt := statement.New("test_table", statement.NewSimpleFields("id"))
+	stmt.Dialect = statement.SQLServer
+	NewLimitOffset(10, 30).Build(stmt)
+
+	gotSQL, gotValues := stmt.Build()
+	if want := "SELECT id FROM test_table ORDER BY (SELECT NULL) OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY"; gotSQL != want {
+		t.Errorf("Build() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{int64(30), int64(10)}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("Build() values = %v, want %v", gotValues, want)
+	}
+
+	stmt = statement.New("test_table", statement.NewSimpleFields("id"))
+	stmt.Dialect = nil
+	NewLimitOffset(10, 0).Build(stmt)
+	gotSQL, _ = stmt.Build()
+	if want := "SELECT id FROM test_table LIMIT ?"; gotSQL != want {
+		t.Errorf("Build() SQL = %v, want %v", gotSQL, want)
+	}
+}
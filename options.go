@@ -0,0 +1,71 @@
+package querybm
+
+import "github.com/tecowl/querybm/statement"
+
+// Options holds the optional settings of a Query.
+type Options struct {
+	// Dialect is the SQL dialect used to render statements.
+	// MySQL is used when it is nil.
+	Dialect Dialect
+	// Source is the subquery selected from instead of the table, such as a statement.Compound.
+	// The table name of the query is used as the alias of the derived table.
+	Source statement.RawBuilder
+	// Joins is the registry of the joins which builders require by name with Statement.Require.
+	Joins *statement.JoinRegistry
+	// ExecMode is the strategy to execute the built SQL. ExecPrepared is used by default.
+	ExecMode ExecMode
+	// StmtCache is the cache of the prepared statements used with ExecCachedPrepared.
+	StmtCache *StmtCache
+	// Hooks are called for the queries executed by the query after the global hooks. See RegisterHook.
+	Hooks []Hook
+}
+
+// Option is a function that modifies Options of a Query.
+type Option func(*Options)
+
+// WithDialect sets the SQL dialect used to render statements.
+func WithDialect(d Dialect) Option {
+	return func(o *Options) {
+		o.Dialect = d
+	}
+}
+
+// WithSource makes the query select from the subquery instead of the table.
+// The table name of the query is used as the alias of the subquery, so conditions and sort items
+// refer to the columns of the subquery with it.
+// It is useful to list and count the combined result of a statement.Compound.
+// A statement.Statement or statement.Compound source is built with the dialect of the query.
+func WithSource(src statement.RawBuilder) Option {
+	return func(o *Options) {
+		o.Source = src
+	}
+}
+
+// WithJoins sets the registry of the joins which builders require by name with Statement.Require.
+func WithJoins(r *statement.JoinRegistry) Option {
+	return func(o *Options) {
+		o.Joins = r
+	}
+}
+
+// WithExecMode sets the strategy to execute the built SQL.
+func WithExecMode(mode ExecMode) Option {
+	return func(o *Options) {
+		o.ExecMode = mode
+	}
+}
+
+// WithStmtCache makes the query reuse the prepared statements in the cache with ExecCachedPrepared.
+func WithStmtCache(cache *StmtCache) Option {
+	return func(o *Options) {
+		o.ExecMode = ExecCachedPrepared
+		o.StmtCache = cache
+	}
+}
+
+// WithHooks adds the hooks called for the queries executed by the query.
+func WithHooks(hooks ...Hook) Option {
+	return func(o *Options) {
+		o.Hooks = append(o.Hooks[:len(o.Hooks):len(o.Hooks)], hooks...)
+	}
+}
@@ -0,0 +1,171 @@
+package querybm
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/statement"
+)
+
+func TestWithDialect(t *testing.T) {
+	t.Parallel()
+	fields := NewFields[TestModel]([]string{"id"}, nil)
+
+	q := New(&sql.DB{}, "users", fields, nil, nil, nil)
+	if q.Dialect != nil {
+		t.Errorf("New() Dialect = %v, want nil", q.Dialect)
+	}
+
+	q = New(&sql.DB{}, "users", fields, nil, nil, nil, WithDialect(PostgreSQL))
+	if q.Dialect != PostgreSQL {
+		t.Errorf("New() Dialect = %v, want %v", q.Dialect, PostgreSQL)
+	}
+}
+
+func TestWithSource(t *testing.T) {
+	t.Parallel()
+	books := statement.New("books", statement.NewSimpleFields("id", "title AS name"))
+	books.Where.Add(expr.Field("yr", expr.Gte(2000)))
+	authors := statement.New("authors", statement.NewSimpleFields("id", "name"))
+	authors.Where.Add(expr.Field("status", expr.Eq("active")))
+	src := statement.NewCompound(statement.UnionAll, books, authors)
+
+	fields := NewFields[TestModel]([]string{"results.id", "results.name"}, nil)
+	q := New(&sql.DB{}, "results", fields, &TestCondition{}, &TestSort{}, NewLimitOffset(10, 20), WithDialect(PostgreSQL), WithSource(src))
+	if q.Source != src {
+		t.Errorf("New() Source = %v, want %v", q.Source, src)
+	}
+
+	gotSQL, gotValues := q.BuildRowsSelect()
+	if want := "SELECT results.id, results.name FROM (SELECT id, title AS name FROM books WHERE yr >= $1 UNION ALL SELECT id, name FROM authors WHERE status = $2) AS results WHERE status = $3 ORDER BY created_at DESC LIMIT $4 OFFSET $5"; gotSQL != want {
+		t.Errorf("BuildRowsSelect() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{2000, "active", "active", int64(10), int64(20)}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("BuildRowsSelect() values = %v, want %v", gotValues, want)
+	}
+
+	gotSQL, gotValues = q.BuildCountSelect()
+	if want := "SELECT COUNT(*) AS count FROM (SELECT id, title AS name FROM books WHERE yr >= $1 UNION ALL SELECT id, name FROM authors WHERE status = $2) AS results WHERE status = $3"; gotSQL != want {
+		t.Errorf("BuildCountSelect() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{2000, "active", "active"}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("BuildCountSelect() values = %v, want %v", gotValues, want)
+	}
+}
+
+func TestWithSource_Dialect(t *testing.T) {
+	t.Parallel()
+	newSource := func() *statement.Compound {
+		src := statement.NewCompound(statement.Union,
+			statement.New("books", statement.NewSimpleFields("id")),
+			statement.New("magazines", statement.NewSimpleFields("id")))
+		src.Add(statement.Intersect, statement.New("visible", statement.NewSimpleFields("id")))
+		src.SetLimitOffset(100, 0)
+		return src
+	}
+	tests := []struct {
+		name    string
+		dialect Dialect
+		wantSQL string
+	}{
+		{
+			name:    "MySQL",
+			dialect: MySQL,
+			wantSQL: "SELECT COUNT(*) AS count FROM ((SELECT id FROM books UNION SELECT id FROM magazines) INTERSECT SELECT id FROM visible LIMIT ?) AS results",
+		},
+		{
+			name:    "SQLite",
+			dialect: SQLite,
+			wantSQL: "SELECT COUNT(*) AS count FROM (SELECT id FROM books UNION SELECT id FROM magazines INTERSECT SELECT id FROM visible LIMIT ?) AS results",
+		},
+		{
+			name:    "SQLServer",
+			dialect: SQLServer,
+			wantSQL: "SELECT COUNT(*) AS count FROM ((SELECT id FROM books UNION SELECT id FROM magazines) INTERSECT SELECT id FROM visible " +
+				"ORDER BY (SELECT NULL) OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY) AS results",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fields := NewFields[TestModel]([]string{"results.id"}, nil)
+			q := New(&sql.DB{}, "results", fields, nil, nil, nil, WithDialect(tt.dialect), WithSource(newSource()))
+			if gotSQL, _ := q.BuildCountSelect(); gotSQL != tt.wantSQL {
+				t.Errorf("BuildCountSelect() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestWithJoins(t *testing.T) {
+	t.Parallel()
+	joins := statement.NewJoinRegistry()
+	joins.Define("authors", statement.JoinDef{Table: "authors a", Condition: "a.id = b.author_id"})
+	joins.Define("publishers", statement.JoinDef{
+		Type:      statement.JoinLeftOuter,
+		Table:     "publishers p",
+		Condition: "p.id = a.publisher_id",
+		Requires:  []string{"authors"},
+	})
+
+	fields := &struct {
+		FieldMapper[TestModel]
+		Builder
+	}{
+		FieldMapper: NewFields[TestModel]([]string{"b.id", "p.name"}, nil),
+		Builder:     NewBuilder(func(st *statement.Statement) { st.Require("publishers") }),
+	}
+	condition := NewBuilder(func(st *statement.Statement) {
+		st.Require("authors")
+		st.Where.Add(expr.Field("a.name", expr.Eq("foo")))
+	})
+	q := New(&sql.DB{}, "books b", fields, condition, nil, nil, WithJoins(joins))
+	if q.Joins != joins {
+		t.Errorf("New() Joins = %v, want %v", q.Joins, joins)
+	}
+
+	gotSQL, gotValues := q.BuildRowsSelect()
+	if want := "SELECT b.id, p.name FROM books b INNER JOIN authors a ON a.id = b.author_id LEFT OUTER JOIN publishers p ON p.id = a.publisher_id WHERE a.name = ?"; gotSQL != want {
+		t.Errorf("BuildRowsSelect() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{"foo"}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("BuildRowsSelect() values = %v, want %v", gotValues, want)
+	}
+
+	gotSQL, _ = q.BuildCountSelect()
+	if want := "SELECT COUNT(*) AS count FROM books b INNER JOIN authors a ON a.id = b.author_id LEFT OUTER JOIN publishers p ON p.id = a.publisher_id WHERE a.name = ?"; gotSQL != want {
+		t.Errorf("BuildCountSelect() SQL = %v, want %v", gotSQL, want)
+	}
+}
+
+func TestWithExecMode(t *testing.T) {
+	t.Parallel()
+	fields := NewFields[TestModel]([]string{"id"}, nil)
+
+	q := New(&sql.DB{}, "users", fields, nil, nil, nil)
+	if q.ExecMode != ExecPrepared {
+		t.Errorf("New() ExecMode = %v, want %v", q.ExecMode, ExecPrepared)
+	}
+
+	q = New(&sql.DB{}, "users", fields, nil, nil, nil, WithExecMode(ExecDirect))
+	if q.ExecMode != ExecDirect {
+		t.Errorf("New() ExecMode = %v, want %v", q.ExecMode, ExecDirect)
+	}
+}
+
+func TestWithStmtCache(t *testing.T) {
+	t.Parallel()
+	fields := NewFields[TestModel]([]string{"id"}, nil)
+	cache := NewStmtCache(10)
+
+	q := New(&sql.DB{}, "users", fields, nil, nil, nil, WithStmtCache(cache))
+	if q.ExecMode != ExecCachedPrepared {
+		t.Errorf("New() ExecMode = %v, want %v", q.ExecMode, ExecCachedPrepared)
+	}
+	if q.StmtCache != cache {
+		t.Errorf("New() StmtCache = %v, want %v", q.StmtCache, cache)
+	}
+}
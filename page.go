@@ -0,0 +1,200 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tecowl/querybm/statement"
+)
+
+// Page is a page of model instances with its pagination metadata.
+type Page[M any] struct {
+	// Items are the model instances of the page.
+	Items []*M
+	// Total is the number of rows matching the conditions.
+	Total int64
+	// Limit is the maximum number of rows in a page.
+	Limit int64
+	// Offset is the number of rows skipped before the page.
+	Offset int64
+	// HasNext is true if there are rows after the page.
+	HasNext bool
+	// TotalPages is the number of pages for Total rows.
+	TotalPages int64
+}
+
+// PageOption configures how Query.Page fetches a page.
+type PageOption func(*pageOptions)
+
+type pageOptions struct {
+	concurrent  bool
+	windowCount bool
+}
+
+// PageConcurrently makes Query.Page run the COUNT query and the SELECT query concurrently.
+// Do not use it on a query bound to a transaction because a transaction can't run queries concurrently.
+func PageConcurrently() PageOption {
+	return func(o *pageOptions) {
+		o.concurrent = true
+	}
+}
+
+// PageWithWindowCount makes Query.Page get the total with a COUNT(*) OVER() window column
+// in the SELECT query to fetch everything in one round trip.
+// It falls back to a COUNT query when the dialect doesn't support window functions,
+// the query removes duplicated rows with DISTINCT, or the page has no rows.
+func PageWithWindowCount() PageOption {
+	return func(o *pageOptions) {
+		o.windowCount = true
+	}
+}
+
+// limitOffsetValues is implemented by LimitOffset which provides its limit and offset like SimpleLimitOffset.
+type limitOffsetValues interface {
+	Limit() int64
+	Offset() int64
+}
+
+// Page executes the COUNT query and the SELECT query and returns the page with its metadata.
+// Limit and Offset of the page are taken from the LimitOffset of the query if it provides them like SimpleLimitOffset.
+func (q *Query[M]) Page(ctx context.Context, opts ...PageOption) (*Page[M], error) {
+	o := &pageOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var items []*M
+	var total int64
+	var err error
+	switch {
+	case o.windowCount && statement.DialectOrDefault(q.Dialect).Supports(statement.FeatureWindowFunctions):
+		items, total, err = q.listWithWindowCount(ctx)
+	case o.concurrent:
+		items, total, err = q.listAndCountConcurrently(ctx)
+	default:
+		if total, err = q.Count(ctx); err == nil {
+			items, err = q.List(ctx)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newPage(items, total, q.LimitOffset), nil
+}
+
+// newPage creates a Page from the items and the total.
+func newPage[M any](items []*M, total int64, limitOffset LimitOffset) *Page[M] {
+	p := &Page[M]{Items: items, Total: total}
+	if v, ok := limitOffset.(limitOffsetValues); ok {
+		p.Limit = v.Limit()
+		p.Offset = max(v.Offset(), 0)
+	}
+	p.HasNext = p.Offset+int64(len(items)) < total
+	switch {
+	case p.Limit > 0:
+		p.TotalPages = (total + p.Limit - 1) / p.Limit
+	case total > 0:
+		p.TotalPages = 1
+	}
+	return p
+}
+
+// listAndCountConcurrently runs the COUNT query and the SELECT query concurrently.
+// The queries are built before starting goroutines so that builders don't run concurrently.
+func (q *Query[M]) listAndCountConcurrently(ctx context.Context) ([]*M, int64, error) {
+	countStr, countArgs, err := q.build(q.countStatement())
+	if err != nil {
+		return nil, 0, err
+	}
+	rowsStr, rowsArgs, err := q.build(q.rowsStatement())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var wg sync.WaitGroup
+	var items []*M
+	var total int64
+	var listErr, countErr error
+	wg.Add(2) //nolint:mnd
+	go func() {
+		defer wg.Done()
+		total, countErr = q.count(ctx, countStr, countArgs)
+	}()
+	go func() {
+		defer wg.Done()
+		items, listErr = q.list(ctx, rowsStr, rowsArgs)
+	}()
+	wg.Wait()
+	if err := errors.Join(countErr, listErr); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// windowCountColumn is the column added to the SELECT query to get the total with a window function.
+const windowCountColumn = "COUNT(*) OVER() AS querybm_total_count"
+
+// windowCountFields appends the window count column to the fields.
+type windowCountFields struct {
+	fields statement.Fields
+}
+
+// Fields implements statement.Fields.
+func (f *windowCountFields) Fields() []string {
+	fields := f.fields.Fields()
+	return append(fields[:len(fields):len(fields)], windowCountColumn)
+}
+
+// windowCountScanner scans the window count column after the columns scanned by the mapper.
+type windowCountScanner struct {
+	Scanner
+	total *int64
+}
+
+// Scan implements Scanner.
+func (s *windowCountScanner) Scan(dest ...any) error {
+	return s.Scanner.Scan(append(dest, s.total)...)
+}
+
+// listWithWindowCount fetches the rows with the total in a single SELECT query.
+// If no rows are returned, it falls back to Count.
+// If the query removes duplicated rows with DISTINCT, it runs Count and List instead.
+func (q *Query[M]) listWithWindowCount(ctx context.Context) ([]*M, int64, error) {
+	st := q.rowsStatement()
+	if st.IsDistinct() {
+		// COUNT(*) OVER() counts the rows before DISTINCT removes duplicated rows.
+		total, err := q.Count(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		items, err := q.List(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		return items, total, nil
+	}
+	st.Fields = &windowCountFields{fields: st.Fields}
+	queryStr, args, err := q.build(st)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := q.rows(ctx, queryStr, args)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var total int64
+	items, err := q.collect(rows, &windowCountScanner{Scanner: rows, total: &total})
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(items) == 0 {
+		if total, err = q.Count(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+	return items, total, nil
+}
@@ -0,0 +1,272 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/tecowl/querybm/statement"
+)
+
+// pageTestRows returns the rows of the names with the total count of the window function.
+func pageTestRows(names []string, total int64) [][]any {
+	rows := make([][]any, 0, len(names))
+	for i, name := range names {
+		rows = append(rows, []any{i + 1, name, total})
+	}
+	return rows
+}
+
+func TestQuery_Page(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		opts        []PageOption
+		dialect     Dialect
+		names       []string
+		total       int64
+		limitOffset LimitOffset
+		unordered   bool
+		want        Page[TestModel]
+		wantQueries []string
+	}{
+		{
+			name:        "count and list",
+			names:       []string{"foo", "bar"},
+			total:       5,
+			limitOffset: NewLimitOffset(2, 2),
+			want: Page[TestModel]{
+				Items: []*TestModel{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}},
+				Total: 5, Limit: 2, Offset: 2, HasNext: true, TotalPages: 3,
+			},
+			wantQueries: []string{
+				"SELECT COUNT(*) AS count FROM users WHERE status = ?",
+				"SELECT id, name FROM users WHERE status = ? ORDER BY created_at DESC LIMIT ? OFFSET ?",
+			},
+		},
+		{
+			name:        "last page",
+			names:       []string{"foo"},
+			total:       5,
+			limitOffset: NewLimitOffset(2, 4),
+			want: Page[TestModel]{
+				Items: []*TestModel{{ID: 1, Name: "foo"}},
+				Total: 5, Limit: 2, Offset: 4, HasNext: false, TotalPages: 3,
+			},
+			wantQueries: []string{
+				"SELECT COUNT(*) AS count FROM users WHERE status = ?",
+				"SELECT id, name FROM users WHERE status = ? ORDER BY created_at DESC LIMIT ? OFFSET ?",
+			},
+		},
+		{
+			name:        "without limitOffset values",
+			names:       []string{"foo"},
+			total:       1,
+			limitOffset: nil,
+			want: Page[TestModel]{
+				Items: []*TestModel{{ID: 1, Name: "foo"}},
+				Total: 1, Limit: 0, Offset: 0, HasNext: false, TotalPages: 1,
+			},
+			wantQueries: []string{
+				"SELECT COUNT(*) AS count FROM users WHERE status = ?",
+				"SELECT id, name FROM users WHERE status = ? ORDER BY created_at DESC",
+			},
+		},
+		{
+			name:        "concurrently",
+			opts:        []PageOption{PageConcurrently()},
+			unordered:   true,
+			names:       []string{"foo", "bar"},
+			total:       2,
+			limitOffset: NewLimitOffset(2, 0),
+			want: Page[TestModel]{
+				Items: []*TestModel{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}},
+				Total: 2, Limit: 2, Offset: 0, HasNext: false, TotalPages: 1,
+			},
+			wantQueries: []string{
+				"SELECT COUNT(*) AS count FROM users WHERE status = ?",
+				"SELECT id, name FROM users WHERE status = ? ORDER BY created_at DESC LIMIT ?",
+			},
+		},
+		{
+			name:        "window count",
+			opts:        []PageOption{PageWithWindowCount()},
+			dialect:     PostgreSQL,
+			names:       []string{"foo", "bar"},
+			total:       10,
+			limitOffset: NewLimitOffset(2, 0),
+			want: Page[TestModel]{
+				Items: []*TestModel{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}},
+				Total: 10, Limit: 2, Offset: 0, HasNext: true, TotalPages: 5,
+			},
+			wantQueries: []string{
+				"SELECT id, name, COUNT(*) OVER() AS querybm_total_count FROM users WHERE status = $1 ORDER BY created_at DESC LIMIT $2",
+			},
+		},
+		{
+			name:        "window count without rows",
+			opts:        []PageOption{PageWithWindowCount()},
+			names:       nil,
+			total:       3,
+			limitOffset: NewLimitOffset(2, 4),
+			want: Page[TestModel]{
+				Items: nil,
+				Total: 3, Limit: 2, Offset: 4, HasNext: false, TotalPages: 2,
+			},
+			wantQueries: []string{
+				"SELECT id, name, COUNT(*) OVER() AS querybm_total_count FROM users WHERE status = ? ORDER BY created_at DESC LIMIT ? OFFSET ?",
+				"SELECT COUNT(*) AS count FROM users WHERE status = ?",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db := &RecordingDB{row: []any{tt.total}, rows: pageTestRows(tt.names, tt.total)}
+			q := newTestQuery(db, "users", WithDialect(tt.dialect))
+			q.LimitOffset = tt.limitOffset
+			page, err := q.Page(t.Context(), tt.opts...)
+			if err != nil {
+				t.Fatalf("Page() error = %v", err)
+			}
+			if !reflect.DeepEqual(*page, tt.want) {
+				t.Errorf("Page() = %+v, want %+v", *page, tt.want)
+			}
+			queries := db.prepared
+			if tt.unordered {
+				slices.Sort(queries)
+			}
+			if !reflect.DeepEqual(queries, tt.wantQueries) {
+				t.Errorf("Page() queries = %v, want %v", queries, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestQuery_PageError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		opts    []PageOption
+		db      *RecordingDB
+		wantErr error
+	}{
+		{name: "count error", db: &RecordingDB{row: []any{errConditionError}}, wantErr: errConditionError},
+		{name: "list error", db: &RecordingDB{row: []any{1}, rows: [][]any{{errSortError}}}, wantErr: errSortError},
+		{name: "concurrent count error", opts: []PageOption{PageConcurrently()}, db: &RecordingDB{row: []any{errConditionError}}, wantErr: errConditionError},
+		{name: "concurrent list error", opts: []PageOption{PageConcurrently()}, db: &RecordingDB{row: []any{1}, rows: [][]any{{errSortError}}}, wantErr: errSortError},
+		{name: "window count list error", opts: []PageOption{PageWithWindowCount()}, db: &RecordingDB{row: []any{1}, rows: [][]any{{errSortError}}}, wantErr: errSortError},
+		{name: "window count fallback error", opts: []PageOption{PageWithWindowCount()}, db: &RecordingDB{row: []any{errConditionError}}, wantErr: errConditionError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := newTestQuery(tt.db, "users").Page(t.Context(), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Page() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuery_PageWithWindowCountError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prepare error", func(t *testing.T) {
+		t.Parallel()
+		db := &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return nil, errConditionError }}
+		_, err := newTestQuery(db, "users").Page(t.Context(), PageWithWindowCount())
+		if !errors.Is(err, errConditionError) {
+			t.Errorf("Page() error = %v, want %v", err, errConditionError)
+		}
+	})
+
+	t.Run("scan error", func(t *testing.T) {
+		t.Parallel()
+		db := &RecordingDB{rows: [][]any{{errSortError}}}
+		_, err := newTestQuery(db, "users").Page(t.Context(), PageWithWindowCount())
+		if !errors.Is(err, errSortError) {
+			t.Errorf("Page() error = %v, want %v", err, errSortError)
+		}
+	})
+
+	t.Run("rows error", func(t *testing.T) {
+		t.Parallel()
+		db := &RecordingDB{err: errSortError}
+		_, err := newTestQuery(db, "users").Page(t.Context(), PageWithWindowCount())
+		if !errors.Is(err, errSortError) {
+			t.Errorf("Page() error = %v, want %v", err, errSortError)
+		}
+	})
+}
+
+type noWindowDialect struct {
+	Dialect
+}
+
+func (d *noWindowDialect) Supports(statement.Feature) bool { return false }
+
+func TestQuery_PageWithWindowCountUnsupported(t *testing.T) {
+	t.Parallel()
+	db := &RecordingDB{row: []any{1}, rows: pageTestRows([]string{"foo"}, 1)}
+	_, err := newTestQuery(db, "users", WithDialect(&noWindowDialect{Dialect: MySQL})).Page(t.Context(), PageWithWindowCount())
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	want := []string{
+		"SELECT COUNT(*) AS count FROM users WHERE status = ?",
+		"SELECT id, name FROM users WHERE status = ? ORDER BY created_at DESC LIMIT ?",
+	}
+	if !reflect.DeepEqual(db.prepared, want) {
+		t.Errorf("Page() queries = %v, want %v", db.prepared, want)
+	}
+}
+
+func TestQuery_PageWithWindowCountDistinct(t *testing.T) {
+	t.Parallel()
+	newQuery := func(db DB) *Query[TestModel] {
+		q := newTestQuery(db, "users", WithDialect(PostgreSQL))
+		q.Condition = NewBuilder(func(st *statement.Statement) {
+			st.Distinct = true
+		})
+		q.Sort = nil
+		return q
+	}
+
+	db := &RecordingDB{row: []any{1}, rows: pageTestRows([]string{"foo"}, 1)}
+	q := newQuery(db)
+	page, err := q.Page(t.Context(), PageWithWindowCount())
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Errorf("Page() = %+v, want 1 item of 1", *page)
+	}
+	want := []string{
+		"SELECT COUNT(*) AS count FROM (SELECT DISTINCT id, name FROM users) AS querybm_count",
+		"SELECT DISTINCT id, name FROM users LIMIT $1",
+	}
+	if !reflect.DeepEqual(db.prepared, want) {
+		t.Errorf("Page() queries = %v, want %v", db.prepared, want)
+	}
+
+	t.Run("count error", func(t *testing.T) {
+		t.Parallel()
+		q := newQuery(&RecordingDB{row: []any{errConditionError}})
+		if _, err := q.Page(t.Context(), PageWithWindowCount()); !errors.Is(err, errConditionError) {
+			t.Errorf("Page() error = %v, want %v", err, errConditionError)
+		}
+	})
+
+	t.Run("list error", func(t *testing.T) {
+		t.Parallel()
+		q := newQuery(&RecordingDB{row: []any{1}, rows: [][]any{{errSortError}}})
+		if _, err := q.Page(t.Context(), PageWithWindowCount()); !errors.Is(err, errSortError) {
+			t.Errorf("Page() error = %v, want %v", err, errSortError)
+		}
+	})
+}
@@ -3,7 +3,9 @@ package querybm
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"iter"
 
 	"github.com/tecowl/querybm/statement"
 )
@@ -11,6 +13,7 @@ import (
 // Query represents a SQL query builder with generic support for models, conditions, and sorting.
 // It provides methods to build and execute SELECT queries with limitOffset support.
 type Query[M any] struct {
+	Options
 	db          DB
 	Table       string
 	Fields      FieldMapper[M]
@@ -20,24 +23,63 @@ type Query[M any] struct {
 }
 
 // New creates a new Query instance with the provided parameters.
-// db: The database connection to use for executing queries.
+// db: The database connection to use for executing queries. It accepts *sql.DB, *sql.Tx, *sql.Conn and sqlc's DBTX.
 // table: The name of the table to query.
 // fields: The field mapper that defines how to map database rows to model instances. This is used for mapping in List method.
 // c: The condition to apply to the query. This is used for List and Count methods.
 // s: The sort item to apply to the query. This is used for ordering the results in List method.
 // limitOffset: The limitOffset settings for the query. This is used to limit the number of results returned in List method.
-func New[M any](db *sql.DB, table string, fields FieldMapper[M], c Condition, s Sort, limitOffset LimitOffset) *Query[M] {
-	return &Query[M]{
-		db:          newDBWrapper(db),
+// opts: The optional settings such as WithDialect.
+func New[M any](db DBTX, table string, fields FieldMapper[M], c Condition, s Sort, limitOffset LimitOffset, opts ...Option) *Query[M] {
+	return NewWithDB(newDBWrapper(db), table, fields, c, s, limitOffset, opts...)
+}
+
+// NewWithDB creates a new Query instance executing queries on the DB interface.
+// It is useful to run queries on your own DB implementation. See New for the other parameters.
+func NewWithDB[M any](db DB, table string, fields FieldMapper[M], c Condition, s Sort, limitOffset LimitOffset, opts ...Option) *Query[M] {
+	q := &Query[M]{
+		db:          db,
 		Table:       table,
 		Fields:      fields,
 		Condition:   c,
 		Sort:        s,
 		LimitOffset: limitOffset,
 	}
+	for _, opt := range opts {
+		opt(&q.Options)
+	}
+	return q
+}
+
+// WithDB returns a copy of the query that executes on db.
+// db accepts *sql.DB, *sql.Tx, *sql.Conn and sqlc's DBTX.
+func (q *Query[M]) WithDB(db DBTX) *Query[M] {
+	r := *q
+	r.db = newDBWrapper(db)
+	return &r
 }
 
-// Validate validates the query's condition, sort, and limitOffset components.
+// WithTx returns a copy of the query that executes in the transaction tx.
+func (q *Query[M]) WithTx(tx *sql.Tx) *Query[M] {
+	return q.WithDB(tx)
+}
+
+// newStatement creates a new statement for the table of the query rendered with the query's dialect.
+// If the query has a source, the statement selects from it.
+func (q *Query[M]) newStatement(fields statement.Fields) *statement.Statement {
+	if q.Source != nil {
+		return q.subqueryStatement(q.Source, q.Table, fields)
+	}
+	st := statement.New(q.Table, fields)
+	if q.Dialect != nil {
+		st.Dialect = q.Dialect
+	}
+	st.Joins = q.Joins
+	return st
+}
+
+// Validate validates the query's condition, sort, and limitOffset components,
+// and then the statement built with them, such as conflicting joins.
 // It returns an error if any component's validation fails.
 func (q *Query[M]) Validate() error {
 	if v, ok := any(q.Condition).(Validatable); ok {
@@ -55,25 +97,108 @@ func (q *Query[M]) Validate() error {
 			return fmt.Errorf("limitOffset validation failed: %w", err)
 		}
 	}
+	if err := q.rowsStatement().Err(); err != nil {
+		return fmt.Errorf("statement validation failed: %w", err)
+	}
 	return nil
 }
 
+// ErrLockingWithoutTx is returned when a query with a locking clause is executed outside a transaction.
+var ErrLockingWithoutTx = errors.New("locking clause requires a transaction")
+
+// build builds the statement and returns the error of the statement.
+// A statement with a locking clause is built only when the query runs in a transaction.
+func (q *Query[M]) build(st *statement.Statement) (string, []any, error) {
+	if err := st.Err(); err != nil {
+		return "", nil, err
+	}
+	if !st.Locking.IsEmpty() && !q.inTx() {
+		return "", nil, ErrLockingWithoutTx
+	}
+	queryStr, args := st.Build()
+	return queryStr, args, nil
+}
+
+// inTx returns true if the DB of the query reports that it runs in a transaction.
+func (q *Query[M]) inTx() bool {
+	db, ok := q.db.(TxReporter)
+	return ok && db.InTx()
+}
+
 // BuildCountSelect builds a COUNT(*) query string with the current conditions.
+// The joins required by the fields are kept, so that it counts the rows which List returns.
 // It returns the SQL query string and its arguments.
 func (q *Query[M]) BuildCountSelect() (string, []any) {
-	st := statement.New(q.Table, statement.NewSimpleFields("COUNT(*) AS count"))
+	return q.countStatement().Build()
+}
+
+// countFields is the fields of the COUNT query.
+var countFields = statement.NewSimpleFields("COUNT(*) AS count")
+
+// countStatement builds the statement for BuildCountSelect.
+// If the fields or the condition group or deduplicate rows, it counts them by wrapping the SELECT query in a subquery.
+func (q *Query[M]) countStatement() *statement.Statement {
+	return q.aggregatingStatement("querybm_count", countFields)
+}
+
+// aggregatingStatement returns the statement selecting the fields which aggregate the rows matching the condition.
+// The fields and the condition of the query are built once. If they group or deduplicate rows, the fields select
+// from the SELECT query without ORDER BY and LIMIT in a derived table named alias, so that aggregate functions
+// apply to the groups or the distinct rows instead of the underlying rows. Otherwise they select from the table.
+func (q *Query[M]) aggregatingStatement(alias string, fields statement.Fields) *statement.Statement {
+	inner := withoutLocking(q.groupedStatement())
+	if !inner.IsGrouped() && !inner.IsDistinct() {
+		inner.Fields = fields
+		return inner
+	}
+	// Some databases don't allow WITH in a derived table, so the WITH clause is moved to the outer statement.
+	with := inner.With
+	inner.With = statement.NewWithBlock()
+	outer := q.subqueryStatement(inner, alias, fields)
+	outer.With = with
+	return outer
+}
 
+// withoutLocking removes the locking clause of the statement counting or aggregating rows and returns it.
+// Locking clauses are not allowed with aggregate functions.
+func withoutLocking(st *statement.Statement) *statement.Statement {
+	st.Locking = statement.NewLockingBlock()
+	return st
+}
+
+// groupedStatement builds the SELECT statement without ORDER BY and LIMIT to be counted in a subquery.
+// It selects the fields of the query because HAVING may refer to them and DISTINCT depends on them.
+func (q *Query[M]) groupedStatement() *statement.Statement {
+	st := q.newStatement(q.Fields)
+	if fb, ok := q.Fields.(Builder); ok {
+		fb.Build(st)
+	}
 	if q.Condition != nil {
 		q.Condition.Build(st)
 	}
+	return st
+}
 
-	return st.Build()
+// subqueryStatement creates a statement selecting the fields from the subquery rendered with the query's dialect.
+// Builders of the statement can require the joins of the query.
+func (q *Query[M]) subqueryStatement(sub statement.RawBuilder, alias string, fields statement.Fields) *statement.Statement {
+	st := statement.NewFromSubquery(sub, alias, fields)
+	if q.Dialect != nil {
+		st.Dialect = q.Dialect
+	}
+	st.Joins = q.Joins
+	return st
 }
 
 // BuildRowsSelect builds a SELECT query string with all fields, conditions, sorting, and limitOffset.
 // It returns the SQL query string and its arguments.
 func (q *Query[M]) BuildRowsSelect() (string, []any) {
-	st := statement.New(q.Table, q.Fields)
+	return q.rowsStatement().Build()
+}
+
+// rowsStatement builds the statement for BuildRowsSelect.
+func (q *Query[M]) rowsStatement() *statement.Statement {
+	st := q.newStatement(q.Fields)
 	if fb, ok := q.Fields.(Builder); ok {
 		fb.Build(st)
 	}
@@ -87,25 +212,32 @@ func (q *Query[M]) BuildRowsSelect() (string, []any) {
 		q.LimitOffset.Build(st)
 	}
 
-	return st.Build()
+	return st
 }
 
-// RowsStatement prepares a SELECT statement for retrieving rows.
+// RowsStatement prepares a SELECT statement for retrieving rows in the exec mode of the query.
 // It returns the prepared statement, query arguments, and any error that occurred.
 func (q *Query[M]) RowsStatement(ctx context.Context) (Stmt, []any, error) { // nolint:ireturn
-	queryStr, args := q.BuildRowsSelect()
-	stmt, err := q.db.PrepareContext(ctx, queryStr)
+	queryStr, args, err := q.build(q.rowsStatement())
 	if err != nil {
 		return nil, nil, err
 	}
-	return stmt, args, nil
+	return q.prepare(ctx, queryStr, args)
 }
 
-// CountStatement prepares a COUNT statement for counting matching rows.
+// CountStatement prepares a COUNT statement for counting matching rows in the exec mode of the query.
 // It returns the prepared statement, query arguments, and any error that occurred.
 func (q *Query[M]) CountStatement(ctx context.Context) (Stmt, []any, error) { // nolint:ireturn
-	queryStr, args := q.BuildCountSelect()
-	stmt, err := q.db.PrepareContext(ctx, queryStr)
+	queryStr, args, err := q.build(q.countStatement())
+	if err != nil {
+		return nil, nil, err
+	}
+	return q.prepare(ctx, queryStr, args)
+}
+
+// prepare prepares the query string in the exec mode of the query and returns the statement with the arguments.
+func (q *Query[M]) prepare(ctx context.Context, queryStr string, args []any) (Stmt, []any, error) { // nolint:ireturn
+	stmt, err := q.statement(ctx, queryStr)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -115,14 +247,22 @@ func (q *Query[M]) CountStatement(ctx context.Context) (Stmt, []any, error) { //
 // Count executes a COUNT query and returns the number of matching rows.
 // It returns 0 if no rows match the conditions.
 func (q *Query[M]) Count(ctx context.Context) (int64, error) {
-	stmt, args, err := q.CountStatement(ctx)
+	queryStr, args, err := q.build(q.countStatement())
+	if err != nil {
+		return 0, err
+	}
+	return q.count(ctx, queryStr, args)
+}
+
+// count executes the COUNT query string and returns the count.
+func (q *Query[M]) count(ctx context.Context, queryStr string, args []any) (int64, error) {
+	row, err := q.queryRow(ctx, QueryKindCount, queryStr, args)
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
 
 	var count int64
-	if err := stmt.QueryRowContext(ctx, args...).Scan(&count); err != nil {
+	if err := row.Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -131,13 +271,14 @@ func (q *Query[M]) Count(ctx context.Context) (int64, error) {
 // FirstRow executes the query and returns the first row as *sql.Row.
 // The caller is responsible for scanning the row.
 func (q *Query[M]) FirstRow(ctx context.Context) (Row, error) { // nolint:ireturn
-	stmt, args, err := q.RowsStatement(ctx)
+	queryStr, args, err := q.build(q.rowsStatement())
+	if err != nil {
+		return nil, err
+	}
+	row, err := q.queryRow(ctx, QueryKindFirst, queryStr, args)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-
-	row := stmt.QueryRowContext(ctx, args...)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
@@ -148,17 +289,16 @@ func (q *Query[M]) FirstRow(ctx context.Context) (Row, error) { // nolint:iretur
 // It prepares the statement, executes it, and returns the rows.
 // The caller is responsible for closing the rows.
 func (q *Query[M]) Rows(ctx context.Context) (Rows, error) { // nolint:ireturn
-	stmt, args, err := q.RowsStatement(ctx)
+	queryStr, args, err := q.build(q.rowsStatement())
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
+	return q.rows(ctx, queryStr, args)
+}
 
-	rows, err := stmt.QueryContext(ctx, args...) // nolint:sqlclosecheck
-	if err != nil {
-		return nil, err
-	}
-	return rows, nil
+// rows executes the SELECT query string and returns the result set.
+func (q *Query[M]) rows(ctx context.Context, queryStr string, args []any) (Rows, error) { // nolint:ireturn
+	return q.query(ctx, QueryKindList, queryStr, args)
 }
 
 // First executes the query and returns the first matching model instance.
@@ -178,16 +318,29 @@ func (q *Query[M]) First(ctx context.Context) (*M, error) {
 // List executes the query and returns all matching model instances as a slice.
 // It returns an empty slice if no rows match the conditions.
 func (q *Query[M]) List(ctx context.Context) ([]*M, error) {
-	rows, err := q.Rows(ctx)
+	queryStr, args, err := q.build(q.rowsStatement())
+	if err != nil {
+		return nil, err
+	}
+	return q.list(ctx, queryStr, args)
+}
+
+// list executes the SELECT query string and returns the mapped model instances.
+func (q *Query[M]) list(ctx context.Context, queryStr string, args []any) ([]*M, error) {
+	rows, err := q.rows(ctx, queryStr, args)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	return q.collect(rows, rows)
+}
 
+// collect maps all rows into model instances. scanner is passed to the mapper for each row.
+func (q *Query[M]) collect(rows Rows, scanner Scanner) ([]*M, error) {
 	var orgs []*M
 	for rows.Next() {
 		org := new(M)
-		if err := q.Fields.Mapper()(rows, org); err != nil {
+		if err := q.Fields.Mapper()(scanner, org); err != nil {
 			return nil, err
 		}
 		orgs = append(orgs, org)
@@ -197,3 +350,38 @@ func (q *Query[M]) List(ctx context.Context) ([]*M, error) {
 	}
 	return orgs, nil
 }
+
+// All executes the query and returns an iterator over the matching model instances.
+// Unlike List, the rows are mapped lazily while iterating, so the result set is not loaded into memory at once.
+// The statement and the rows are closed when the iteration finishes or the loop breaks early.
+// If an error occurs, it is yielded with a nil model and the iteration stops.
+func (q *Query[M]) All(ctx context.Context) iter.Seq2[*M, error] {
+	return func(yield func(*M, error) bool) {
+		queryStr, args, err := q.build(q.rowsStatement())
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		rows, err := q.rows(ctx, queryStr, args)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer rows.Close()
+
+		mapper := q.Fields.Mapper()
+		for rows.Next() {
+			org := new(M)
+			if err := mapper(rows, org); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(org, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
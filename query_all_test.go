@@ -0,0 +1,116 @@
+package querybm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// allTestRows returns the rows of the names.
+func allTestRows(names ...string) [][]any {
+	rows := make([][]any, 0, len(names))
+	for i, name := range names {
+		rows = append(rows, []any{i + 1, name})
+	}
+	return rows
+}
+
+func TestQuery_All(t *testing.T) {
+	t.Parallel()
+
+	t.Run("iterates all rows", func(t *testing.T) {
+		t.Parallel()
+		db := &RecordingDB{rows: allTestRows("foo", "bar", "baz")}
+		var got []TestModel
+		for m, err := range newTestQuery(db, "users").All(t.Context()) {
+			if err != nil {
+				t.Fatalf("All() error = %v", err)
+			}
+			got = append(got, *m)
+		}
+		want := []TestModel{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}, {ID: 3, Name: "baz"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("All() = %v, want %v", got, want)
+		}
+		if db.rowsClosed != 1 || len(db.closed) != 1 {
+			t.Errorf("All() closed rows %d times and stmt %d times, want 1 and 1", db.rowsClosed, len(db.closed))
+		}
+	})
+
+	t.Run("closes on early break", func(t *testing.T) {
+		t.Parallel()
+		db := &RecordingDB{rows: allTestRows("foo", "bar", "baz")}
+		count := 0
+		for range newTestQuery(db, "users").All(t.Context()) {
+			count++
+			break
+		}
+		if count != 1 {
+			t.Errorf("All() iterated %d times, want 1", count)
+		}
+		if db.rowsClosed != 1 || len(db.closed) != 1 {
+			t.Errorf("All() closed rows %d times and stmt %d times, want 1 and 1", db.rowsClosed, len(db.closed))
+		}
+	})
+
+	t.Run("yields scan error", func(t *testing.T) {
+		t.Parallel()
+		db := &RecordingDB{rows: [][]any{{errConditionError}}}
+		var errs []error
+		for m, err := range newTestQuery(db, "users").All(t.Context()) {
+			if m != nil {
+				t.Errorf("All() model = %v, want nil", m)
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) != 1 || !errors.Is(errs[0], errConditionError) {
+			t.Errorf("All() errors = %v, want [%v]", errs, errConditionError)
+		}
+		if db.rowsClosed != 1 {
+			t.Errorf("All() closed rows %d times, want 1", db.rowsClosed)
+		}
+	})
+
+	t.Run("yields rows error", func(t *testing.T) {
+		t.Parallel()
+		db := &RecordingDB{rows: allTestRows("foo"), err: errSortError}
+		var errs []error
+		for _, err := range newTestQuery(db, "users").All(t.Context()) {
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) != 1 || !errors.Is(errs[0], errSortError) {
+			t.Errorf("All() errors = %v, want [%v]", errs, errSortError)
+		}
+	})
+
+	t.Run("yields prepare error", func(t *testing.T) {
+		t.Parallel()
+		q := newTestQuery(&MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return nil, errConditionError }}, "users")
+		for _, err := range q.All(t.Context()) {
+			if !errors.Is(err, errConditionError) {
+				t.Errorf("All() error = %v, want %v", err, errConditionError)
+			}
+		}
+	})
+
+	t.Run("yields query error", func(t *testing.T) {
+		t.Parallel()
+		stmtClosed := 0
+		stmt := &MockStmt{
+			close:        func() error { stmtClosed++; return nil },
+			queryContext: func(context.Context, ...any) (Rows, error) { return nil, errSortError },
+		}
+		q := newTestQuery(&MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return stmt, nil }}, "users")
+		for _, err := range q.All(t.Context()) {
+			if !errors.Is(err, errSortError) {
+				t.Errorf("All() error = %v, want %v", err, errSortError)
+			}
+		}
+		if stmtClosed != 1 {
+			t.Errorf("All() closed stmt %d times, want 1", stmtClosed)
+		}
+	})
+}
@@ -2,8 +2,14 @@ package querybm
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 	"testing"
+
+	"github.com/tecowl/querybm/statement"
 )
 
 type MockDB struct {
@@ -103,6 +109,122 @@ func (m *MockRows) Scan(dest ...any) error {
 	return nil
 }
 
+// RecordingDB is a DB recording the prepared and directly executed queries and the queries of the closed statements.
+// QueryRowContext scans row, or returns sql.ErrNoRows if row is nil, and QueryContext iterates rows.
+// A value which is an error is returned by Scan, and err is returned by Row.Err and Rows.Err.
+type RecordingDB struct {
+	row  []any
+	rows [][]any
+	err  error
+	inTx bool
+
+	mu         sync.Mutex
+	prepared   []string
+	direct     []string
+	closed     []string
+	rowsClosed int
+}
+
+var (
+	_ DB         = (*RecordingDB)(nil)
+	_ DirectDB   = (*RecordingDB)(nil)
+	_ TxReporter = (*RecordingDB)(nil)
+)
+
+func (db *RecordingDB) PrepareContext(_ context.Context, query string) (Stmt, error) { // nolint:ireturn
+	db.record(&db.prepared, query)
+	return &MockStmt{
+		close:           func() error { db.record(&db.closed, query); return nil },
+		queryRowContext: func(context.Context, ...any) Row { return db.newRow() },
+		queryContext:    func(context.Context, ...any) (Rows, error) { return db.newRows(), nil },
+	}, nil
+}
+
+// QueryContext implements DirectDB.
+func (db *RecordingDB) QueryContext(_ context.Context, query string, _ ...any) (Rows, error) { // nolint:ireturn
+	db.record(&db.direct, query)
+	return db.newRows(), nil
+}
+
+// QueryRowContext implements DirectDB.
+func (db *RecordingDB) QueryRowContext(_ context.Context, query string, _ ...any) Row { // nolint:ireturn
+	db.record(&db.direct, query)
+	return db.newRow()
+}
+
+// InTx implements TxReporter.
+func (db *RecordingDB) InTx() bool { return db.inTx }
+
+func (db *RecordingDB) record(queries *[]string, query string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	*queries = append(*queries, query)
+}
+
+func (db *RecordingDB) newRow() *MockRow {
+	return &MockRow{err: db.err, scan: func(dest ...any) error {
+		if db.row == nil {
+			return sql.ErrNoRows
+		}
+		return scanTestValues(dest, db.row)
+	}}
+}
+
+func (db *RecordingDB) newRows() *MockRows {
+	idx := -1
+	return &MockRows{
+		close: func() error {
+			db.mu.Lock()
+			defer db.mu.Unlock()
+			db.rowsClosed++
+			return nil
+		},
+		err:  func() error { return db.err },
+		next: func() bool { idx++; return idx < len(db.rows) },
+		scan: func(dest ...any) error { return scanTestValues(dest, db.rows[idx]) },
+	}
+}
+
+// scanTestValues scans the values into dest. The values which dest doesn't have are ignored.
+func scanTestValues(dest []any, values []any) error {
+	for _, v := range values {
+		if err, ok := v.(error); ok {
+			return err
+		}
+	}
+	for i, d := range dest[:min(len(dest), len(values))] {
+		if s, ok := d.(sql.Scanner); ok {
+			if err := s.Scan(values[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		if values[i] != nil {
+			p := reflect.ValueOf(d).Elem()
+			p.Set(reflect.ValueOf(values[i]).Convert(p.Type()))
+		}
+	}
+	return nil
+}
+
+// countQueries returns the number of each query in queries.
+func countQueries(queries []string) map[string]int {
+	counts := map[string]int{}
+	for _, query := range queries {
+		counts[query]++
+	}
+	return counts
+}
+
+// newTestQuery creates a query on db selecting id and name from the table into TestModel
+// with TestCondition, TestSort and the limit 10. Set the fields of the query to change them.
+func newTestQuery(db DB, table string, opts ...Option) *Query[TestModel] {
+	fields := NewFields([]string{"id", "name"}, func(s Scanner, m *TestModel) error {
+		return s.Scan(&m.ID, &m.Name)
+	})
+	return NewWithDB(db, table, fields, &TestCondition{}, &TestSort{}, NewLimitOffset(10, 0), opts...)
+}
+
 func TestQueryPrepareContextError(t *testing.T) {
 	t.Parallel()
 
@@ -257,3 +379,33 @@ func TestQueryValidateError(t *testing.T) {
 		}
 	})
 }
+
+func TestQuery_JoinConflictError(t *testing.T) {
+	t.Parallel()
+	db := &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) {
+		t.Error("PrepareContext() should not be called")
+		return nil, nil // nolint:nilnil
+	}}
+	condition := NewBuilder(func(st *statement.Statement) {
+		st.Table.LeftOuterJoin("authors a", "a.id = books.author_id")
+		st.Table.InnerJoin("authors a", "a.id = books.editor_id")
+	})
+	fields := NewFields[TestModel]([]string{"id", "name"}, nil)
+	q := NewWithDB(db, "books", fields, condition, nil, nil)
+
+	if _, err := q.Count(t.Context()); !errors.Is(err, statement.ErrJoinConflict) {
+		t.Errorf("Count() error = %v, want %v", err, statement.ErrJoinConflict)
+	}
+	if _, err := q.List(t.Context()); !errors.Is(err, statement.ErrJoinConflict) {
+		t.Errorf("List() error = %v, want %v", err, statement.ErrJoinConflict)
+	}
+	if _, _, err := q.CountStatement(t.Context()); !errors.Is(err, statement.ErrJoinConflict) {
+		t.Errorf("CountStatement() error = %v, want %v", err, statement.ErrJoinConflict)
+	}
+	if _, err := Sum[int64](t.Context(), q, "yr"); !errors.Is(err, statement.ErrJoinConflict) {
+		t.Errorf("Sum() error = %v, want %v", err, statement.ErrJoinConflict)
+	}
+	if _, err := CountBy[string](t.Context(), q, "book_type"); !errors.Is(err, statement.ErrJoinConflict) {
+		t.Errorf("CountBy() error = %v, want %v", err, statement.ErrJoinConflict)
+	}
+}
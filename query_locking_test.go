@@ -0,0 +1,107 @@
+package querybm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/statement"
+)
+
+// lockingTestCondition selects the queued rows with FOR UPDATE SKIP LOCKED.
+var lockingTestCondition = NewBuilder(func(st *statement.Statement) {
+	st.Where.Add(expr.Field("status", expr.Eq("queued")))
+	st.Locking.ForUpdate()
+	st.Locking.SkipLocked = true
+})
+
+func TestQuery_Locking(t *testing.T) {
+	t.Parallel()
+	db := &RecordingDB{row: []any{int64(1)}, inTx: true}
+	q := newTestQuery(db, "jobs", WithDialect(PostgreSQL))
+	q.Condition = lockingTestCondition
+
+	if _, err := q.Page(t.Context()); err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	want := []string{
+		"SELECT COUNT(*) AS count FROM jobs WHERE status = $1",
+		"SELECT id, name FROM jobs WHERE status = $1 ORDER BY created_at DESC LIMIT $2 FOR UPDATE SKIP LOCKED",
+	}
+	if !reflect.DeepEqual(db.prepared, want) {
+		t.Errorf("Page() queries = %v, want %v", db.prepared, want)
+	}
+}
+
+func TestQuery_LockingError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		inTx    bool
+		dialect Dialect
+		wantErr error
+	}{
+		{name: "without transaction", dialect: PostgreSQL, wantErr: ErrLockingWithoutTx},
+		{name: "unsupported dialect", inTx: true, dialect: SQLite, wantErr: statement.ErrLockingUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db := &RecordingDB{inTx: tt.inTx}
+			q := newTestQuery(db, "jobs", WithDialect(tt.dialect))
+			q.Condition = lockingTestCondition
+			if _, err := q.List(t.Context()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("List() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := q.Rows(t.Context()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Rows() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := q.First(t.Context()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("First() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := q.Page(t.Context(), PageConcurrently()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Page() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := q.Page(t.Context(), PageWithWindowCount()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Page() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, err := range q.All(t.Context()) {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("All() error = %v, want %v", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestQuery_LockingStatementError(t *testing.T) {
+	t.Parallel()
+	// The COUNT statement drops the locking clause, so it is built without the error.
+	db := &MockDB{}
+	fields := NewFields[TestModel]([]string{"id"}, nil)
+	condition := NewBuilder(func(st *statement.Statement) {
+		st.Locking.ForUpdate()
+		st.Locking.NoWait = true
+		st.Locking.SkipLocked = true
+	})
+	q := NewWithDB(db, "jobs", fields, condition, nil, nil)
+	if _, _, err := q.RowsStatement(t.Context()); !errors.Is(err, statement.ErrLockingConflict) {
+		t.Errorf("RowsStatement() error = %v, want %v", err, statement.ErrLockingConflict)
+	}
+	if gotSQL, _ := q.BuildCountSelect(); gotSQL != "SELECT COUNT(*) AS count FROM jobs" {
+		t.Errorf("BuildCountSelect() SQL = %v", gotSQL)
+	}
+}
+
+func TestDBWrapper_InTx(t *testing.T) {
+	t.Parallel()
+	if newDBWrapper(&sql.DB{}).InTx() {
+		t.Error("InTx() of *sql.DB = true, want false")
+	}
+	if !newDBWrapper(&sql.Tx{}).InTx() {
+		t.Error("InTx() of *sql.Tx = false, want true")
+	}
+}
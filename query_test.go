@@ -133,6 +133,24 @@ func TestQuery_Validate(t *testing.T) {
 			wantErr:       true,
 			wantErrString: "sort validation failed:",
 		},
+		{
+			name: "Statement validation fails",
+			setupQuery: func() *Query[TestModel] {
+				db := &sql.DB{}
+				condition := NewBuilder(func(st *statement.Statement) {
+					st.Table.InnerJoin("authors a", "a.id = users.author_id")
+				})
+				sort := NewBuilder(func(st *statement.Statement) {
+					st.Table.InnerJoin("authors a", "a.id = users.editor_id")
+					st.Sort.Add("a.name")
+				})
+				fields := NewFields[TestModel]([]string{"id"}, nil)
+				limitOffset := NewLimitOffset(10, 0)
+				return New(db, "users", fields, condition, sort, limitOffset)
+			},
+			wantErr:       true,
+			wantErrString: "statement validation failed: conflicting join",
+		},
 		{
 			name: "Non-validatable condition and sort",
 			setupQuery: func() *Query[TestModel] {
@@ -198,6 +216,64 @@ func TestQuery_BuildCountSelect(t *testing.T) {
 			wantSQL:    "SELECT COUNT(*) AS count FROM products WHERE status = ?",
 			wantValues: []any{"active"},
 		},
+		{
+			name: "Count groups",
+			setupQuery: func() *Query[TestModel] {
+				db := &sql.DB{}
+				condition := NewBuilder(func(st *statement.Statement) {
+					st.Where.Add(expr.Field("status", expr.Eq("active")))
+					st.GroupBy.Add("author_id")
+					st.Having.Add(expr.Field("COUNT(*)", expr.Gte(2)))
+				})
+				fields := NewFields[TestModel]([]string{"author_id", "COUNT(*) AS books"}, nil)
+				return New(db, "books", fields, condition, &TestSort{}, NewLimitOffset(10, 0), WithDialect(PostgreSQL))
+			},
+			wantSQL:    "SELECT COUNT(*) AS count FROM (SELECT author_id, COUNT(*) AS books FROM books WHERE status = $1 GROUP BY author_id HAVING COUNT(*) >= $2) AS querybm_count",
+			wantValues: []any{"active", 2},
+		},
+		{
+			name: "Count distinct rows",
+			setupQuery: func() *Query[TestModel] {
+				db := &sql.DB{}
+				condition := NewBuilder(func(st *statement.Statement) {
+					st.Distinct = true
+					st.Table.InnerJoin("books b", "b.author_id = a.id")
+					st.Where.Add(expr.Field("b.yr", expr.Gte(2000)))
+				})
+				fields := NewFields[TestModel]([]string{"a.id", "a.name"}, nil)
+				return New(db, "authors a", fields, condition, &TestSort{}, NewLimitOffset(10, 0))
+			},
+			wantSQL:    "SELECT COUNT(*) AS count FROM (SELECT DISTINCT a.id, a.name FROM authors a INNER JOIN books b ON b.author_id = a.id WHERE b.yr >= ?) AS querybm_count",
+			wantValues: []any{2000},
+		},
+		{
+			name: "Count groups with WITH clause",
+			setupQuery: func() *Query[TestModel] {
+				db := &sql.DB{}
+				condition := NewBuilder(func(st *statement.Statement) {
+					st.With.AddRaw("recent", "SELECT * FROM books WHERE yr >= ?", 2000)
+					st.Table = statement.NewTableBlock("recent")
+					st.Where.Add(expr.Field("status", expr.Eq("active")))
+					st.GroupBy.Add("author_id")
+				})
+				fields := NewFields[TestModel]([]string{"author_id", "COUNT(*) AS books"}, nil)
+				return New(db, "books", fields, condition, &TestSort{}, NewLimitOffset(10, 0))
+			},
+			wantSQL:    "WITH recent AS (SELECT * FROM books WHERE yr >= ?) SELECT COUNT(*) AS count FROM (SELECT author_id, COUNT(*) AS books FROM recent WHERE status = ? GROUP BY author_id) AS querybm_count",
+			wantValues: []any{2000, "active"},
+		},
+		{
+			name: "Count distinct rows of fields",
+			setupQuery: func() *Query[TestModel] {
+				db := &sql.DB{}
+				fields := NewFields[TestModel]([]string{"author_id"}, nil, func(st *statement.Statement) {
+					st.Distinct = true
+				})
+				return New(db, "books", fields, &TestCondition{}, &TestSort{}, NewLimitOffset(10, 0))
+			},
+			wantSQL:    "SELECT COUNT(*) AS count FROM (SELECT DISTINCT author_id FROM books WHERE status = ?) AS querybm_count",
+			wantValues: []any{"active"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -215,6 +291,44 @@ func TestQuery_BuildCountSelect(t *testing.T) {
 	}
 }
 
+func TestQuery_BuildCountSelect_BuildsConditionOnce(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		group bool
+		build func(q *Query[TestModel]) (string, []any)
+	}{
+		{name: "count", build: (*Query[TestModel]).BuildCountSelect},
+		{name: "count groups", group: true, build: (*Query[TestModel]).BuildCountSelect},
+		{name: "aggregate", build: func(q *Query[TestModel]) (string, []any) { return q.BuildAggregateSelect("SUM(yr)") }},
+		{name: "aggregate groups", group: true, build: func(q *Query[TestModel]) (string, []any) { return q.BuildAggregateSelect("SUM(yr)") }},
+		{name: "count by", build: func(q *Query[TestModel]) (string, []any) { return q.BuildCountBySelect("yr") }},
+		{name: "count by groups", group: true, build: func(q *Query[TestModel]) (string, []any) { return q.BuildCountBySelect("yr") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			condition := NewBuilder(func(st *statement.Statement) {
+				calls++
+				st.Where.Add(expr.Field("status", expr.Eq("active")))
+				if tt.group {
+					st.GroupBy.Add("yr")
+				}
+			})
+			fields := NewFields[TestModel]([]string{"yr"}, nil)
+			q := New(&sql.DB{}, "books", fields, condition, &TestSort{}, NewLimitOffset(10, 0))
+			_, gotValues := tt.build(q)
+			if calls != 1 {
+				t.Errorf("condition built %d times, want 1", calls)
+			}
+			if want := []any{"active"}; !reflect.DeepEqual(gotValues, want) {
+				t.Errorf("values = %v, want %v", gotValues, want)
+			}
+		})
+	}
+}
+
 func TestQuery_BuildRowsSelect(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -356,3 +470,131 @@ func TestQuery_Validate_WithValidatableComponents(t *testing.T) {
 		})
 	}
 }
+
+func TestQuery_BuildWithDialect(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		dialect         Dialect
+		limitOffset     LimitOffset
+		wantRowsSQL     string
+		wantRowsValues  []any
+		wantCountSQL    string
+		wantCountValues []any
+	}{
+		{
+			name:            "MySQL",
+			dialect:         MySQL,
+			limitOffset:     NewLimitOffset(20, 40),
+			wantRowsSQL:     "SELECT id, name FROM users WHERE status = ? ORDER BY created_at DESC LIMIT ? OFFSET ?",
+			wantRowsValues:  []any{"active", int64(20), int64(40)},
+			wantCountSQL:    "SELECT COUNT(*) AS count FROM users WHERE status = ?",
+			wantCountValues: []any{"active"},
+		},
+		{
+			name:            "PostgreSQL",
+			dialect:         PostgreSQL,
+			limitOffset:     NewLimitOffset(20, 40),
+			wantRowsSQL:     "SELECT id, name FROM users WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3",
+			wantRowsValues:  []any{"active", int64(20), int64(40)},
+			wantCountSQL:    "SELECT COUNT(*) AS count FROM users WHERE status = $1",
+			wantCountValues: []any{"active"},
+		},
+		{
+			name:            "SQLite",
+			dialect:         SQLite,
+			limitOffset:     NewLimitOffset(20, 0),
+			wantRowsSQL:     "SELECT id, name FROM users WHERE status = ? ORDER BY created_at DESC LIMIT ?",
+			wantRowsValues:  []any{"active", int64(20)},
+			wantCountSQL:    "SELECT COUNT(*) AS count FROM users WHERE status = ?",
+			wantCountValues: []any{"active"},
+		},
+		{
+			name:            "SQLServer",
+			dialect:         SQLServer,
+			limitOffset:     NewLimitOffset(20, 40),
+			wantRowsSQL:     "SELECT id, name FROM users WHERE status = @p1 ORDER BY created_at DESC OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY",
+			wantRowsValues:  []any{"active", int64(40), int64(20)},
+			wantCountSQL:    "SELECT COUNT(*) AS count FROM users WHERE status = @p1",
+			wantCountValues: []any{"active"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fields := NewFields[TestModel]([]string{"id", "name"}, nil)
+			q := New(&sql.DB{}, "users", fields, &TestCondition{}, &TestSort{}, tt.limitOffset, WithDialect(tt.dialect))
+
+			gotSQL, gotValues := q.BuildRowsSelect()
+			if gotSQL != tt.wantRowsSQL {
+				t.Errorf("BuildRowsSelect() SQL = %v, want %v", gotSQL, tt.wantRowsSQL)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantRowsValues) {
+				t.Errorf("BuildRowsSelect() values = %v, want %v", gotValues, tt.wantRowsValues)
+			}
+
+			gotSQL, gotValues = q.BuildCountSelect()
+			if gotSQL != tt.wantCountSQL {
+				t.Errorf("BuildCountSelect() SQL = %v, want %v", gotSQL, tt.wantCountSQL)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantCountValues) {
+				t.Errorf("BuildCountSelect() values = %v, want %v", gotValues, tt.wantCountValues)
+			}
+		})
+	}
+}
+
+func TestNewWithDB(t *testing.T) {
+	t.Parallel()
+	db := &MockDB{}
+	fields := NewFields[TestModel]([]string{"id", "name"}, nil)
+	condition := &TestCondition{}
+
+	q := NewWithDB(db, "users", fields, condition, nil, nil, WithDialect(SQLite))
+	if q.db != db {
+		t.Error("NewWithDB() db not set correctly")
+	}
+	if q.Table != "users" || q.Condition != condition || q.Dialect != SQLite {
+		t.Errorf("NewWithDB() = %+v, fields not set correctly", q)
+	}
+}
+
+func TestQuery_WithDB(t *testing.T) {
+	t.Parallel()
+	db := &sql.DB{}
+	fields := NewFields[TestModel]([]string{"id", "name"}, nil)
+	q := New(db, "users", fields, &TestCondition{}, nil, nil, WithDialect(PostgreSQL))
+
+	assertDB := func(t *testing.T, got *Query[TestModel], want DBTX) {
+		t.Helper()
+		if got == q {
+			t.Fatal("returned query should be a copy")
+		}
+		w, ok := got.db.(*DBWrapper)
+		if !ok {
+			t.Fatalf("db = %T, want *DBWrapper", got.db)
+		}
+		if w.db != want {
+			t.Errorf("db = %v, want %v", w.db, want)
+		}
+		if got.Table != q.Table || got.Condition != q.Condition || got.Dialect != q.Dialect {
+			t.Errorf("copied query = %+v, want %+v", got, q)
+		}
+	}
+
+	t.Run("WithTx", func(t *testing.T) {
+		t.Parallel()
+		tx := &sql.Tx{}
+		assertDB(t, q.WithTx(tx), tx)
+	})
+	t.Run("WithDB with *sql.Conn", func(t *testing.T) {
+		t.Parallel()
+		conn := &sql.Conn{}
+		assertDB(t, q.WithDB(conn), conn)
+	})
+
+	if w := q.db.(*DBWrapper); w.db != db {
+		t.Error("original query db should not be changed")
+	}
+}
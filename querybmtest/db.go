@@ -0,0 +1,238 @@
+// Package querybmtest provides a scriptable fake DB to unit test the conditions, sorts and mappers
+// of querybm.Query without a database, golden-file assertions of the SQL built by them, and a recorder
+// which captures the queries on a real database into fixture files to replay them without the database.
+package querybmtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tecowl/querybm"
+)
+
+// ErrUnexpectedQuery is returned when a query matches no expectation of DB.
+var ErrUnexpectedQuery = errors.New("querybmtest: unexpected query")
+
+// Call is a query executed on DB.
+type Call struct {
+	// SQL is the executed SQL.
+	SQL string
+	// Args are the arguments of the SQL.
+	Args []any
+	// Err is the error returned for the query, including ErrUnexpectedQuery.
+	Err error
+}
+
+// Expectation is an expected query of DB with its canned result.
+type Expectation struct {
+	sql        SQLMatcher
+	args       []any
+	anyArgs    bool
+	rows       *Rows
+	err        error
+	prepareErr error
+	times      int
+	calls      int
+}
+
+// WithArgs makes the expectation match only the queries with the arguments.
+// An argument implementing ArgMatcher matches with it, and the others are compared with reflect.DeepEqual.
+// Without WithArgs, the expectation matches any arguments.
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args = args
+	e.anyArgs = false
+	return e
+}
+
+// WillReturnRows sets the rows returned for the query.
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// WillReturnError makes the execution of the query fail with err.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// WillFailPrepare makes PrepareContext of the query fail with err.
+func (e *Expectation) WillFailPrepare(err error) *Expectation {
+	e.prepareErr = err
+	return e
+}
+
+// Times sets how many times the expectation matches. It is 1 by default, and 0 means any times.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// String describes the expectation in error messages.
+func (e *Expectation) String() string {
+	if e.anyArgs {
+		return e.sql.String()
+	}
+	return fmt.Sprintf("%s with args %v", e.sql, e.args)
+}
+
+// available reports whether the expectation can match more queries.
+func (e *Expectation) available() bool {
+	return e.times == 0 || e.calls < e.times
+}
+
+// match reports whether the expectation matches the query.
+func (e *Expectation) match(query string, args []any) bool {
+	return e.available() && e.sql.MatchSQL(query) && (e.anyArgs || matchArgs(e.args, args))
+}
+
+// DB is a fake querybm.DB which returns the canned results of the expected queries and records the calls.
+// The queries are matched with the expectations in the order they are added, and each expectation matches once
+// by default. It implements querybm.DirectDB and querybm.TxReporter too. It is safe for concurrent use.
+type DB struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+	inTx         bool
+}
+
+var (
+	_ querybm.DB         = (*DB)(nil)
+	_ querybm.DirectDB   = (*DB)(nil)
+	_ querybm.TxReporter = (*DB)(nil)
+)
+
+// NewDB creates a DB without expectations.
+func NewDB() *DB {
+	return &DB{}
+}
+
+// Expect adds the expectation of the queries matching the SQL matcher.
+// Use SQL to match the SQL as it is.
+func (db *DB) Expect(sql SQLMatcher) *Expectation {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	e := &Expectation{sql: sql, anyArgs: true, rows: NewRows(), times: 1}
+	db.expectations = append(db.expectations, e)
+	return e
+}
+
+// SetInTx sets whether the DB reports that it runs in a transaction, which is required by locking clauses.
+func (db *DB) SetInTx(inTx bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.inTx = inTx
+}
+
+// InTx implements querybm.TxReporter.
+func (db *DB) InTx() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.inTx
+}
+
+// Calls returns the recorded calls in the order they are executed.
+func (db *DB) Calls() []Call {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Call(nil), db.calls...)
+}
+
+// ExpectationsWereMet returns an error if any expectation matched fewer queries than its Times.
+// Expectations with Times(0) are never reported.
+func (db *DB) ExpectationsWereMet() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var errs []error
+	for _, e := range db.expectations {
+		if e.available() && e.times > 0 {
+			errs = append(errs, fmt.Errorf("querybmtest: expected %s %d times, but got %d", e, e.times, e.calls))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PrepareContext implements querybm.DB. It fails if an expectation matching the SQL has WillFailPrepare.
+// The returned statement matches the expectations when it is executed.
+func (db *DB) PrepareContext(_ context.Context, query string) (querybm.Stmt, error) { //nolint:ireturn
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, e := range db.expectations {
+		if e.available() && e.prepareErr != nil && e.sql.MatchSQL(query) {
+			e.calls++
+			db.calls = append(db.calls, Call{SQL: query, Err: e.prepareErr})
+			return nil, e.prepareErr
+		}
+	}
+	return &stmt{db: db, query: query}, nil
+}
+
+// QueryContext implements querybm.DirectDB.
+func (db *DB) QueryContext(_ context.Context, query string, args ...any) (querybm.Rows, error) { //nolint:ireturn
+	rows, err := db.execute(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+// QueryRowContext implements querybm.DirectDB.
+func (db *DB) QueryRowContext(_ context.Context, query string, args ...any) querybm.Row { //nolint:ireturn
+	rows, err := db.execute(query, args)
+	return &fakeRow{rows: rows, err: err}
+}
+
+// execute records the call and returns the result of the first expectation matching the query.
+func (db *DB) execute(query string, args []any) (*Rows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, e := range db.expectations {
+		if e.prepareErr == nil && e.match(query, args) {
+			e.calls++
+			db.calls = append(db.calls, Call{SQL: query, Args: args, Err: e.err})
+			return e.rows, e.err
+		}
+	}
+	err := db.unexpected(query, args)
+	db.calls = append(db.calls, Call{SQL: query, Args: args, Err: err})
+	return nil, err
+}
+
+// unexpected returns ErrUnexpectedQuery describing the query and the remaining expectations.
+func (db *DB) unexpected(query string, args []any) error {
+	var remaining []string
+	for _, e := range db.expectations {
+		if e.available() {
+			remaining = append(remaining, "\t"+e.String())
+		}
+	}
+	msg := "no expectations remain"
+	if len(remaining) > 0 {
+		msg = "remaining expectations:\n" + strings.Join(remaining, "\n")
+	}
+	return fmt.Errorf("%w: %q with args %v, %s", ErrUnexpectedQuery, query, args, msg)
+}
+
+// stmt is a prepared statement of DB. It implements querybm.Stmt.
+type stmt struct {
+	db    *DB
+	query string
+}
+
+// Close implements querybm.Stmt.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// QueryContext implements querybm.Stmt.
+func (s *stmt) QueryContext(ctx context.Context, args ...any) (querybm.Rows, error) { //nolint:ireturn
+	return s.db.QueryContext(ctx, s.query, args...)
+}
+
+// QueryRowContext implements querybm.Stmt.
+func (s *stmt) QueryRowContext(ctx context.Context, args ...any) querybm.Row { //nolint:ireturn
+	return s.db.QueryRowContext(ctx, s.query, args...)
+}
@@ -0,0 +1,283 @@
+package querybmtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/tecowl/querybm"
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/statement"
+)
+
+type testBook struct {
+	ID    int64
+	Title string
+}
+
+var errTest = errors.New("test error")
+
+func newTestQuery(db *DB, title string) *querybm.Query[testBook] {
+	fields := querybm.NewFields([]string{"id", "title"}, func(s querybm.Scanner, b *testBook) error {
+		return s.Scan(&b.ID, &b.Title)
+	})
+	condition := querybm.NewBuilder(func(st *statement.Statement) {
+		if title != "" {
+			st.Where.Add(expr.Field("title", expr.LikeContains(title)))
+		}
+	})
+	return NewQuery(db, "books", fields, condition, querybm.NewSortItem("id", false), querybm.NewLimitOffset(10, 0),
+		querybm.WithDialect(querybm.PostgreSQL))
+}
+
+func TestDB_Page(t *testing.T) {
+	t.Parallel()
+	db := NewDB()
+	db.Expect(SQL("SELECT COUNT(*) AS count FROM books WHERE title LIKE $1")).
+		WithArgs("%Go%").
+		WillReturnRows(NewRows("count").AddRow(int64(2)))
+	db.Expect(SQLRegexp(`^SELECT id, title FROM books .* LIMIT \$2$`)).
+		WithArgs(AnyArg(), int64(10)).
+		WillReturnRows(NewRows("id", "title").AddRow(int64(1), "Go 101").AddRow(int64(2), []byte("Learning Go")))
+
+	page, err := newTestQuery(db, "Go").Page(t.Context())
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	if want := []*testBook{{1, "Go 101"}, {2, "Learning Go"}}; !reflect.DeepEqual(page.Items, want) {
+		t.Errorf("Page() items = %v, want %v", page.Items, want)
+	}
+	if page.Total != 2 {
+		t.Errorf("Page() total = %v, want 2", page.Total)
+	}
+	if err := db.ExpectationsWereMet(); err != nil {
+		t.Errorf("ExpectationsWereMet() error = %v", err)
+	}
+	want := []Call{
+		{SQL: "SELECT COUNT(*) AS count FROM books WHERE title LIKE $1", Args: []any{"%Go%"}},
+		{SQL: "SELECT id, title FROM books WHERE title LIKE $1 ORDER BY id ASC LIMIT $2", Args: []any{"%Go%", int64(10)}},
+	}
+	if got := db.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Calls() = %v, want %v", got, want)
+	}
+}
+
+func TestDB_First(t *testing.T) {
+	t.Parallel()
+	db := NewDB()
+	db.Expect(SQLContains("FROM books")).WillReturnRows(NewRows("id", "title").AddRow(3, "Go"))
+	db.Expect(SQLContains("FROM books"))
+
+	got, err := newTestQuery(db, "").First(t.Context())
+	if err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if want := (&testBook{3, "Go"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("First() = %v, want %v", got, want)
+	}
+	if _, err := newTestQuery(db, "").First(t.Context()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("First() error = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func TestDB_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		setup   func(db *DB)
+		run     func(ctx context.Context, q *querybm.Query[testBook]) error
+		wantErr error
+	}{
+		{
+			name:  "unexpected query",
+			setup: func(db *DB) { db.Expect(SQL("SELECT 1")) },
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.List(ctx)
+				return err
+			},
+			wantErr: ErrUnexpectedQuery,
+		},
+		{
+			name:  "no expectations",
+			setup: func(*DB) {},
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.Count(ctx)
+				return err
+			},
+			wantErr: ErrUnexpectedQuery,
+		},
+		{
+			name:  "unmatched args",
+			setup: func(db *DB) { db.Expect(AnySQL()).WithArgs("other") },
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.Count(ctx)
+				return err
+			},
+			wantErr: ErrUnexpectedQuery,
+		},
+		{
+			name: "unmatched arg matcher",
+			setup: func(db *DB) {
+				db.Expect(AnySQL()).WithArgs(ArgMatcherFunc(func(v any) bool { return v == "other" }))
+			},
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.List(ctx)
+				return err
+			},
+			wantErr: ErrUnexpectedQuery,
+		},
+		{
+			name:  "query error",
+			setup: func(db *DB) { db.Expect(AnySQL()).WillReturnError(errTest) },
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.List(ctx)
+				return err
+			},
+			wantErr: errTest,
+		},
+		{
+			name:  "row error",
+			setup: func(db *DB) { db.Expect(AnySQL()).WillReturnError(errTest) },
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.Count(ctx)
+				return err
+			},
+			wantErr: errTest,
+		},
+		{
+			name:  "prepare error",
+			setup: func(db *DB) { db.Expect(SQLContains("COUNT")).WillFailPrepare(errTest) },
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.Count(ctx)
+				return err
+			},
+			wantErr: errTest,
+		},
+		{
+			name: "rows error",
+			setup: func(db *DB) {
+				db.Expect(AnySQL()).WillReturnRows(NewRows("id", "title").AddRow(1, "a").AddRow(2, "b").RowError(1, errTest))
+			},
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.List(ctx)
+				return err
+			},
+			wantErr: errTest,
+		},
+		{
+			name: "first row error",
+			setup: func(db *DB) {
+				db.Expect(AnySQL()).WillReturnRows(NewRows("id", "title").RowError(0, errTest))
+			},
+			run: func(ctx context.Context, q *querybm.Query[testBook]) error {
+				_, err := q.First(ctx)
+				return err
+			},
+			wantErr: errTest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db := NewDB()
+			tt.setup(db)
+			err := tt.run(t.Context(), newTestQuery(db, ""))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if calls := db.Calls(); len(calls) != 1 {
+				t.Errorf("Calls() = %v, want 1 call", calls)
+			}
+		})
+	}
+}
+
+func TestDB_UnexpectedQueryMessage(t *testing.T) {
+	t.Parallel()
+	db := NewDB()
+	db.Expect(SQL("SELECT  1")).WithArgs(1)
+	_, err := newTestQuery(db, "").Count(t.Context())
+	want := regexp.QuoteMeta(`querybmtest: unexpected query: "SELECT COUNT(*) AS count FROM books" with args [], remaining expectations:` +
+		"\n\t" + `SQL "SELECT 1" with args [1]`)
+	if err == nil || !regexp.MustCompile(want).MatchString(err.Error()) {
+		t.Errorf("error = %v, want %v", err, want)
+	}
+}
+
+func TestDB_Times(t *testing.T) {
+	t.Parallel()
+	db := NewDB()
+	db.Expect(SQLContains("COUNT")).WillReturnRows(NewRows("count").AddRow(int64(1))).Times(2)
+	db.Expect(SQLContains("SELECT id")).Times(0)
+
+	q := newTestQuery(db, "")
+	if _, err := q.Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if err := db.ExpectationsWereMet(); err == nil {
+		t.Errorf("ExpectationsWereMet() error = nil, want error")
+	}
+	if _, err := q.Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if _, err := q.Count(t.Context()); !errors.Is(err, ErrUnexpectedQuery) {
+		t.Errorf("Count() error = %v, want %v", err, ErrUnexpectedQuery)
+	}
+	for range 3 {
+		if _, err := q.List(t.Context()); err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+	}
+	if err := db.ExpectationsWereMet(); err != nil {
+		t.Errorf("ExpectationsWereMet() error = %v", err)
+	}
+}
+
+func TestDB_InTxAndDirect(t *testing.T) {
+	t.Parallel()
+	db := NewDB()
+	if db.InTx() {
+		t.Errorf("InTx() = true, want false")
+	}
+	db.SetInTx(true)
+	db.Expect(SQLContains("FOR UPDATE")).WillReturnRows(NewRows("id", "title").AddRow(1, "a"))
+
+	fields := querybm.NewFields([]string{"id", "title"}, func(s querybm.Scanner, b *testBook) error {
+		return s.Scan(&b.ID, &b.Title)
+	})
+	condition := querybm.NewBuilder(func(st *statement.Statement) { st.Locking.ForUpdate() })
+	q := NewQuery(db, "books", fields, condition, nil, nil, querybm.WithExecMode(querybm.ExecDirect))
+	got, err := q.List(t.Context())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if want := []*testBook{{1, "a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func TestDB_Concurrent(t *testing.T) {
+	t.Parallel()
+	db := NewDB()
+	db.Expect(AnySQL()).WillReturnRows(NewRows("count").AddRow(int64(1))).Times(0)
+	q := newTestQuery(db, "")
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.Count(t.Context()); err != nil {
+				t.Errorf("Count() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := len(db.Calls()); got != 10 {
+		t.Errorf("len(Calls()) = %v, want 10", got)
+	}
+}
@@ -0,0 +1,50 @@
+package querybmtest_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tecowl/querybm"
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/querybmtest"
+	"github.com/tecowl/querybm/statement"
+)
+
+func ExampleNewQuery() {
+	type Author struct {
+		AuthorID int64
+		Name     string
+	}
+
+	db := querybmtest.NewDB()
+	db.Expect(querybmtest.SQL("SELECT author_id, name FROM authors WHERE name LIKE ? ORDER BY name ASC LIMIT ?")).
+		WithArgs("%Martin%", int64(100)).
+		WillReturnRows(querybmtest.NewRows("author_id", "name").AddRow(int64(1), "Martin Fowler"))
+
+	q := querybmtest.NewQuery(db, "authors",
+		querybm.NewFields(
+			[]string{"author_id", "name"},
+			func(rows querybm.Scanner, author *Author) error {
+				return rows.Scan(&author.AuthorID, &author.Name)
+			},
+		),
+		querybm.NewBuilder(func(st *statement.Statement) {
+			st.Where.Add(expr.Field("name", expr.LikeContains("Martin")))
+		}),
+		querybm.NewSortItem("name", false),
+		querybm.NewLimitOffset(100, 0),
+	)
+
+	list, err := q.List(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, author := range list {
+		fmt.Printf("Author ID: %d, Name: %s\n", author.AuthorID, author.Name)
+	}
+	if err := db.ExpectationsWereMet(); err != nil {
+		fmt.Println(err)
+	}
+	// Output: Author ID: 1, Name: Martin Fowler
+}
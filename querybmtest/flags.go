@@ -0,0 +1,11 @@
+package querybmtest
+
+import "flag"
+
+// The flags are prefixed with the package name, so they don't conflict with the flags of the tests importing querybmtest.
+var (
+	// updateFlag rewrites the golden files of AssertGolden.
+	updateFlag = flag.Bool("querybmtest.update", false, "rewrite the golden files of querybmtest.AssertGolden")
+	// recordFlag records the fixture files of UseFixture.
+	recordFlag = flag.Bool("querybmtest.record", false, "record the fixture files of querybmtest.UseFixture on the real database")
+)
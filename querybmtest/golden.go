@@ -0,0 +1,136 @@
+package querybmtest
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tecowl/querybm/statement"
+)
+
+// SQLBuilder builds the SELECT and COUNT queries. It is implemented by querybm.Query.
+type SQLBuilder interface {
+	BuildRowsSelect() (string, []any)
+	BuildCountSelect() (string, []any)
+}
+
+// RenderGolden renders the SELECT query and the COUNT query built by q with their arguments
+// in the format of the golden files of AssertGolden.
+// The queries are formatted by statement.Pretty, so the differences of whitespace are ignored.
+func RenderGolden(q SQLBuilder) string {
+	var sb strings.Builder
+	rowsSQL, rowsArgs := q.BuildRowsSelect()
+	countSQL, countArgs := q.BuildCountSelect()
+	renderGoldenSection(&sb, "rows", rowsSQL, rowsArgs)
+	sb.WriteString("\n")
+	renderGoldenSection(&sb, "count", countSQL, countArgs)
+	return sb.String()
+}
+
+// renderGoldenSection renders the query and its arguments with the title.
+func renderGoldenSection(sb *strings.Builder, title, query string, args []any) {
+	sb.WriteString("-- " + title + " --\n")
+	sb.WriteString(statement.Pretty(query) + "\n")
+	sb.WriteString("-- " + title + " args --\n")
+	for i, arg := range args {
+		fmt.Fprintf(sb, "%d: %s\n", i+1, formatGoldenArg(arg))
+	}
+}
+
+// formatGoldenArg formats the argument readably with its type.
+func formatGoldenArg(arg any) string {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v) + " (string)"
+	case []byte:
+		return "0x" + hex.EncodeToString(v) + " ([]byte)"
+	case time.Time:
+		return v.Format(time.RFC3339Nano) + " (time.Time)"
+	case fmt.Stringer:
+		return strconv.Quote(v.String()) + fmt.Sprintf(" (%T)", arg)
+	default:
+		return fmt.Sprintf("%#v (%T)", arg, arg)
+	}
+}
+
+// AssertGolden asserts that the queries built by q, rendered by RenderGolden, equal the golden file
+// testdata/<test name>/<name>.golden. Run the tests with the -querybmtest.update flag to rewrite the golden files,
+// and review the differences of them before committing.
+func AssertGolden(t testing.TB, name string, q SQLBuilder) {
+	t.Helper()
+	assertGolden(t, goldenPath("testdata", t.Name(), name), RenderGolden(q), *updateFlag)
+}
+
+// goldenInvalidChars are the characters replaced in the paths of golden files.
+var goldenInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_.\-/]+`)
+
+// goldenPath returns the path of the golden file for the scenario of the test.
+func goldenPath(dir, testName, name string) string {
+	testName = goldenInvalidChars.ReplaceAllString(testName, "_")
+	name = goldenInvalidChars.ReplaceAllString(strings.ReplaceAll(name, "/", "_"), "_")
+	return filepath.Join(dir, filepath.FromSlash(testName), name+".golden")
+}
+
+// assertGolden compares got with the golden file at path, or rewrites the file if update is true.
+func assertGolden(t testing.TB, path, got string, update bool) {
+	t.Helper()
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+			t.Fatalf("failed to create the directory of golden file %s: %v", path, err)
+			return
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil { //nolint:gosec,mnd
+			t.Fatalf("failed to write golden file %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Errorf("golden file %s doesn't exist; run the test with -querybmtest.update to create it\ngot:\n%s", path, got)
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to read golden file %s: %v", path, err)
+		return
+	}
+	if string(want) != got {
+		t.Errorf("golden file %s mismatch; run the test with -querybmtest.update to rewrite it\n%s", path, goldenDiff(string(want), got))
+	}
+}
+
+// goldenDiff describes the differences of the lines between want and got.
+func goldenDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	var sb strings.Builder
+	for i := range max(len(wantLines), len(gotLines)) {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			fmt.Fprintf(&sb, "  %s\n", w)
+			continue
+		}
+		if i < len(wantLines) {
+			fmt.Fprintf(&sb, "- %s\n", w)
+		}
+		if i < len(gotLines) {
+			fmt.Fprintf(&sb, "+ %s\n", g)
+		}
+	}
+	return sb.String()
+}
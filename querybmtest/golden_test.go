@@ -0,0 +1,195 @@
+package querybmtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tecowl/querybm"
+)
+
+// fakeTB records the failures of assertions and the cleanup functions.
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	fatal    bool
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+// runCleanups runs the functions registered by Cleanup in the reverse order.
+func (f *fakeTB) runCleanups() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+	f.cleanups = nil
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	f.fatal = true
+}
+
+type testStatus string
+
+type testStringer struct{}
+
+func (testStringer) String() string { return "stringer" }
+
+func TestAssertGolden(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		title string
+	}{
+		{name: "without condition", title: ""},
+		{name: "title contains Go", title: "Go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			AssertGolden(t, "postgres", newTestQuery(nil, tt.title))
+		})
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	q := querybm.New(nil, "books", querybm.NewFields[testBook]([]string{"id"}, nil),
+		querybm.NewBuilder(func(st *querybm.Statement) {
+			st.Where.Add(querybmtestCondition{"a = ? AND b = ? AND c = ? AND d = ? AND e = ? AND f = ? AND g = ?",
+				[]any{nil, "x", []byte{1, 2}, at, testStatus("active"), testStringer{}, 1.5}})
+		}), nil, nil)
+	want := `-- rows --
+SELECT id
+FROM books
+WHERE a = ?
+  AND b = ?
+  AND c = ?
+  AND d = ?
+  AND e = ?
+  AND f = ?
+  AND g = ?
+-- rows args --
+1: NULL
+2: "x" (string)
+3: 0x0102 ([]byte)
+4: 2024-01-02T03:04:05Z (time.Time)
+5: "active" (querybmtest.testStatus)
+6: "stringer" (querybmtest.testStringer)
+7: 1.5 (float64)
+
+-- count --
+SELECT COUNT(*) AS count
+FROM books
+WHERE a = ?
+  AND b = ?
+  AND c = ?
+  AND d = ?
+  AND e = ?
+  AND f = ?
+  AND g = ?
+-- count args --
+1: NULL
+2: "x" (string)
+3: 0x0102 ([]byte)
+4: 2024-01-02T03:04:05Z (time.Time)
+5: "active" (querybmtest.testStatus)
+6: "stringer" (querybmtest.testStringer)
+7: 1.5 (float64)
+`
+	if got := RenderGolden(q); got != want {
+		t.Errorf("RenderGolden() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// querybmtestCondition is a condition with the SQL and the values as they are.
+type querybmtestCondition struct {
+	sql    string
+	values []any
+}
+
+func (c querybmtestCondition) String() string { return c.sql }
+func (c querybmtestCondition) Values() []any  { return c.values }
+
+func TestGoldenPath(t *testing.T) {
+	t.Parallel()
+	got := goldenPath("testdata", "TestFoo/with_sub test", "name/with: chars")
+	if want := filepath.Join("testdata", "TestFoo", "with_sub_test", "name_with_chars.golden"); got != want {
+		t.Errorf("goldenPath() = %v, want %v", got, want)
+	}
+}
+
+func TestAssertGolden_Update(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "TestFoo", "scenario.golden")
+
+	tb := &fakeTB{}
+	assertGolden(tb, path, "SELECT 1\n", false)
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "doesn't exist") {
+		t.Errorf("errors = %v, want a missing file error", tb.errors)
+	}
+
+	tb = &fakeTB{}
+	assertGolden(tb, path, "SELECT 1\n", true)
+	if len(tb.errors) != 0 {
+		t.Fatalf("errors = %v, want none", tb.errors)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "SELECT 1\n" {
+		t.Errorf("golden file = %q, %v, want %q", b, err, "SELECT 1\n")
+	}
+
+	tb = &fakeTB{}
+	assertGolden(tb, path, "SELECT 1\n", false)
+	if len(tb.errors) != 0 {
+		t.Errorf("errors = %v, want none", tb.errors)
+	}
+
+	tb = &fakeTB{}
+	assertGolden(tb, path, "SELECT 2\nFROM t\n", false)
+	want := "mismatch; run the test with -querybmtest.update to rewrite it\n- SELECT 1\n+ SELECT 2\n- \n+ FROM t\n  \n"
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], want) {
+		t.Errorf("errors = %q, want %q", tb.errors, want)
+	}
+}
+
+func TestAssertGolden_FileErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tb := &fakeTB{}
+	assertGolden(tb, dir, "SELECT 1\n", false)
+	if !tb.fatal {
+		t.Errorf("reading a directory: errors = %v, want fatal", tb.errors)
+	}
+
+	tb = &fakeTB{}
+	assertGolden(tb, filepath.Join(file, "x", "y.golden"), "SELECT 1\n", true)
+	if !tb.fatal {
+		t.Errorf("creating a directory under a file: errors = %v, want fatal", tb.errors)
+	}
+
+	tb = &fakeTB{}
+	assertGolden(tb, dir, "SELECT 1\n", true)
+	if !tb.fatal {
+		t.Errorf("writing a directory: errors = %v, want fatal", tb.errors)
+	}
+}
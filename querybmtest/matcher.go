@@ -0,0 +1,104 @@
+package querybmtest
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// SQLMatcher matches the SQL of a query with an expectation.
+type SQLMatcher interface {
+	// MatchSQL reports whether the SQL matches.
+	MatchSQL(query string) bool
+	// String describes the matcher in error messages.
+	String() string
+}
+
+// sqlMatcherFunc is a SQLMatcher with its description.
+type sqlMatcherFunc struct {
+	match func(query string) bool
+	desc  string
+}
+
+// MatchSQL implements SQLMatcher.
+func (m *sqlMatcherFunc) MatchSQL(query string) bool { return m.match(query) }
+
+// String implements SQLMatcher.
+func (m *sqlMatcherFunc) String() string { return m.desc }
+
+// normalizeSQL collapses the whitespace in query.
+func normalizeSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// SQL matches the SQL equal to query ignoring the differences of whitespace.
+func SQL(query string) SQLMatcher { //nolint:ireturn
+	want := normalizeSQL(query)
+	return &sqlMatcherFunc{
+		match: func(query string) bool { return normalizeSQL(query) == want },
+		desc:  fmt.Sprintf("SQL %q", want),
+	}
+}
+
+// SQLContains matches the SQL containing substr.
+func SQLContains(substr string) SQLMatcher { //nolint:ireturn
+	return &sqlMatcherFunc{
+		match: func(query string) bool { return strings.Contains(query, substr) },
+		desc:  fmt.Sprintf("SQL containing %q", substr),
+	}
+}
+
+// SQLRegexp matches the SQL matching the regular expression pattern. It panics if pattern is invalid.
+func SQLRegexp(pattern string) SQLMatcher { //nolint:ireturn
+	re := regexp.MustCompile(pattern)
+	return &sqlMatcherFunc{
+		match: re.MatchString,
+		desc:  fmt.Sprintf("SQL matching %q", pattern),
+	}
+}
+
+// AnySQL matches any SQL.
+func AnySQL() SQLMatcher { //nolint:ireturn
+	return &sqlMatcherFunc{
+		match: func(string) bool { return true },
+		desc:  "any SQL",
+	}
+}
+
+// ArgMatcher matches an argument of a query with an expectation.
+// The arguments given to Expectation.WithArgs which don't implement ArgMatcher are compared with reflect.DeepEqual.
+type ArgMatcher interface {
+	// MatchArg reports whether the argument matches.
+	MatchArg(v any) bool
+}
+
+// ArgMatcherFunc is an ArgMatcher calling the function.
+type ArgMatcherFunc func(v any) bool
+
+// MatchArg implements ArgMatcher.
+func (f ArgMatcherFunc) MatchArg(v any) bool { return f(v) }
+
+// AnyArg matches any argument.
+func AnyArg() ArgMatcher { //nolint:ireturn
+	return ArgMatcherFunc(func(any) bool { return true })
+}
+
+// matchArgs reports whether args match the expected arguments.
+func matchArgs(expected, args []any) bool {
+	if len(expected) != len(args) {
+		return false
+	}
+	for i, e := range expected {
+		if m, ok := e.(ArgMatcher); ok {
+			if !m.MatchArg(args[i]) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(e, args[i]) {
+			return false
+		}
+	}
+	return true
+}
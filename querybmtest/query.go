@@ -0,0 +1,11 @@
+package querybmtest
+
+import "github.com/tecowl/querybm"
+
+// NewQuery creates a querybm.Query executing on the fake DB. See querybm.New for the parameters.
+func NewQuery[M any](
+	db *DB, table string, fields querybm.FieldMapper[M], c querybm.Condition, s querybm.Sort, limitOffset querybm.LimitOffset,
+	opts ...querybm.Option,
+) *querybm.Query[M] {
+	return querybm.NewWithDB(db, table, fields, c, s, limitOffset, opts...)
+}
@@ -0,0 +1,462 @@
+package querybmtest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tecowl/querybm"
+)
+
+// fixture is the content of a fixture file.
+type fixture struct {
+	// InTx is true if the queries are recorded in a transaction.
+	InTx bool `json:"in_tx,omitempty"`
+	// Queries are the recorded queries in the order they are executed.
+	Queries []*recordedQuery `json:"queries"`
+}
+
+// recordedQuery is a query recorded in a fixture file with its result.
+// The arguments and the driver values of the scanned values are encoded by encoding/json.
+// time.Time and []byte values are encoded like {"time": "2024-01-02T03:04:05Z"} and {"bytes": "Zm9v"}
+// to keep their types.
+type recordedQuery struct {
+	SQL          string              `json:"sql"`
+	Args         []json.RawMessage   `json:"args,omitempty"`
+	Rows         [][]json.RawMessage `json:"rows,omitempty"`
+	PrepareError string              `json:"prepare_error,omitempty"`
+	Error        string              `json:"error,omitempty"`
+	RowsError    string              `json:"rows_error,omitempty"`
+}
+
+// Recorder is a querybm.DB which executes the queries on the wrapped DB and records the SQL, the arguments
+// and the scanned values of the results. Save writes them into a fixture file which Replay serves back.
+// Only the rows and the columns scanned by the caller are recorded, and the errors are recorded with their messages.
+// It implements querybm.DirectDB and querybm.TxReporter too. It is safe for concurrent use.
+type Recorder struct {
+	db      querybm.DB
+	mu      sync.Mutex
+	queries []*recordedQuery
+}
+
+var (
+	_ querybm.DB         = (*Recorder)(nil)
+	_ querybm.DirectDB   = (*Recorder)(nil)
+	_ querybm.TxReporter = (*Recorder)(nil)
+)
+
+// NewRecorder creates a Recorder on db. Use querybm.NewDBWrapper to record the queries on *sql.DB.
+func NewRecorder(db querybm.DB) *Recorder {
+	return &Recorder{db: db}
+}
+
+// InTx implements querybm.TxReporter. It returns the result of the wrapped DB if it implements querybm.TxReporter.
+func (r *Recorder) InTx() bool {
+	tx, ok := r.db.(querybm.TxReporter)
+	return ok && tx.InTx()
+}
+
+// PrepareContext implements querybm.DB.
+func (r *Recorder) PrepareContext(ctx context.Context, query string) (querybm.Stmt, error) { //nolint:ireturn
+	st, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.queries = append(r.queries, &recordedQuery{SQL: query, PrepareError: err.Error()})
+		return nil, err
+	}
+	return &recordingStmt{stmt: st, recorder: r, query: query}, nil
+}
+
+// QueryContext implements querybm.DirectDB.
+// It returns querybm.ErrDirectExecUnsupported if the wrapped DB doesn't implement querybm.DirectDB.
+func (r *Recorder) QueryContext(ctx context.Context, query string, args ...any) (querybm.Rows, error) { //nolint:ireturn
+	db, ok := r.db.(querybm.DirectDB)
+	if !ok {
+		return nil, querybm.ErrDirectExecUnsupported
+	}
+	return r.query(query, args, func() (querybm.Rows, error) {
+		return db.QueryContext(ctx, query, args...)
+	})
+}
+
+// QueryRowContext implements querybm.DirectDB.
+// The row fails with querybm.ErrDirectExecUnsupported if the wrapped DB doesn't implement querybm.DirectDB.
+func (r *Recorder) QueryRowContext(ctx context.Context, query string, args ...any) querybm.Row { //nolint:ireturn
+	db, ok := r.db.(querybm.DirectDB)
+	if !ok {
+		return &fakeRow{err: querybm.ErrDirectExecUnsupported}
+	}
+	return r.queryRow(query, args, func() querybm.Row {
+		return db.QueryRowContext(ctx, query, args...)
+	})
+}
+
+// Save writes the recorded queries into the fixture file at path, creating its directory if needed.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	b, err := json.MarshalIndent(&fixture{InTx: r.InTx(), Queries: r.queries}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("querybmtest: failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return fmt.Errorf("querybmtest: failed to create the directory of fixture: %w", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil { //nolint:gosec,mnd
+		return fmt.Errorf("querybmtest: failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// add records the query with its arguments.
+func (r *Recorder) add(query string, args []any) (*recordedQuery, error) {
+	q := &recordedQuery{SQL: query}
+	var err error
+	if q.Args, err = marshalValues(args); err != nil {
+		return nil, fmt.Errorf("querybmtest: failed to record the arguments of %q: %w", query, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, q)
+	return q, nil
+}
+
+// update modifies the recorded query while holding the lock.
+func (r *Recorder) update(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+}
+
+// query records the query executed by run and the rows returned by it.
+func (r *Recorder) query(query string, args []any, run func() (querybm.Rows, error)) (querybm.Rows, error) { //nolint:ireturn
+	q, err := r.add(query, args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := run()
+	if err != nil {
+		r.update(func() { q.Error = err.Error() })
+		return nil, err
+	}
+	return &recordingRows{rows: rows, recorder: r, query: q}, nil
+}
+
+// queryRow records the query executed by run and the row returned by it.
+func (r *Recorder) queryRow(query string, args []any, run func() querybm.Row) querybm.Row { //nolint:ireturn
+	q, err := r.add(query, args)
+	if err != nil {
+		return &fakeRow{err: err}
+	}
+	return &recordingRow{row: run(), recorder: r, query: q}
+}
+
+// recordingStmt records the queries executed on the prepared statement. It implements querybm.Stmt.
+type recordingStmt struct {
+	stmt     querybm.Stmt
+	recorder *Recorder
+	query    string
+}
+
+// Close implements querybm.Stmt.
+func (s *recordingStmt) Close() error {
+	return s.stmt.Close()
+}
+
+// QueryContext implements querybm.Stmt.
+func (s *recordingStmt) QueryContext(ctx context.Context, args ...any) (querybm.Rows, error) { //nolint:ireturn
+	return s.recorder.query(s.query, args, func() (querybm.Rows, error) {
+		return s.stmt.QueryContext(ctx, args...)
+	})
+}
+
+// QueryRowContext implements querybm.Stmt.
+func (s *recordingStmt) QueryRowContext(ctx context.Context, args ...any) querybm.Row { //nolint:ireturn
+	return s.recorder.queryRow(s.query, args, func() querybm.Row {
+		return s.stmt.QueryRowContext(ctx, args...)
+	})
+}
+
+// recordingRows records the rows and the error of the iteration. It implements querybm.Rows.
+type recordingRows struct {
+	rows     querybm.Rows
+	recorder *Recorder
+	query    *recordedQuery
+}
+
+// Close implements querybm.Rows.
+func (r *recordingRows) Close() error {
+	return r.rows.Close()
+}
+
+// Err implements querybm.Rows.
+func (r *recordingRows) Err() error {
+	err := r.rows.Err()
+	if err != nil {
+		r.recorder.update(func() { r.query.RowsError = err.Error() })
+	}
+	return err
+}
+
+// Next implements querybm.Rows.
+func (r *recordingRows) Next() bool {
+	if !r.rows.Next() {
+		return false
+	}
+	r.recorder.update(func() { r.query.Rows = append(r.query.Rows, nil) })
+	return true
+}
+
+// Scan implements querybm.Rows.
+func (r *recordingRows) Scan(dest ...any) error {
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	values, err := marshalDest(dest)
+	if err != nil {
+		return err
+	}
+	r.recorder.update(func() {
+		if n := len(r.query.Rows); n > 0 {
+			r.query.Rows[n-1] = values
+		}
+	})
+	return nil
+}
+
+// recordingRow records the row or the error. It implements querybm.Row.
+type recordingRow struct {
+	row      querybm.Row
+	recorder *Recorder
+	query    *recordedQuery
+}
+
+// Err implements querybm.Row.
+func (r *recordingRow) Err() error {
+	err := r.row.Err()
+	if err != nil {
+		r.recorder.update(func() { r.query.Error = err.Error() })
+	}
+	return err
+}
+
+// Scan implements querybm.Row. sql.ErrNoRows is recorded as a result without rows.
+func (r *recordingRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return err
+	case err != nil:
+		r.recorder.update(func() { r.query.Error = err.Error() })
+		return err
+	}
+	values, err := marshalDest(dest)
+	if err != nil {
+		return err
+	}
+	r.recorder.update(func() { r.query.Rows = [][]json.RawMessage{values} })
+	return nil
+}
+
+// marshalValues encodes the values by encoding/json.
+func marshalValues(values []any) ([]json.RawMessage, error) {
+	result := make([]json.RawMessage, 0, len(values))
+	for _, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	return result, nil
+}
+
+// marshalDest encodes the driver values of the values scanned into the destinations.
+// The destinations must implement driver.Valuer or point to the values of the types which
+// driver.DefaultParameterConverter converts, like the arguments of database/sql.
+func marshalDest(dest []any) ([]json.RawMessage, error) {
+	result := make([]json.RawMessage, 0, len(dest))
+	for _, d := range dest {
+		b, err := marshalDestValue(d)
+		if err != nil {
+			return nil, fmt.Errorf("querybmtest: failed to record the scanned values: %w", err)
+		}
+		result = append(result, b)
+	}
+	return result, nil
+}
+
+// marshalDestValue encodes the driver value of the value scanned into the destination.
+func marshalDestValue(dest any) ([]byte, error) {
+	var v driver.Value
+	var err error
+	if valuer, ok := dest.(driver.Valuer); ok {
+		v, err = valuer.Value()
+	} else {
+		rv := reflect.ValueOf(dest)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return nil, errors.New("destination not a pointer")
+		}
+		v, err = driver.DefaultParameterConverter.ConvertValue(rv.Elem().Interface())
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case time.Time:
+		return json.Marshal(map[string]time.Time{"time": x})
+	case []byte:
+		if x == nil {
+			return json.Marshal(nil)
+		}
+		return json.Marshal(map[string][]byte{"bytes": x})
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// recordedValue is a value of a column replayed from a fixture file. It is scanned into the destination of Scan
+// as the driver value decoded by driverValue.
+type recordedValue json.RawMessage
+
+// driverValue decodes the driver value encoded by marshalDestValue.
+// Numbers are decoded into int64 if they are integers, and float64 otherwise.
+func (v recordedValue) driverValue() (driver.Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(v))
+	dec.UseNumber()
+	var x any
+	if err := dec.Decode(&x); err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case nil, bool, string:
+		return x, nil
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n, nil
+		}
+		return x.Float64()
+	case map[string]any:
+		if s, ok := x["time"].(string); ok && len(x) == 1 {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+		if s, ok := x["bytes"].(string); ok && len(x) == 1 {
+			return base64.StdEncoding.DecodeString(s)
+		}
+	}
+	return nil, fmt.Errorf("unsupported recorded value %s", v)
+}
+
+// recordedArg matches the argument encoded into the same JSON as the recorded one.
+type recordedArg json.RawMessage
+
+// MatchArg implements ArgMatcher.
+func (a recordedArg) MatchArg(v any) bool {
+	b, err := json.Marshal(v)
+	return err == nil && bytes.Equal(a, b)
+}
+
+// String returns the recorded JSON of the argument in error messages.
+func (a recordedArg) String() string {
+	return string(a)
+}
+
+// Replay creates a DB serving the queries recorded in the fixture file at path by Recorder.
+// Each recorded query is expected once with the same SQL and the arguments encoded into the same JSON,
+// and the other queries fail with ErrUnexpectedQuery. The scanned values are replayed as their driver values
+// and scanned into the destinations like the other Rows, and the errors are replayed with their messages
+// except sql.ErrNoRows.
+func Replay(path string) (*DB, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("querybmtest: failed to read fixture: %w", err)
+	}
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("querybmtest: failed to decode fixture %s: %w", path, err)
+	}
+
+	db := NewDB()
+	db.SetInTx(f.InTx)
+	for _, q := range f.Queries {
+		e := db.Expect(SQL(q.SQL))
+		if q.PrepareError != "" {
+			e.WillFailPrepare(errors.New(q.PrepareError))
+			continue
+		}
+		args := make([]any, 0, len(q.Args))
+		for _, arg := range q.Args {
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, arg); err != nil {
+				return nil, fmt.Errorf("querybmtest: failed to decode fixture %s: %w", path, err)
+			}
+			args = append(args, recordedArg(buf.Bytes()))
+		}
+		e.WithArgs(args...)
+		if q.Error != "" {
+			e.WillReturnError(errors.New(q.Error))
+			continue
+		}
+		rows := &Rows{errs: map[int]error{}}
+		for _, row := range q.Rows {
+			values := make([]any, 0, len(row))
+			for _, v := range row {
+				values = append(values, recordedValue(v))
+			}
+			rows.values = append(rows.values, values)
+		}
+		if q.RowsError != "" {
+			rows.errs[len(rows.values)] = errors.New(q.RowsError)
+		}
+		e.WillReturnRows(rows)
+	}
+	return db, nil
+}
+
+// UseFixture returns a DB for the test with the fixture file at path.
+// By default, it returns the DB replaying the fixture by Replay, and reports the recorded queries
+// which are not executed at the end of the test. With the -querybmtest.record flag, it returns a Recorder on
+// the DB returned by connect, and saves the fixture at the end of the test.
+// connect is called only in the record mode, so the tests replaying fixtures don't require the database.
+func UseFixture(t testing.TB, path string, connect func() querybm.DB) querybm.DB { //nolint:ireturn
+	t.Helper()
+	return useFixture(t, path, connect, *recordFlag)
+}
+
+// useFixture returns the DB recording or replaying the fixture at path.
+func useFixture(t testing.TB, path string, connect func() querybm.DB, record bool) querybm.DB { //nolint:ireturn
+	t.Helper()
+	if record {
+		r := NewRecorder(connect())
+		t.Cleanup(func() {
+			if err := r.Save(path); err != nil {
+				t.Errorf("%v", err)
+			}
+		})
+		return r
+	}
+
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("%v; run the test with -querybmtest.record to record it", err)
+		return nil
+	}
+	t.Cleanup(func() {
+		if err := db.ExpectationsWereMet(); err != nil {
+			t.Errorf("fixture %s: %v", path, err)
+		}
+	})
+	return db
+}
@@ -0,0 +1,448 @@
+package querybmtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tecowl/querybm"
+	"github.com/tecowl/querybm/expr"
+	"github.com/tecowl/querybm/statement"
+)
+
+func newRecordTestQuery(db querybm.DB, title string, opts ...querybm.Option) *querybm.Query[testBook] {
+	fields := querybm.NewFields([]string{"id", "title"}, func(s querybm.Scanner, b *testBook) error {
+		return s.Scan(&b.ID, &b.Title)
+	})
+	condition := querybm.NewBuilder(func(st *statement.Statement) {
+		st.Where.Add(expr.Field("title", expr.Eq(title)))
+	})
+	opts = append([]querybm.Option{querybm.WithDialect(querybm.PostgreSQL)}, opts...)
+	return querybm.NewWithDB(db, "books", fields, condition, querybm.NewSortItem("id", false), querybm.NewLimitOffset(10, 0), opts...)
+}
+
+// newRecordSourceDB returns a DB standing in for the real database to record.
+func newRecordSourceDB() *DB {
+	db := NewDB()
+	db.Expect(SQLContains("COUNT")).WithArgs("Go").WillReturnRows(NewRows("count").AddRow(int64(2)))
+	db.Expect(SQLContains("SELECT id")).WithArgs("Go", int64(10)).
+		WillReturnRows(NewRows("id", "title").AddRow(int64(1), "Go 101").AddRow(int64(2), "Learning Go"))
+	db.Expect(SQLContains("SELECT id")).WithArgs("none", int64(10))
+	db.Expect(SQLContains("SELECT id")).WithArgs("error", int64(10)).WillReturnError(errTest)
+	db.Expect(SQLContains("SELECT id")).WithArgs("rows", int64(10)).
+		WillReturnRows(NewRows("id", "title").AddRow(int64(3), "a").RowError(1, errTest))
+	return db
+}
+
+// recordScenario runs the queries of the source DB and describes their results.
+func recordScenario(ctx context.Context, db querybm.DB) []string {
+	var results []string
+	describe := func(err error) {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			results = append(results, "no rows")
+		case err != nil:
+			results = append(results, "error: "+err.Error())
+		}
+	}
+
+	page, err := newRecordTestQuery(db, "Go").Page(ctx)
+	describe(err)
+	if err == nil {
+		results = append(results, fmt.Sprintf("total: %d", page.Total))
+		for _, item := range page.Items {
+			results = append(results, item.Title)
+		}
+	}
+	_, err = newRecordTestQuery(db, "none").First(ctx)
+	describe(err)
+	_, err = newRecordTestQuery(db, "error").List(ctx)
+	describe(err)
+	_, err = newRecordTestQuery(db, "rows").List(ctx)
+	describe(err)
+	return results
+}
+
+func TestRecorderAndReplay(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "fixtures", "books.json")
+	want := []string{"total: 2", "Go 101", "Learning Go", "no rows", "error: test error", "error: test error"}
+
+	source := newRecordSourceDB()
+	recorder := NewRecorder(source)
+	if got := recordScenario(t.Context(), recorder); !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded results = %q, want %q", got, want)
+	}
+	if err := source.ExpectationsWereMet(); err != nil {
+		t.Errorf("source ExpectationsWereMet() error = %v", err)
+	}
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if got := recordScenario(t.Context(), db); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed results = %q, want %q", got, want)
+	}
+	if err := db.ExpectationsWereMet(); err != nil {
+		t.Errorf("ExpectationsWereMet() error = %v", err)
+	}
+	if _, err := newRecordTestQuery(db, "Go").Count(t.Context()); !errors.Is(err, ErrUnexpectedQuery) {
+		t.Errorf("Count() error = %v, want %v", err, ErrUnexpectedQuery)
+	}
+}
+
+func TestRecorder_NewDBWrapper(t *testing.T) {
+	t.Parallel()
+	if NewRecorder(querybm.NewDBWrapper(&sql.DB{})).InTx() {
+		t.Error("InTx() of NewDBWrapper(*sql.DB) = true, want false")
+	}
+	if !NewRecorder(querybm.NewDBWrapper(&sql.Tx{})).InTx() {
+		t.Error("InTx() of NewDBWrapper(*sql.Tx) = false, want true")
+	}
+}
+
+func TestRecorder_Fixture(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "count.json")
+	source := NewDB()
+	source.SetInTx(true)
+	source.Expect(AnySQL()).WillReturnRows(NewRows("count").AddRow(int64(2)))
+
+	recorder := NewRecorder(source)
+	if _, err := newRecordTestQuery(recorder, "Go").Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "in_tx": true,
+  "queries": [
+    {
+      "sql": "SELECT COUNT(*) AS count FROM books WHERE title = $1",
+      "args": [
+        "Go"
+      ],
+      "rows": [
+        [
+          2
+        ]
+      ]
+    }
+  ]
+}
+`
+	if string(got) != want {
+		t.Errorf("fixture =\n%s\nwant\n%s", got, want)
+	}
+
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if !db.InTx() {
+		t.Errorf("InTx() = false, want true")
+	}
+	if _, err := newRecordTestQuery(db, "Other").Count(t.Context()); err == nil || !strings.Contains(err.Error(), `with args ["Go"]`) {
+		t.Errorf("Count() error = %v, want unexpected query with the recorded args", err)
+	}
+}
+
+func TestRecorder_DriverValues(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "values.json")
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	source := NewDB()
+	source.Expect(AnySQL()).WillReturnRows(NewRows("note", "at", "code", "price", "memo", "flag").
+		AddRow("foo", at, []byte{0x00, 0xff}, 1.5, nil, int64(1)))
+
+	type result struct {
+		Note  sql.NullString
+		At    time.Time
+		Code  []byte
+		Price float64
+		Memo  *string
+		Flag  sql.NullBool
+	}
+	scan := func(db querybm.DirectDB) result {
+		t.Helper()
+		var r result
+		if err := db.QueryRowContext(t.Context(), "SELECT 1").Scan(&r.Note, &r.At, &r.Code, &r.Price, &r.Memo, &r.Flag); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		return r
+	}
+
+	recorder := NewRecorder(source)
+	recorded := scan(recorder)
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"foo",
+          {
+            "time": "2024-01-02T03:04:05.000006Z"
+          },
+          {
+            "bytes": "AP8="
+          },
+          1.5,
+          null,
+          true`; !strings.Contains(string(got), want) {
+		t.Errorf("fixture =\n%s\nwant the driver values\n%s", got, want)
+	}
+
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if replayed := scan(db); !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed = %+v, want %+v", replayed, recorded)
+	}
+}
+
+func TestRecorder_PrepareError(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "prepare.json")
+	source := NewDB()
+	source.Expect(AnySQL()).WillFailPrepare(errTest)
+
+	recorder := NewRecorder(source)
+	if _, err := newRecordTestQuery(recorder, "Go").Count(t.Context()); !errors.Is(err, errTest) {
+		t.Errorf("Count() error = %v, want %v", err, errTest)
+	}
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	db, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if _, err := newRecordTestQuery(db, "Go").Count(t.Context()); err == nil || err.Error() != errTest.Error() {
+		t.Errorf("Count() error = %v, want %v", err, errTest)
+	}
+}
+
+func TestRecorder_Direct(t *testing.T) {
+	t.Parallel()
+	source := NewDB()
+	source.Expect(SQLContains("COUNT")).WillReturnRows(NewRows("count").AddRow(int64(2)))
+	source.Expect(SQLContains("SELECT id")).WillReturnRows(NewRows("id", "title").AddRow(int64(1), "Go 101"))
+	recorder := NewRecorder(source)
+	if recorder.InTx() {
+		t.Errorf("InTx() = true, want false")
+	}
+
+	page, err := newRecordTestQuery(recorder, "Go", querybm.WithExecMode(querybm.ExecDirect)).Page(t.Context())
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	if page.Total != 2 || len(page.Items) != 1 {
+		t.Errorf("Page() = %+v, want 1 item of 2", page)
+	}
+	if got := len(recorder.queries); got != 2 {
+		t.Errorf("recorded queries = %v, want 2", got)
+	}
+}
+
+// prepareOnlyDB is a querybm.DB which doesn't implement querybm.DirectDB.
+type prepareOnlyDB struct {
+	querybm.DB
+}
+
+func TestRecorder_DirectUnsupported(t *testing.T) {
+	t.Parallel()
+	recorder := NewRecorder(prepareOnlyDB{NewDB()})
+	if recorder.InTx() {
+		t.Errorf("InTx() = true, want false")
+	}
+	if _, err := recorder.QueryContext(t.Context(), "SELECT 1"); !errors.Is(err, querybm.ErrDirectExecUnsupported) {
+		t.Errorf("QueryContext() error = %v, want %v", err, querybm.ErrDirectExecUnsupported)
+	}
+	if err := recorder.QueryRowContext(t.Context(), "SELECT 1").Scan(); !errors.Is(err, querybm.ErrDirectExecUnsupported) {
+		t.Errorf("QueryRowContext() error = %v, want %v", err, querybm.ErrDirectExecUnsupported)
+	}
+}
+
+// unmarshalableValue is a value which can't be encoded by encoding/json.
+type unmarshalableValue struct {
+	C chan int
+}
+
+// Scan implements sql.Scanner.
+func (v *unmarshalableValue) Scan(any) error { return nil }
+
+func TestRecorder_MarshalErrors(t *testing.T) {
+	t.Parallel()
+	source := NewDB()
+	source.Expect(AnySQL()).WillReturnRows(NewRows("v").AddRow(1)).Times(0)
+	recorder := NewRecorder(source)
+
+	if _, err := recorder.QueryContext(t.Context(), "SELECT ?", make(chan int)); err == nil {
+		t.Errorf("QueryContext() error = nil, want error")
+	}
+	if err := recorder.QueryRowContext(t.Context(), "SELECT ?", make(chan int)).Scan(); err == nil {
+		t.Errorf("QueryRowContext() error = nil, want error")
+	}
+
+	rows, err := recorder.QueryContext(t.Context(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatalf("Next() = false, want true")
+	}
+	if err := rows.Scan(&unmarshalableValue{}); err == nil {
+		t.Errorf("Rows.Scan() error = nil, want error")
+	}
+	if err := recorder.QueryRowContext(t.Context(), "SELECT 1").Scan(&unmarshalableValue{}); err == nil {
+		t.Errorf("Row.Scan() error = nil, want error")
+	}
+}
+
+func TestRecorder_ScanErrors(t *testing.T) {
+	t.Parallel()
+	source := NewDB()
+	source.Expect(AnySQL()).WillReturnRows(NewRows("v").AddRow("a")).Times(0)
+	recorder := NewRecorder(source)
+
+	rows, err := recorder.QueryContext(t.Context(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	defer rows.Close()
+	var n int
+	if !rows.Next() || rows.Scan(&n) == nil {
+		t.Errorf("Rows.Scan() error = nil, want error")
+	}
+	row := recorder.QueryRowContext(t.Context(), "SELECT 2")
+	if row.Err() != nil || row.Scan(&n) == nil {
+		t.Errorf("Row.Scan() error = nil, want error")
+	}
+	if got := recorder.queries[1].Error; got == "" {
+		t.Errorf("recorded error = %q, want the scan error", got)
+	}
+
+	failing := NewDB()
+	failing.Expect(AnySQL()).WillReturnError(errTest)
+	recorder = NewRecorder(failing)
+	if err := recorder.QueryRowContext(t.Context(), "SELECT 3").Err(); !errors.Is(err, errTest) {
+		t.Errorf("Row.Err() error = %v, want %v", err, errTest)
+	}
+	if got := recorder.queries[0].Error; got != errTest.Error() {
+		t.Errorf("recorded error = %q, want %q", got, errTest.Error())
+	}
+}
+
+func TestRecorder_SaveError(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	recorder := NewRecorder(NewDB())
+	if err := recorder.Save(filepath.Join(file, "fixture.json")); err == nil {
+		t.Errorf("Save() under a file error = nil, want error")
+	}
+	if err := recorder.Save(dir); err == nil {
+		t.Errorf("Save() to a directory error = nil, want error")
+	}
+}
+
+func TestReplay_Errors(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Replay(path); err == nil {
+		t.Errorf("Replay() error = nil, want error")
+	}
+	if _, err := Replay(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Replay() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestUseFixture(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	recordTB := &fakeTB{TB: t}
+	source := NewDB()
+	source.Expect(AnySQL()).WillReturnRows(NewRows("count").AddRow(int64(2)))
+	db := useFixture(recordTB, path, func() querybm.DB { return source }, true)
+	if _, ok := db.(*Recorder); !ok {
+		t.Fatalf("useFixture() = %T, want *Recorder", db)
+	}
+	if _, err := newRecordTestQuery(db, "Go").Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	recordTB.runCleanups()
+	if len(recordTB.errors) != 0 {
+		t.Fatalf("errors = %v, want none", recordTB.errors)
+	}
+
+	connect := func() querybm.DB {
+		t.Error("connect is called in the replay mode")
+		return nil
+	}
+	replayTB := &fakeTB{TB: t}
+	db = useFixture(replayTB, path, connect, false)
+	if got, err := newRecordTestQuery(db, "Go").Count(t.Context()); err != nil || got != 2 {
+		t.Errorf("Count() = %v, %v, want 2", got, err)
+	}
+	replayTB.runCleanups()
+	if len(replayTB.errors) != 0 {
+		t.Errorf("errors = %v, want none", replayTB.errors)
+	}
+
+	unusedTB := &fakeTB{TB: t}
+	useFixture(unusedTB, path, connect, false)
+	unusedTB.runCleanups()
+	if len(unusedTB.errors) != 1 || !strings.Contains(unusedTB.errors[0], "expected") {
+		t.Errorf("errors = %v, want an unused query error", unusedTB.errors)
+	}
+
+	missingTB := &fakeTB{TB: t}
+	useFixture(missingTB, filepath.Join(t.TempDir(), "missing.json"), connect, false)
+	if !missingTB.fatal {
+		t.Errorf("errors = %v, want fatal", missingTB.errors)
+	}
+
+	saveTB := &fakeTB{TB: t}
+	useFixture(saveTB, t.TempDir(), func() querybm.DB { return NewDB() }, true)
+	saveTB.runCleanups()
+	if len(saveTB.errors) != 1 {
+		t.Errorf("errors = %v, want a save error", saveTB.errors)
+	}
+}
+
+func TestUseFixture_Replay(t *testing.T) {
+	t.Parallel()
+	// The fixture is recorded on the DB of newRecordSourceDB with the -querybmtest.record flag.
+	db := UseFixture(t, filepath.Join("testdata", t.Name(), "books.json"), func() querybm.DB { return newRecordSourceDB() })
+	want := []string{"total: 2", "Go 101", "Learning Go", "no rows", "error: test error", "error: test error"}
+	if got := recordScenario(t.Context(), db); !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %q, want %q", got, want)
+	}
+}
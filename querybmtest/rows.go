@@ -0,0 +1,188 @@
+package querybmtest
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Rows is the canned result of a query.
+type Rows struct {
+	columns []string
+	values  [][]any
+	errs    map[int]error
+}
+
+// NewRows creates Rows with the columns. Scan requires as many destinations as the columns.
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns, errs: map[int]error{}}
+}
+
+// AddRow adds a row with the values of the columns. It panics if the number of the values differs from the columns.
+func (r *Rows) AddRow(values ...any) *Rows {
+	if len(values) != len(r.columns) {
+		panic(fmt.Sprintf("querybmtest: AddRow got %d values for %d columns", len(values), len(r.columns)))
+	}
+	r.values = append(r.values, values)
+	return r
+}
+
+// RowError makes the iteration stop at the row of the index with err reported by Err.
+func (r *Rows) RowError(index int, err error) *Rows {
+	r.errs[index] = err
+	return r
+}
+
+// Columns returns the columns of the rows.
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// fakeRows iterates over Rows. It implements querybm.Rows.
+type fakeRows struct {
+	rows   *Rows
+	index  int
+	err    error
+	closed bool
+}
+
+// Close implements querybm.Rows.
+func (r *fakeRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+// Err implements querybm.Rows.
+func (r *fakeRows) Err() error {
+	return r.err
+}
+
+// Next implements querybm.Rows.
+func (r *fakeRows) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+	r.index++
+	if err, ok := r.rows.errs[r.index-1]; ok {
+		r.err = err
+		return false
+	}
+	return r.index <= len(r.rows.values)
+}
+
+// Scan implements querybm.Rows.
+func (r *fakeRows) Scan(dest ...any) error {
+	if r.closed {
+		return errors.New("querybmtest: Rows are closed")
+	}
+	if r.index < 1 || r.index > len(r.rows.values) {
+		return errors.New("querybmtest: Scan called without calling Next")
+	}
+	return scanValues(r.rows.values[r.index-1], dest)
+}
+
+// fakeRow is the first row of a result. It implements querybm.Row.
+type fakeRow struct {
+	rows *Rows
+	err  error
+}
+
+// Err implements querybm.Row.
+func (r *fakeRow) Err() error {
+	return r.err
+}
+
+// Scan implements querybm.Row. It returns sql.ErrNoRows if there are no rows.
+func (r *fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	rows := &fakeRows{rows: r.rows}
+	if !rows.Next() {
+		if rows.err != nil {
+			return rows.err
+		}
+		return sql.ErrNoRows
+	}
+	return rows.Scan(dest...)
+}
+
+// scanValues assigns the values of a row to the destinations.
+func scanValues(values, dest []any) error {
+	if len(dest) != len(values) {
+		return fmt.Errorf("querybmtest: expected %d destination arguments in Scan, not %d", len(values), len(dest))
+	}
+	for i, v := range values {
+		if err := assign(dest[i], v); err != nil {
+			return fmt.Errorf("querybmtest: Scan error on column index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// assign assigns the value to the destination like database/sql.
+// Values replayed from a fixture file are decoded into their driver values first.
+// sql.Scanner destinations scan the value, and the other destinations must be pointers
+// to the types which the value is assignable or convertible to.
+func assign(dest, v any) error {
+	if raw, ok := v.(recordedValue); ok {
+		value, err := raw.driverValue()
+		if err != nil {
+			return err
+		}
+		v = value
+	}
+	if s, ok := dest.(sql.Scanner); ok {
+		return s.Scan(v)
+	}
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return errors.New("destination not a pointer")
+	}
+	dv = dv.Elem()
+	if v == nil {
+		switch dv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.SetZero()
+			return nil
+		default:
+			return fmt.Errorf("converting NULL to %s is unsupported", dv.Type())
+		}
+	}
+	sv := reflect.ValueOf(v)
+	switch {
+	case sv.Type().AssignableTo(dv.Type()):
+		dv.Set(sv)
+	case dv.Kind() == reflect.Pointer:
+		p := reflect.New(dv.Type().Elem())
+		if err := assign(p.Interface(), v); err != nil {
+			return err
+		}
+		dv.Set(p)
+	case convertible(sv, dv.Type()):
+		dv.Set(sv.Convert(dv.Type()))
+	default:
+		return fmt.Errorf("unsupported Scan, storing %T into type %s", v, dv.Type())
+	}
+	return nil
+}
+
+// convertible reports whether the value can be converted to the type without changing its meaning,
+// that is, between numbers or between strings and byte slices.
+func convertible(v reflect.Value, t reflect.Type) bool {
+	if !v.CanConvert(t) {
+		return false
+	}
+	return isNumber(v.Kind()) && isNumber(t.Kind()) || isText(v.Type()) && isText(t)
+}
+
+// isNumber reports whether the kind is a number.
+func isNumber(k reflect.Kind) bool {
+	return reflect.Int <= k && k <= reflect.Float64
+}
+
+// isText reports whether the type is a string or a byte slice.
+func isText(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
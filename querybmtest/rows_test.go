@@ -0,0 +1,141 @@
+package querybmtest
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAssign(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	str := "foo"
+	yes := true
+	tests := []struct {
+		name    string
+		dest    func() any
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{name: "assignable", dest: func() any { return new(string) }, value: "foo", want: "foo"},
+		{name: "time", dest: func() any { return new(time.Time) }, value: now, want: now},
+		{name: "int to int64", dest: func() any { return new(int64) }, value: 1, want: int64(1)},
+		{name: "int64 to float64", dest: func() any { return new(float64) }, value: int64(2), want: 2.0},
+		{name: "bytes to string", dest: func() any { return new(string) }, value: []byte("foo"), want: "foo"},
+		{name: "string to bytes", dest: func() any { return new([]byte) }, value: "foo", want: []byte("foo")},
+		{name: "pointer", dest: func() any { return new(*string) }, value: "foo", want: &str},
+		{name: "NULL to pointer", dest: func() any { return new(*string) }, value: nil, want: (*string)(nil)},
+		{name: "any", dest: func() any { return new(any) }, value: 1, want: any(1)},
+		{name: "scanner", dest: func() any { return new(sql.NullString) }, value: "foo", want: sql.NullString{String: "foo", Valid: true}},
+		{name: "NULL to scanner", dest: func() any { return new(sql.NullInt64) }, value: nil, want: sql.NullInt64{}},
+		{name: "NULL to string", dest: func() any { return new(string) }, value: nil, wantErr: true},
+		{name: "int to string", dest: func() any { return new(string) }, value: 65, wantErr: true},
+		{name: "string to int", dest: func() any { return new(int) }, value: "1", wantErr: true},
+		{name: "pointer of unsupported", dest: func() any { return new(*int) }, value: "1", wantErr: true},
+		{name: "recorded number to scanner", dest: func() any { return new(sql.NullInt64) }, value: recordedValue(`3`), want: sql.NullInt64{Int64: 3, Valid: true}},
+		{name: "recorded float", dest: func() any { return new(float64) }, value: recordedValue(`1.5`), want: 1.5},
+		{name: "recorded string to scanner", dest: func() any { return new(sql.NullString) }, value: recordedValue(`"foo"`), want: sql.NullString{String: "foo", Valid: true}},
+		{name: "recorded NULL to scanner", dest: func() any { return new(sql.NullString) }, value: recordedValue(`null`), want: sql.NullString{}},
+		{name: "recorded time", dest: func() any { return new(time.Time) }, value: recordedValue(`{"time":"2024-01-02T03:04:05Z"}`), want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "recorded bytes to string", dest: func() any { return new(string) }, value: recordedValue(`{"bytes":"Zm9v"}`), want: "foo"},
+		{name: "recorded bool to pointer", dest: func() any { return new(*bool) }, value: recordedValue(`true`), want: &yes},
+		{name: "recorded string to time", dest: func() any { return new(time.Time) }, value: recordedValue(`"2024-01-02"`), wantErr: true},
+		{name: "unsupported recorded value", dest: func() any { return new(any) }, value: recordedValue(`[1]`), wantErr: true},
+		{name: "invalid recorded value", dest: func() any { return new(any) }, value: recordedValue(`{`), wantErr: true},
+		{name: "not a pointer", dest: func() any { return 1 }, value: 1, wantErr: true},
+		{name: "nil pointer", dest: func() any { return (*int)(nil) }, value: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dest := tt.dest()
+			err := assign(dest, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("assign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := reflect.ValueOf(dest).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assign() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRows(t *testing.T) {
+	t.Parallel()
+	rows := NewRows("id", "title").AddRow(1, "a").AddRow(2, "b")
+	if want := []string{"id", "title"}; !reflect.DeepEqual(rows.Columns(), want) {
+		t.Errorf("Columns() = %v, want %v", rows.Columns(), want)
+	}
+
+	r := &fakeRows{rows: rows}
+	var id int
+	var title string
+	if err := r.Scan(&id, &title); err == nil {
+		t.Errorf("Scan() before Next error = nil, want error")
+	}
+	var got []string
+	for r.Next() {
+		if err := r.Scan(&id); err == nil {
+			t.Errorf("Scan() with a destination error = nil, want error")
+		}
+		if err := r.Scan(&id, &id); err == nil {
+			t.Errorf("Scan() into int error = nil, want error")
+		}
+		if err := r.Scan(&id, &title); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		got = append(got, title)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("titles = %v, want %v", got, want)
+	}
+	if err := r.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+
+	r = &fakeRows{rows: rows}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if r.Next() {
+		t.Errorf("Next() after Close = true, want false")
+	}
+	if err := r.Scan(&id, &title); err == nil {
+		t.Errorf("Scan() after Close error = nil, want error")
+	}
+}
+
+func TestRows_AddRowPanic(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("AddRow() didn't panic")
+		}
+	}()
+	NewRows("id", "title").AddRow(1)
+}
+
+func TestRow(t *testing.T) {
+	t.Parallel()
+	var id int
+	if err := (&fakeRow{err: errTest}).Scan(&id); !errors.Is(err, errTest) {
+		t.Errorf("Scan() error = %v, want %v", err, errTest)
+	}
+	if err := (&fakeRow{rows: NewRows("id")}).Scan(&id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Scan() error = %v, want %v", err, sql.ErrNoRows)
+	}
+	row := &fakeRow{rows: NewRows("id").AddRow(7).AddRow(8)}
+	if err := row.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+	if err := row.Scan(&id); err != nil || id != 7 {
+		t.Errorf("Scan() = %v, %v, want 7", id, err)
+	}
+}
@@ -0,0 +1,161 @@
+package querybm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tecowl/querybm/statement"
+)
+
+// RedactedValue replaces the arguments for the sensitive columns in the logs of SlogHook.
+const RedactedValue = "[REDACTED]"
+
+// SlogHook is a Hook which logs the executed queries to a *slog.Logger with the attributes
+// kind, sql, args, duration and rows, and error if the query fails.
+// Register it with RegisterHook or WithHooks.
+type SlogHook struct {
+	logger        *slog.Logger
+	level         slog.Level
+	slowThreshold time.Duration
+	slowLevel     slog.Level
+	sensitive     map[string]bool
+}
+
+var _ Hook = (*SlogHook)(nil)
+
+// SlogOption configures a SlogHook.
+type SlogOption func(*SlogHook)
+
+// SlogLevel sets the level of the logs. It is slog.LevelDebug by default.
+// Failed queries are logged with slog.LevelError.
+func SlogLevel(level slog.Level) SlogOption {
+	return func(h *SlogHook) {
+		h.level = level
+	}
+}
+
+// SlogSlowThreshold makes the queries taking threshold or longer logged with the level
+// and the attribute slow=true.
+func SlogSlowThreshold(threshold time.Duration, level slog.Level) SlogOption {
+	return func(h *SlogHook) {
+		h.slowThreshold = threshold
+		h.slowLevel = level
+	}
+}
+
+// SlogSensitiveColumns marks the columns sensitive, so that the arguments compared with them
+// are logged as RedactedValue. The columns are matched case-insensitively with or without their qualifier,
+// so "password" matches both password and users.password. See statement.ArgColumns for the matching columns.
+// If the SQL mentions a sensitive column, the arguments which aren't resolved to any column are redacted too.
+func SlogSensitiveColumns(columns ...string) SlogOption {
+	return func(h *SlogHook) {
+		for _, col := range columns {
+			h.sensitive[strings.ToLower(col)] = true
+		}
+	}
+}
+
+// NewSlogHook creates a SlogHook logging to logger. slog.Default() is used when logger is nil.
+func NewSlogHook(logger *slog.Logger, opts ...SlogOption) *SlogHook {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	h := &SlogHook{logger: logger, level: slog.LevelDebug, sensitive: map[string]bool{}}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// BeforeQuery implements Hook.
+func (h *SlogHook) BeforeQuery(ctx context.Context, _ *QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery implements Hook.
+func (h *SlogHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	level := h.level
+	slow := h.slowThreshold > 0 && event.Duration >= h.slowThreshold
+	if slow {
+		level = h.slowLevel
+	}
+	failed := event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows)
+	if failed {
+		level = slog.LevelError
+	}
+	if !h.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("kind", string(event.Kind)),
+		slog.String("sql", event.SQL),
+		slog.Any("args", h.redact(event.SQL, event.Args)),
+		slog.Duration("duration", event.Duration),
+		slog.Int64("rows", event.Rows),
+	}
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.Any("error", event.Err))
+	}
+	h.logger.LogAttrs(ctx, level, "querybm query", attrs...)
+}
+
+// redact returns the arguments with the ones for the sensitive columns replaced by RedactedValue.
+func (h *SlogHook) redact(queryStr string, args []any) []any {
+	if len(h.sensitive) == 0 || len(args) == 0 {
+		return args
+	}
+	columns := statement.ArgColumns(queryStr)
+	mentioned := h.mentionsSensitive(queryStr)
+	var result []any
+	for i := range args {
+		var col string
+		if i < len(columns) {
+			col = columns[i]
+		}
+		if !h.isSensitive(col) && (col != "" || !mentioned) {
+			continue
+		}
+		if result == nil {
+			result = append([]any(nil), args...)
+		}
+		result[i] = RedactedValue
+	}
+	if result == nil {
+		return args
+	}
+	return result
+}
+
+// mentionsSensitive reports whether the query may refer to a sensitive column.
+func (h *SlogHook) mentionsSensitive(queryStr string) bool {
+	queryStr = strings.ToLower(queryStr)
+	for column := range h.sensitive {
+		if i := strings.LastIndexByte(column, '.'); i >= 0 {
+			column = column[i+1:]
+		}
+		if strings.Contains(queryStr, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitive reports whether the column is sensitive with or without its qualifier.
+func (h *SlogHook) isSensitive(column string) bool {
+	column = strings.ToLower(column)
+	if h.sensitive[column] {
+		return true
+	}
+	if i := strings.LastIndexByte(column, '.'); i >= 0 {
+		return h.sensitive[column[i+1:]]
+	}
+	return false
+}
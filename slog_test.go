@@ -0,0 +1,215 @@
+package querybm
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slogTestHandler records the logs.
+type slogTestHandler struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []slogTestRecord
+}
+
+type slogTestRecord struct {
+	level slog.Level
+	attrs map[string]any
+}
+
+func (h *slogTestHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *slogTestHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	attrs := map[string]any{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+	h.records = append(h.records, slogTestRecord{level: r.Level, attrs: attrs})
+	return nil
+}
+
+func (h *slogTestHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *slogTestHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestSlogHook(t *testing.T) {
+	t.Parallel()
+	const query = "SELECT id FROM users WHERE name = $1 AND users.password = $2 LIMIT $3"
+	tests := []struct {
+		name      string
+		opts      []SlogOption
+		level     slog.Level
+		event     QueryEvent
+		wantLevel slog.Level
+		wantAttrs map[string]any
+		wantNone  bool
+	}{
+		{
+			name:      "default",
+			level:     slog.LevelDebug,
+			event:     QueryEvent{Kind: QueryKindList, SQL: query, Args: []any{"foo", "secret", 10}, Duration: time.Second, Rows: 2},
+			wantLevel: slog.LevelDebug,
+			wantAttrs: map[string]any{
+				"kind": "list", "sql": query, "args": []any{"foo", "secret", 10}, "duration": time.Second, "rows": int64(2),
+			},
+		},
+		{
+			name:     "disabled level",
+			level:    slog.LevelInfo,
+			event:    QueryEvent{Kind: QueryKindList, SQL: query, Args: []any{"foo", "secret", 10}},
+			wantNone: true,
+		},
+		{
+			name:      "level and sensitive columns",
+			opts:      []SlogOption{SlogLevel(slog.LevelInfo), SlogSensitiveColumns("PASSWORD", "token")},
+			level:     slog.LevelInfo,
+			event:     QueryEvent{Kind: QueryKindList, SQL: query, Args: []any{"foo", "secret", 10}, Rows: 1},
+			wantLevel: slog.LevelInfo,
+			// The argument of LIMIT isn't resolved to a column, so it is redacted because the SQL mentions password.
+			wantAttrs: map[string]any{
+				"kind": "list", "sql": query, "args": []any{"foo", RedactedValue, RedactedValue}, "duration": time.Duration(0), "rows": int64(1),
+			},
+		},
+		{
+			name:  "function-wrapped sensitive columns",
+			opts:  []SlogOption{SlogSensitiveColumns("password", "email")},
+			level: slog.LevelDebug,
+			event: QueryEvent{
+				Kind: QueryKindFirst, SQL: "SELECT id FROM users WHERE password = SHA2(?, 256) AND email = LOWER(?) AND status = ?",
+				Args: []any{"secret", "a@example.com", "active"},
+			},
+			wantLevel: slog.LevelDebug,
+			wantAttrs: map[string]any{
+				"kind": "first", "sql": "SELECT id FROM users WHERE password = SHA2(?, 256) AND email = LOWER(?) AND status = ?",
+				"args": []any{RedactedValue, RedactedValue, "active"}, "duration": time.Duration(0), "rows": int64(0),
+			},
+		},
+		{
+			name:  "reversed comparison",
+			opts:  []SlogOption{SlogSensitiveColumns("password")},
+			level: slog.LevelDebug,
+			event: QueryEvent{
+				Kind: QueryKindFirst, SQL: "SELECT id FROM users WHERE ? = password AND name = ?",
+				Args: []any{"secret", "foo"},
+			},
+			wantLevel: slog.LevelDebug,
+			wantAttrs: map[string]any{
+				"kind": "first", "sql": "SELECT id FROM users WHERE ? = password AND name = ?",
+				"args": []any{RedactedValue, "foo"}, "duration": time.Duration(0), "rows": int64(0),
+			},
+		},
+		{
+			name:  "unresolved arguments",
+			opts:  []SlogOption{SlogSensitiveColumns("password")},
+			level: slog.LevelDebug,
+			event: QueryEvent{
+				Kind: QueryKindFirst, SQL: "SELECT id FROM users WHERE password = CONCAT(salt, ?) AND name = ?",
+				Args: []any{"secret", "foo", "extra"},
+			},
+			wantLevel: slog.LevelDebug,
+			wantAttrs: map[string]any{
+				"kind": "first", "sql": "SELECT id FROM users WHERE password = CONCAT(salt, ?) AND name = ?",
+				"args": []any{RedactedValue, "foo", RedactedValue}, "duration": time.Duration(0), "rows": int64(0),
+			},
+		},
+		{
+			name:      "no sensitive arguments",
+			opts:      []SlogOption{SlogSensitiveColumns("users.token")},
+			level:     slog.LevelDebug,
+			event:     QueryEvent{Kind: QueryKindCount, SQL: query, Args: []any{"foo", "secret", 10}, Rows: 1},
+			wantLevel: slog.LevelDebug,
+			wantAttrs: map[string]any{
+				"kind": "count", "sql": query, "args": []any{"foo", "secret", 10}, "duration": time.Duration(0), "rows": int64(1),
+			},
+		},
+		{
+			name:      "slow query",
+			opts:      []SlogOption{SlogSlowThreshold(time.Second, slog.LevelWarn)},
+			level:     slog.LevelInfo,
+			event:     QueryEvent{Kind: QueryKindFirst, SQL: "SELECT 1", Duration: 2 * time.Second, Rows: 1},
+			wantLevel: slog.LevelWarn,
+			wantAttrs: map[string]any{
+				"kind": "first", "sql": "SELECT 1", "args": []any(nil), "duration": 2 * time.Second, "rows": int64(1), "slow": true,
+			},
+		},
+		{
+			name:      "failed query",
+			opts:      []SlogOption{SlogSlowThreshold(time.Second, slog.LevelWarn)},
+			level:     slog.LevelInfo,
+			event:     QueryEvent{Kind: QueryKindList, SQL: "SELECT 1", Err: errConditionError},
+			wantLevel: slog.LevelError,
+			wantAttrs: map[string]any{
+				"kind": "list", "sql": "SELECT 1", "args": []any(nil), "duration": time.Duration(0), "rows": int64(0), "error": errConditionError,
+			},
+		},
+		{
+			name:      "no rows",
+			level:     slog.LevelDebug,
+			event:     QueryEvent{Kind: QueryKindFirst, SQL: "SELECT 1", Err: sql.ErrNoRows},
+			wantLevel: slog.LevelDebug,
+			wantAttrs: map[string]any{
+				"kind": "first", "sql": "SELECT 1", "args": []any(nil), "duration": time.Duration(0), "rows": int64(0), "error": sql.ErrNoRows,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			handler := &slogTestHandler{level: tt.level}
+			h := NewSlogHook(slog.New(handler), tt.opts...)
+			event := tt.event
+			ctx := h.BeforeQuery(t.Context(), &event)
+			h.AfterQuery(ctx, &event)
+
+			if tt.wantNone {
+				if len(handler.records) != 0 {
+					t.Errorf("records = %+v, want none", handler.records)
+				}
+				return
+			}
+			want := []slogTestRecord{{level: tt.wantLevel, attrs: tt.wantAttrs}}
+			if !reflect.DeepEqual(handler.records, want) {
+				t.Errorf("records = %+v, want %+v", handler.records, want)
+			}
+			if !reflect.DeepEqual(event, tt.event) {
+				t.Errorf("event = %+v, want unchanged %+v", event, tt.event)
+			}
+		})
+	}
+}
+
+func TestSlogHook_Query(t *testing.T) {
+	t.Parallel()
+	handler := &slogTestHandler{}
+	q := newTestQuery(&RecordingDB{row: []any{int64(3)}}, "users", WithDialect(PostgreSQL), WithHooks(NewSlogHook(slog.New(handler), SlogLevel(slog.LevelInfo), SlogSensitiveColumns("status"))))
+	if _, err := q.Count(t.Context()); err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if len(handler.records) != 1 {
+		t.Fatalf("records = %+v, want 1 record", handler.records)
+	}
+	attrs := handler.records[0].attrs
+	if want := "SELECT COUNT(*) AS count FROM users WHERE status = $1"; attrs["sql"] != want {
+		t.Errorf("sql = %v, want %v", attrs["sql"], want)
+	}
+	if want := []any{RedactedValue}; !reflect.DeepEqual(attrs["args"], want) {
+		t.Errorf("args = %v, want %v", attrs["args"], want)
+	}
+}
+
+func TestNewSlogHook_DefaultLogger(t *testing.T) {
+	t.Parallel()
+	if h := NewSlogHook(nil); h.logger != slog.Default() {
+		t.Errorf("NewSlogHook(nil) logger = %v, want slog.Default()", h.logger)
+	}
+}
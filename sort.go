@@ -11,8 +11,9 @@ type Sort = Builder
 
 // SortItem represents a single column to sort by with its direction.
 type SortItem struct {
-	column string
-	desc   bool
+	column   string
+	desc     bool
+	nullable bool
 }
 
 var _ Sort = (*SortItem)(nil)
@@ -23,6 +24,12 @@ func NewSortItem(column string, desc bool) *SortItem {
 	return &SortItem{column: column, desc: desc}
 }
 
+// NewNullableSortItem creates a new SortItem for a column which can be NULL.
+// Keyset pages through the NULL values of the column in the order of the dialect.
+func NewNullableSortItem(column string, desc bool) *SortItem {
+	return &SortItem{column: column, desc: desc, nullable: true}
+}
+
 // ErrEmptySortItem is returned when a sort item has an empty column name.
 var ErrEmptySortItem = errors.New("sort item cannot be empty")
 
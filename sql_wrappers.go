@@ -5,25 +5,92 @@ import (
 	"database/sql"
 )
 
+// DBTX is the interface implemented by *sql.DB, *sql.Tx and *sql.Conn.
+// It has the same methods as the DBTX interface generated by sqlc, so a sqlc DBTX can be passed as is.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+var (
+	_ DBTX = (*sql.DB)(nil)
+	_ DBTX = (*sql.Tx)(nil)
+	_ DBTX = (*sql.Conn)(nil)
+)
+
 type DB interface {
 	PrepareContext(ctx context.Context, query string) (Stmt, error)
 }
 
+// DirectDB is implemented by DB which runs SQL directly without preparing a statement.
+// It is required by ExecDirect.
+type DirectDB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) Row
+}
+
 type DBWrapper struct {
-	db *sql.DB
+	db    DBTX
+	cache *StmtCache
 }
 
-var _ DB = (*DBWrapper)(nil)
+var (
+	_ DB       = (*DBWrapper)(nil)
+	_ DirectDB = (*DBWrapper)(nil)
+)
 
-func newDBWrapper(db *sql.DB) *DBWrapper {
+func newDBWrapper(db DBTX) *DBWrapper {
 	return &DBWrapper{db: db}
 }
 
+// NewDBWrapper creates a DB executing the queries on db as New does.
+// Use it to pass a DBTX to the functions taking a DB such as NewWithDB.
+func NewDBWrapper(db DBTX) *DBWrapper {
+	return newDBWrapper(db)
+}
+
+// NewCachedDB creates a DB which reuses the prepared statements in the cache.
+// Pass it to NewWithDB to create a Query executing on it, or use WithStmtCache for a query created with New.
+// db should be *sql.DB or *sql.Conn. The statements aren't cached if db is *sql.Tx. See StmtCache.
+func NewCachedDB(db DBTX, cache *StmtCache) *DBWrapper {
+	return &DBWrapper{db: db, cache: cache}
+}
+
+// TxReporter is implemented by DB which reports whether it runs in a transaction.
+// Queries with a locking clause such as FOR UPDATE are executed only on a DB reporting true.
+type TxReporter interface {
+	InTx() bool
+}
+
+var _ TxReporter = (*DBWrapper)(nil)
+
+// InTx implements TxReporter. It returns true if the wrapped DBTX is *sql.Tx.
+func (w *DBWrapper) InTx() bool {
+	_, ok := w.db.(*sql.Tx)
+	return ok
+}
+
 func (w *DBWrapper) PrepareContext(ctx context.Context, query string) (Stmt, error) { // nolint:ireturn
+	if w.cache != nil && !w.InTx() {
+		return w.cache.prepare(ctx, newDBWrapper(w.db), query)
+	}
 	stmt, err := w.db.PrepareContext(ctx, query)
 	return newStmtWrapper(stmt), err
 }
 
+// QueryContext implements DirectDB.
+func (w *DBWrapper) QueryContext(ctx context.Context, query string, args ...any) (Rows, error) { // nolint:ireturn
+	rows, err := w.db.QueryContext(ctx, query, args...) // nolint:rowserrcheck
+	return rows, err
+}
+
+// QueryRowContext implements DirectDB.
+func (w *DBWrapper) QueryRowContext(ctx context.Context, query string, args ...any) Row { // nolint:ireturn
+	return w.db.QueryRowContext(ctx, query, args...)
+}
+
 type Stmt interface {
 	Close() error
 	QueryRowContext(ctx context.Context, args ...any) Row
@@ -0,0 +1,193 @@
+package querybm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"maps"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// wrapperTestDriver is a database/sql driver counting the prepared and closed statements per query.
+// The statements return a row with the query and the number of its arguments.
+type wrapperTestDriver struct {
+	mu       sync.Mutex
+	prepared map[string]int
+	closed   map[string]int
+}
+
+var (
+	_ driver.Driver    = (*wrapperTestDriver)(nil)
+	_ driver.Connector = (*wrapperTestDriver)(nil)
+)
+
+// openWrapperTestDB opens a *sql.DB on a new wrapperTestDriver.
+func openWrapperTestDB(t *testing.T) (*sql.DB, *wrapperTestDriver) {
+	t.Helper()
+	d := &wrapperTestDriver{prepared: map[string]int{}, closed: map[string]int{}}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func (d *wrapperTestDriver) Open(string) (driver.Conn, error)             { return &wrapperTestConn{d: d}, nil } // nolint:ireturn
+func (d *wrapperTestDriver) Connect(context.Context) (driver.Conn, error) { return d.Open("") }                  // nolint:ireturn
+func (d *wrapperTestDriver) Driver() driver.Driver                        { return d }                           // nolint:ireturn
+
+func (d *wrapperTestDriver) counts() (map[string]int, map[string]int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return maps.Clone(d.prepared), maps.Clone(d.closed)
+}
+
+type wrapperTestConn struct {
+	d *wrapperTestDriver
+}
+
+func (c *wrapperTestConn) Prepare(query string) (driver.Stmt, error) { // nolint:ireturn
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.prepared[query]++
+	return &wrapperTestStmt{d: c.d, query: query}, nil
+}
+
+func (c *wrapperTestConn) Close() error              { return nil }
+func (c *wrapperTestConn) Begin() (driver.Tx, error) { return c, nil } // nolint:ireturn
+func (c *wrapperTestConn) Commit() error             { return nil }
+func (c *wrapperTestConn) Rollback() error           { return nil }
+
+type wrapperTestStmt struct {
+	d     *wrapperTestDriver
+	query string
+}
+
+func (s *wrapperTestStmt) Close() error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.closed[s.query]++
+	return nil
+}
+
+func (s *wrapperTestStmt) NumInput() int { return -1 }
+
+func (s *wrapperTestStmt) Exec([]driver.Value) (driver.Result, error) { // nolint:ireturn
+	return driver.RowsAffected(0), nil
+}
+
+func (s *wrapperTestStmt) Query(args []driver.Value) (driver.Rows, error) { // nolint:ireturn
+	return &wrapperTestRows{values: []driver.Value{s.query, int64(len(args))}}, nil
+}
+
+type wrapperTestRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *wrapperTestRows) Columns() []string { return []string{"query", "args"} }
+func (r *wrapperTestRows) Close() error      { return nil }
+
+func (r *wrapperTestRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestNewCachedDB(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	sqlDB, d := openWrapperTestDB(t)
+	cache := NewStmtCache(1)
+	db := NewCachedDB(sqlDB, cache)
+
+	use := func(db DB, query string) {
+		t.Helper()
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			t.Fatalf("PrepareContext(%q) error = %v", query, err)
+		}
+		var gotQuery string
+		var gotArgs int64
+		if err := stmt.QueryRowContext(ctx, 1).Scan(&gotQuery, &gotArgs); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if gotQuery != query || gotArgs != 1 {
+			t.Errorf("QueryRowContext() = %v, %v, want %v, %v", gotQuery, gotArgs, query, 1)
+		}
+		if err := stmt.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	use(db, "q1")
+	use(db, "q1")
+	// The wrapper of the same DB shares the cached statements.
+	use(NewCachedDB(sqlDB, cache), "q1")
+	use(db, "q2") // evicts q1
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	use(NewCachedDB(tx, cache), "q2")
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	prepared, closed := d.counts()
+	if want := map[string]int{"q1": 1, "q2": 2}; !reflect.DeepEqual(prepared, want) {
+		t.Errorf("prepared = %v, want %v", prepared, want)
+	}
+	if want := map[string]int{"q1": 1, "q2": 1}; !reflect.DeepEqual(closed, want) {
+		t.Errorf("closed = %v, want %v", closed, want)
+	}
+	want := StmtCacheStats{Hits: 2, Misses: 2, Evictions: 1, Size: 1, MaxSize: 1}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewDBWrapper(t *testing.T) {
+	t.Parallel()
+	const listSQL = "SELECT query, args FROM users WHERE status = $1 ORDER BY created_at DESC LIMIT $2"
+	tests := []struct {
+		name string
+		mode ExecMode
+	}{
+		{name: "prepared", mode: ExecPrepared},
+		{name: "direct", mode: ExecDirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sqlDB, _ := openWrapperTestDB(t)
+			// The rows of the driver have the query and the number of its arguments.
+			fields := NewFields([]string{"query", "args"}, func(s Scanner, m *TestModel) error {
+				return s.Scan(&m.Name, &m.ID)
+			})
+			q := NewWithDB(NewDBWrapper(sqlDB), "users", fields, &TestCondition{}, &TestSort{}, NewLimitOffset(10, 0), WithDialect(PostgreSQL), WithExecMode(tt.mode))
+			want := &TestModel{ID: 2, Name: listSQL}
+
+			got, err := q.List(t.Context())
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, []*TestModel{want}) {
+				t.Errorf("List() = %+v, want %+v", got, []*TestModel{want})
+			}
+			first, err := q.First(t.Context())
+			if err != nil {
+				t.Fatalf("First() error = %v", err)
+			}
+			if !reflect.DeepEqual(first, want) {
+				t.Errorf("First() = %+v, want %+v", first, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package statement
+
+import (
+	"strconv"
+	"strings"
+)
+
+// argColumnResetKeywords are the keywords after which a placeholder isn't compared with the previous column.
+var argColumnResetKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "HAVING": true, "GROUP": true, "ORDER": true, "BY": true,
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "ROWS": true, "ONLY": true, "TOP": true,
+	"JOIN": true, "LATERAL": true, "APPLY": true, "ON": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "WITH": true, "AS": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+}
+
+// argColumnKeywords are the keywords which don't change the column compared with the next placeholder.
+var argColumnKeywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true, "NULL": true, "BETWEEN": true,
+	"LIKE": true, "ILIKE": true, "ESCAPE": true, "EXISTS": true, "ANY": true, "ALL": true, "SOME": true,
+	"TRUE": true, "FALSE": true, "ASC": true, "DESC": true, "DISTINCT": true, "NEXT": true, "ROW": true,
+}
+
+// argColumnGroup is a parenthesized group scanned by ArgColumns.
+type argColumnGroup struct {
+	// columns are the columns in the group, which is a row value if it has only columns.
+	columns  []string
+	rowValue bool
+	// compared is the row value compared with the group like (a, b) > (?, ?).
+	compared []string
+	// index is the position of the current element in the group.
+	index int
+	// function is true for the arguments of a function call like SHA2(?, 256),
+	// and outer is the column compared with the function call.
+	function bool
+	outer    string
+}
+
+// ArgColumns returns the column compared with each bind parameter of query, in the order of the arguments.
+// query may use the placeholders of any built-in dialect.
+// The column of a placeholder is the last column referenced before it in the same comparison, such as name
+// in "name = ?", "name IN (?, ?)", "name BETWEEN ? AND ?" and "LOWER(name) = ?", the column compared with
+// the function call of the argument like password in "password = SHA2(?, 256)", the column at the same position
+// of a row value like a and b in "(a, b) > (?, ?)", or the column following it like password in "? = password".
+// It is empty for a placeholder not compared with a column like "LIMIT ?".
+// Quotes of the column are removed, but its qualifier is kept like "books.title".
+func ArgColumns(query string) []string {
+	var result []string
+	var column string
+	var rowValue []string
+	var groups []*argColumnGroup
+	seq := 0
+	// pending is the index of the last placeholder without a column, which takes the column following it.
+	pending := -1
+	between := false
+
+	notRowValue := func() {
+		if len(groups) > 0 {
+			groups[len(groups)-1].rowValue = false
+		}
+	}
+	reset := func() {
+		column, rowValue, pending = "", nil, -1
+	}
+	functionColumn := func() string {
+		for _, g := range groups {
+			if g.function && g.outer != "" {
+				return g.outer
+			}
+		}
+		return ""
+	}
+	placeholder := func(n int) {
+		if n <= 0 {
+			n = seq + 1
+		}
+		seq = n
+		col := column
+		if f := functionColumn(); f != "" {
+			col = f
+		} else if len(groups) > 0 {
+			if g := groups[len(groups)-1]; g.index < len(g.compared) {
+				col = g.compared[g.index]
+			}
+		}
+		for len(result) < n {
+			result = append(result, "")
+		}
+		result[n-1] = col
+		if col == "" {
+			pending = n - 1
+		}
+		notRowValue()
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '?':
+			placeholder(0)
+			i++
+		case (c == '$' || c == '@') && i+1 < len(query):
+			j := i + 1
+			if c == '@' && query[j] == 'p' {
+				j++
+			}
+			k := j
+			for k < len(query) && isDigit(query[k]) {
+				k++
+			}
+			if k == j {
+				notRowValue()
+				i++
+				continue
+			}
+			n, _ := strconv.Atoi(query[j:k])
+			placeholder(n)
+			i = k
+		case c == '\'':
+			notRowValue()
+			i = skipQuoted(query, i, c) + 1
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return result
+			}
+			i += end + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return result
+			}
+			i += end + 4 //nolint:mnd
+		case c == '(':
+			groups = append(groups, &argColumnGroup{rowValue: true, compared: rowValue})
+			i++
+		case c == ')':
+			if len(groups) > 0 {
+				g := groups[len(groups)-1]
+				groups = groups[:len(groups)-1]
+				if g.rowValue && len(g.columns) > 1 {
+					rowValue = g.columns
+				}
+				notRowValue()
+			}
+			i++
+		case c == ',':
+			if len(groups) > 0 {
+				groups[len(groups)-1].index++
+			}
+			pending = -1
+			i++
+		case isIdentifierStart(c):
+			word, end := scanIdentifier(query, i)
+			i = end
+			switch upper := strings.ToUpper(word); {
+			case argColumnResetKeywords[upper]:
+				reset()
+				notRowValue()
+				if g := lastGroup(groups); upper == "SELECT" && g != nil {
+					// A subquery isn't the arguments of a function call.
+					g.function, g.outer = false, ""
+				}
+			case upper == "BETWEEN":
+				between = true
+				notRowValue()
+			case upper == "AND" && between:
+				// AND of BETWEEN ? AND ? keeps the column.
+				between = false
+				notRowValue()
+			case upper == "AND" || upper == "OR":
+				reset()
+				notRowValue()
+			case argColumnKeywords[upper]:
+				notRowValue()
+			case isFunctionCall(query, end):
+				notRowValue()
+				outer := functionColumn()
+				if outer == "" {
+					outer = column
+				}
+				groups = append(groups, &argColumnGroup{function: true, outer: outer})
+				i = strings.IndexByte(query[end:], '(') + end + 1
+			default:
+				column, rowValue = word, nil
+				if pending >= 0 {
+					result[pending] = word
+					pending = -1
+				}
+				if len(groups) > 0 {
+					g := groups[len(groups)-1]
+					g.columns = append(g.columns, word)
+				}
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		default:
+			notRowValue()
+			i++
+		}
+	}
+	return result
+}
+
+// lastGroup returns the innermost group, or nil if there are no groups.
+func lastGroup(groups []*argColumnGroup) *argColumnGroup {
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups[len(groups)-1]
+}
+
+// isFunctionCall reports whether the identifier ending at i is followed by an opening parenthesis.
+func isFunctionCall(query string, i int) bool {
+	for ; i < len(query); i++ {
+		switch query[i] {
+		case ' ', '\t', '\n', '\r':
+		case '(':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// isDigit reports whether c is a decimal digit.
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isIdentifierStart reports whether c starts an identifier, which may be quoted.
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '"' || c == '`' || c == '[' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// scanIdentifier scans the possibly qualified and quoted identifier starting at i.
+// It returns the identifier without quotes and the index after it.
+func scanIdentifier(query string, i int) (string, int) {
+	var parts []string
+	for {
+		switch c := query[i]; c {
+		case '"', '`', '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := skipQuoted(query, i, closing)
+			parts = append(parts, strings.ReplaceAll(query[i+1:end], string(closing)+string(closing), string(closing)))
+			i = end + 1
+		default:
+			j := i
+			for j < len(query) && (query[j] == '_' || query[j] == '$' || isDigit(query[j]) ||
+				('a' <= query[j] && query[j] <= 'z') || ('A' <= query[j] && query[j] <= 'Z')) {
+				j++
+			}
+			parts = append(parts, query[i:j])
+			i = j
+		}
+		if i+1 < len(query) && query[i] == '.' && isIdentifierStart(query[i+1]) {
+			i++
+			continue
+		}
+		return strings.Join(parts, "."), i
+	}
+}
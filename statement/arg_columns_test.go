@@ -0,0 +1,105 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgColumns(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "no placeholders",
+			query: "SELECT id FROM users",
+			want:  nil,
+		},
+		{
+			name:  "comparisons",
+			query: "SELECT id FROM users WHERE name = ? AND password <> ? LIMIT ? OFFSET ?",
+			want:  []string{"name", "password", "", ""},
+		},
+		{
+			name:  "in and between",
+			query: "SELECT id FROM users WHERE status IN (?, ?) AND NOT age BETWEEN ? AND ? ORDER BY id DESC LIMIT ?",
+			want:  []string{"status", "status", "age", "age", ""},
+		},
+		{
+			name:  "PostgreSQL placeholders",
+			query: `SELECT id FROM users WHERE "users"."email" = $1 AND LOWER(name) LIKE $2 LIMIT $3`,
+			want:  []string{"users.email", "name", ""},
+		},
+		{
+			name:  "SQL Server placeholders",
+			query: "SELECT id FROM users WHERE [token] = @p1 ORDER BY (SELECT NULL) OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY",
+			want:  []string{"token", "", ""},
+		},
+		{
+			name:  "MySQL quoted identifiers",
+			query: "SELECT id FROM users AS u WHERE `u`.`secret` = ? AND u.name IS NOT NULL AND u.id > ?",
+			want:  []string{"u.secret", "u.id"},
+		},
+		{
+			name:  "row values",
+			query: "SELECT id FROM users WHERE (created_at, id) > (?, ?) AND (a, b) IN ((?, ?), (?, ?)) AND c = ?",
+			want:  []string{"created_at", "id", "a", "b", "a", "b", "c"},
+		},
+		{
+			name:  "literals and comments",
+			query: "SELECT id FROM users WHERE note = 'a = ?' /* b = ? */ AND pin = ? -- c = ?\nAND n = 1 + ?",
+			want:  []string{"pin", "n"},
+		},
+		{
+			name:  "subquery",
+			query: "SELECT id FROM users WHERE id IN (SELECT user_id FROM tokens WHERE token = ?) AND name = ?",
+			want:  []string{"token", "name"},
+		},
+		{
+			name:  "function-wrapped arguments",
+			query: "SELECT id FROM users WHERE password = SHA2(?, 256) AND email = LOWER(TRIM(?)) AND COALESCE(nickname, ?) = name",
+			want:  []string{"password", "email", "nickname"},
+		},
+		{
+			name:  "function-wrapped columns",
+			query: "SELECT id FROM users WHERE LOWER(email) = ? AND DATE(created_at) BETWEEN ? AND ? AND COUNT(*) > ?",
+			want:  []string{"email", "created_at", "created_at", ""},
+		},
+		{
+			name:  "reversed comparisons",
+			query: "SELECT id FROM users WHERE ? = password AND ? < LOWER(token) OR status = ? AND LOWER(?) = email",
+			want:  []string{"password", "token", "status", "email"},
+		},
+		{
+			name:  "lateral subquery",
+			query: "SELECT b.id FROM books b CROSS APPLY (SELECT TOP 1 body FROM reviews r WHERE r.rating >= ?) AS top WHERE b.yr >= ?",
+			want:  []string{"r.rating", "b.yr"},
+		},
+		{
+			name:  "dollar without number",
+			query: "SELECT price$ FROM t WHERE x = $ AND y = ?",
+			want:  []string{"y"},
+		},
+		{
+			name:  "unterminated comment",
+			query: "SELECT id FROM users WHERE a = ? /* b = ?",
+			want:  []string{"a"},
+		},
+		{
+			name:  "unterminated line comment",
+			query: "SELECT id FROM users WHERE a = ? -- b = ?",
+			want:  []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ArgColumns(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ArgColumns() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tecowl/querybm/expr"
+)
+
+// SetOperator is an operator combining the results of SELECT statements.
+type SetOperator string
+
+// Set operators for Compound.
+const (
+	// Union combines the results removing duplicated rows.
+	Union SetOperator = "UNION"
+	// UnionAll combines the results keeping duplicated rows.
+	UnionAll SetOperator = "UNION ALL"
+	// Intersect returns the rows which are in both results.
+	Intersect SetOperator = "INTERSECT"
+	// Except returns the rows of the left result which are not in the right result.
+	Except SetOperator = "EXCEPT"
+)
+
+type compoundItem struct {
+	operator  SetOperator
+	statement RawBuilder
+}
+
+// Compound represents SELECT statements combined with set operators such as UNION ALL,
+// with a single ORDER BY and LIMIT applied to the combined result.
+// The combined statements must have the same number of columns and must not have their own ORDER BY or LIMIT.
+// Compound implements RawBuilder, so it can be used as a derived table with NewFromSubquery.
+// Embedded into a statement, it is built with the dialect of the statement.
+type Compound struct {
+	first RawBuilder
+	items []*compoundItem
+	// Sort is the ORDER BY clause block applied to the combined result.
+	Sort *Block
+	// LimitOffset holds LIMIT and OFFSET clauses applied to the combined result.
+	// Use SetLimitOffset for the clauses following the dialect of the statement embedding the compound.
+	LimitOffset *Block
+	// Dialect is the SQL dialect used to render the statement.
+	Dialect Dialect
+
+	pagination *pagination
+}
+
+// NewCompound creates a new Compound combining the statements with the operator.
+// More statements can be combined with other operators by Add.
+func NewCompound(operator SetOperator, first RawBuilder, others ...RawBuilder) *Compound {
+	c := &Compound{
+		first:       first,
+		items:       []*compoundItem{},
+		Sort:        NewBlock(", "),
+		LimitOffset: NewBlock(" "),
+		Dialect:     MySQL,
+	}
+	for _, st := range others {
+		c.Add(operator, st)
+	}
+	return c
+}
+
+// Add combines the statement with the operator to the result of the statements added before.
+// When the operator differs from the previous one, the statements added before are parenthesized
+// for the dialects which give INTERSECT a higher precedence, so that they are always combined from left to right.
+func (c *Compound) Add(operator SetOperator, st RawBuilder) {
+	c.items = append(c.items, &compoundItem{operator: operator, statement: st})
+}
+
+// SetLimitOffset sets the LIMIT and OFFSET applied to the combined result, which are rendered by the dialect
+// of the compound when it is built. If limit is <= 0, no clause is rendered.
+func (c *Compound) SetLimitOffset(limit, offset int64) {
+	c.pagination = &pagination{limit: limit, offset: offset}
+}
+
+// RawErr returns the errors of the combined statements which have a RawErr method like Statement.
+func (c *Compound) RawErr() error {
+	errs := []error{expr.SubqueryErr(c.first)}
+	for _, item := range c.items {
+		errs = append(errs, expr.SubqueryErr(item.statement))
+	}
+	return errors.Join(errs...)
+}
+
+// Err returns the errors of the combined statements validated with the dialect of the compound.
+func (c *Compound) Err() error {
+	d := DialectOrDefault(c.Dialect)
+	errs := []error{embeddedErr(c.first, d)}
+	for _, item := range c.items {
+		errs = append(errs, embeddedErr(item.statement, d))
+	}
+	return errors.Join(errs...)
+}
+
+// withDialect returns a shallow copy of the compound rendered with the dialect d.
+func (c *Compound) withDialect(d Dialect) *Compound {
+	r := *c
+	r.Dialect = d
+	return &r
+}
+
+// buildRawFor implements dialectBuilder.
+func (c *Compound) buildRawFor(d Dialect) (string, []any) {
+	return c.withDialect(d).BuildRaw()
+}
+
+// errFor implements dialectBuilder.
+func (c *Compound) errFor(d Dialect) error {
+	return c.withDialect(d).Err()
+}
+
+// Build constructs the complete SQL query string and returns it along with the placeholder values.
+// The placeholders are rendered for the dialect of the compound.
+func (c *Compound) Build() (string, []any) {
+	query, args := c.BuildRaw()
+	return Rebind(DialectOrDefault(c.Dialect), query), args
+}
+
+// BuildRaw constructs the SQL query string with generic ? placeholders and returns it along with the placeholder values.
+// The statements are built with the dialect of the compound in the order they are combined.
+func (c *Compound) BuildRaw() (string, []any) {
+	d := DialectOrDefault(c.Dialect)
+	first := buildEmbedded(c.first, d)
+	combined := first.query
+	args := append(make([]any, 0, len(first.values)), first.values...)
+
+	for i, item := range c.items {
+		if i > 0 && item.operator != c.items[i-1].operator && d.Supports(FeatureSetOperatorPrecedence) {
+			combined = "(" + combined + ")"
+		}
+		built := buildEmbedded(item.statement, d)
+		combined += " " + string(item.operator) + " " + built.query
+		args = append(args, built.values...)
+	}
+	queryParts := []string{combined}
+
+	limitOffset, limitOffsetValues := c.pagination.build(c.LimitOffset, d)
+	if !c.Sort.IsEmpty() {
+		queryParts = append(queryParts, "ORDER BY "+c.Sort.content)
+		args = append(args, c.Sort.values...)
+	} else if limitOffset != "" && d.Supports(FeatureOffsetFetch) {
+		// OFFSET ... FETCH is not allowed without ORDER BY.
+		queryParts = append(queryParts, "ORDER BY (SELECT NULL)")
+	}
+
+	if limitOffset != "" {
+		queryParts = append(queryParts, limitOffset)
+		args = append(args, limitOffsetValues...)
+	}
+
+	return strings.Join(queryParts, " "), args
+}
@@ -0,0 +1,203 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tecowl/querybm/expr"
+)
+
+func TestCompound_Build(t *testing.T) {
+	t.Parallel()
+	books := func() *Statement {
+		s := New("books", NewSimpleFields("id", "title AS name"))
+		s.Where.Add(expr.Field("title", expr.LikeContains("go")))
+		return s
+	}
+	authors := func() *Statement {
+		s := New("authors", NewSimpleFields("id", "name"))
+		s.Where.Add(expr.Field("name", expr.LikeContains("rob")))
+		return s
+	}
+	tests := []struct {
+		name       string
+		setup      func() *Compound
+		wantSQL    string
+		wantValues []any
+	}{
+		{
+			name: "UNION ALL",
+			setup: func() *Compound {
+				return NewCompound(UnionAll, books(), authors())
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE ? UNION ALL SELECT id, name FROM authors WHERE name LIKE ?",
+			wantValues: []any{"%go%", "%rob%"},
+		},
+		{
+			name: "single statement",
+			setup: func() *Compound {
+				return NewCompound(Union, books())
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE ?",
+			wantValues: []any{"%go%"},
+		},
+		{
+			name: "mixed operators with ORDER BY and LIMIT",
+			setup: func() *Compound {
+				c := NewCompound(Union, books(), authors())
+				c.Add(Except, New("banned", NewSimpleFields("id", "name")))
+				c.Add(Intersect, New("visible", NewSimpleFields("id", "name")))
+				c.Sort.Add("name ASC")
+				c.LimitOffset.Add("LIMIT ?", 10)
+				return c
+			},
+			wantSQL:    "((SELECT id, title AS name FROM books WHERE title LIKE ? UNION SELECT id, name FROM authors WHERE name LIKE ?) EXCEPT SELECT id, name FROM banned) INTERSECT SELECT id, name FROM visible ORDER BY name ASC LIMIT ?",
+			wantValues: []any{"%go%", "%rob%", 10},
+		},
+		{
+			name: "same operators are not parenthesized",
+			setup: func() *Compound {
+				c := NewCompound(UnionAll, books(), authors())
+				c.Add(UnionAll, New("editors", NewSimpleFields("id", "name")))
+				c.Add(Intersect, New("visible", NewSimpleFields("id", "name")))
+				return c
+			},
+			wantSQL:    "(SELECT id, title AS name FROM books WHERE title LIKE ? UNION ALL SELECT id, name FROM authors WHERE name LIKE ? UNION ALL SELECT id, name FROM editors) INTERSECT SELECT id, name FROM visible",
+			wantValues: []any{"%go%", "%rob%"},
+		},
+		{
+			name: "SQLite combines mixed operators from left to right without parentheses",
+			setup: func() *Compound {
+				c := NewCompound(Union, books(), authors())
+				c.Add(Intersect, New("visible", NewSimpleFields("id", "name")))
+				c.Dialect = SQLite
+				return c
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE ? UNION SELECT id, name FROM authors WHERE name LIKE ? INTERSECT SELECT id, name FROM visible",
+			wantValues: []any{"%go%", "%rob%"},
+		},
+		{
+			name: "PostgreSQL placeholders",
+			setup: func() *Compound {
+				c := NewCompound(UnionAll, books(), authors())
+				c.Dialect = PostgreSQL
+				c.Sort.Add("name ASC")
+				clause, values := PostgreSQL.LimitOffset(10, 20)
+				c.LimitOffset.Add(clause, values...)
+				return c
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE $1 UNION ALL SELECT id, name FROM authors WHERE name LIKE $2 ORDER BY name ASC LIMIT $3 OFFSET $4",
+			wantValues: []any{"%go%", "%rob%", int64(10), int64(20)},
+		},
+		{
+			name: "SQLServer pagination without sort",
+			setup: func() *Compound {
+				c := NewCompound(UnionAll, books(), authors())
+				c.Dialect = SQLServer
+				clause, values := SQLServer.LimitOffset(10, 0)
+				c.LimitOffset.Add(clause, values...)
+				return c
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE @p1 UNION ALL SELECT id, name FROM authors WHERE name LIKE @p2 ORDER BY (SELECT NULL) OFFSET @p3 ROWS FETCH NEXT @p4 ROWS ONLY",
+			wantValues: []any{"%go%", "%rob%", int64(0), int64(10)},
+		},
+		{
+			name: "SQLServer SetLimitOffset",
+			setup: func() *Compound {
+				c := NewCompound(UnionAll, books(), authors())
+				c.Dialect = SQLServer
+				c.Sort.Add("name ASC")
+				c.SetLimitOffset(10, 20)
+				return c
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE @p1 UNION ALL SELECT id, name FROM authors WHERE name LIKE @p2 ORDER BY name ASC OFFSET @p3 ROWS FETCH NEXT @p4 ROWS ONLY",
+			wantValues: []any{"%go%", "%rob%", int64(20), int64(10)},
+		},
+		{
+			name: "statements built with the dialect of the compound",
+			setup: func() *Compound {
+				locked := New("jobs", NewSimpleFields("id", "name"))
+				locked.Locking.ForUpdate()
+				c := NewCompound(UnionAll, books(), locked)
+				c.Dialect = SQLServer
+				return c
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE @p1 UNION ALL SELECT id, name FROM jobs WITH (UPDLOCK, ROWLOCK)",
+			wantValues: []any{"%go%"},
+		},
+		{
+			name: "nil dialect falls back to MySQL",
+			setup: func() *Compound {
+				c := NewCompound(UnionAll, books(), authors())
+				c.Dialect = nil
+				return c
+			},
+			wantSQL:    "SELECT id, title AS name FROM books WHERE title LIKE ? UNION ALL SELECT id, name FROM authors WHERE name LIKE ?",
+			wantValues: []any{"%go%", "%rob%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotSQL, gotValues := tt.setup().Build()
+			if gotSQL != tt.wantSQL {
+				t.Errorf("Build() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("Build() values = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestCompound_AsSubquery(t *testing.T) {
+	t.Parallel()
+	c := NewCompound(UnionAll, New("books", NewSimpleFields("id")), New("magazines", NewSimpleFields("id")))
+	s := NewFromSubquery(c, "items", NewSimpleFields("COUNT(*)"))
+	s.Dialect = PostgreSQL
+	s.Where.Add(expr.Field("id", expr.Gt(100)))
+
+	gotSQL, gotValues := s.Build()
+	if want := "SELECT COUNT(*) FROM (SELECT id FROM books UNION ALL SELECT id FROM magazines) AS items WHERE id > $1"; gotSQL != want {
+		t.Errorf("Build() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{100}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("Build() values = %v, want %v", gotValues, want)
+	}
+}
+
+func TestCompound_RawErr(t *testing.T) {
+	t.Parallel()
+	unknownJoin := New("books b", NewSimpleFields("b.id"))
+	unknownJoin.Require("publishers")
+	valid := NewCompound(UnionAll, New("books", NewSimpleFields("id")), New("magazines", NewSimpleFields("id")))
+	if err := valid.RawErr(); err != nil {
+		t.Errorf("RawErr() = %v, want nil", err)
+	}
+	c := NewCompound(UnionAll, New("books", NewSimpleFields("id")), New("magazines", NewSimpleFields("id")))
+	c.Add(Except, unknownJoin)
+	if err := c.RawErr(); !errors.Is(err, ErrUnknownJoin) {
+		t.Errorf("RawErr() = %v, want %v", err, ErrUnknownJoin)
+	}
+}
+
+func TestCompound_Err(t *testing.T) {
+	t.Parallel()
+	locked := New("jobs", NewSimpleFields("id"))
+	locked.Locking.ForUpdate()
+	c := NewCompound(UnionAll, New("books", NewSimpleFields("id")), locked)
+	if err := c.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+	c.Dialect = SQLite
+	if err := c.Err(); !errors.Is(err, ErrLockingUnsupported) {
+		t.Errorf("Err() = %v, want %v", err, ErrLockingUnsupported)
+	}
+	s := NewFromSubquery(c.withDialect(MySQL), "t", NewSimpleFields("COUNT(*)"))
+	s.Dialect = SQLite
+	if err := s.Err(); !errors.Is(err, ErrLockingUnsupported) {
+		t.Errorf("Err() = %v, want %v", err, ErrLockingUnsupported)
+	}
+}
@@ -0,0 +1,284 @@
+package statement
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect describes how a statement is rendered for a specific database.
+type Dialect interface {
+	// Name returns the name of the dialect.
+	Name() string
+	// Placeholder returns the bind parameter marker for the n-th (1-based) value.
+	Placeholder(n int) string
+	// QuoteIdentifier quotes an identifier such as a table or column name.
+	// Qualified names like "books.title" are quoted part by part.
+	QuoteIdentifier(name string) string
+	// LimitOffset returns the pagination clause for the limit and offset with its values.
+	LimitOffset(limit, offset int64) (string, []any)
+	// Supports reports whether the dialect supports the feature.
+	Supports(feature Feature) bool
+}
+
+// Feature represents an optional capability or requirement of a Dialect.
+type Feature int
+
+const (
+	// FeatureOffsetFetch indicates that pagination is rendered with OFFSET ... FETCH,
+	// which requires an ORDER BY clause.
+	FeatureOffsetFetch Feature = iota + 1
+	// FeatureRowValues indicates that row value comparisons like (a, b) > (?, ?) are supported.
+	FeatureRowValues
+	// FeatureWindowFunctions indicates that window functions like COUNT(*) OVER() are supported.
+	FeatureWindowFunctions
+	// FeatureRecursiveKeyword indicates that recursive common table expressions are declared with WITH RECURSIVE.
+	FeatureRecursiveKeyword
+	// FeatureLockingClause indicates that row locks are taken with a trailing clause like FOR UPDATE SKIP LOCKED.
+	FeatureLockingClause
+	// FeatureLockingTableHints indicates that row locks are taken with table hints like WITH (UPDLOCK, ROWLOCK).
+	FeatureLockingTableHints
+	// FeatureFullOuterJoin indicates that FULL OUTER JOIN is supported.
+	FeatureFullOuterJoin
+	// FeatureLateralJoin indicates that LATERAL joins are supported.
+	FeatureLateralJoin
+	// FeatureApplyJoin indicates that lateral joins are rendered as CROSS APPLY and OUTER APPLY.
+	FeatureApplyJoin
+	// FeatureSetOperatorPrecedence indicates that INTERSECT binds more tightly than UNION and EXCEPT,
+	// so the statements combined before another set operator need to be parenthesized.
+	FeatureSetOperatorPrecedence
+	// FeatureDistinctOn indicates that SELECT DISTINCT ON (...) is supported.
+	FeatureDistinctOn
+	// FeatureNullsLast indicates that NULL values sort after the other values in ascending order
+	// and before them in descending order. The other dialects sort NULL values as the lowest values.
+	FeatureNullsLast
+)
+
+// dialect is the implementation of the built-in dialects.
+type dialect struct {
+	name        string
+	placeholder func(n int) string
+	quoteOpen   string
+	quoteClose  string
+	limitOffset func(limit, offset int64) (string, []any)
+	features    []Feature
+	literals    literalStyle
+}
+
+var _ Dialect = (*dialect)(nil)
+
+var (
+	// MySQL is the dialect for MySQL 8.0 or later and MariaDB. It is the default dialect.
+	MySQL Dialect = &dialect{
+		name:        "mysql",
+		placeholder: questionPlaceholder,
+		quoteOpen:   "`",
+		quoteClose:  "`",
+		limitOffset: limitOffsetClause,
+		features: []Feature{
+			FeatureRowValues, FeatureWindowFunctions, FeatureRecursiveKeyword, FeatureLockingClause,
+			FeatureLateralJoin, FeatureSetOperatorPrecedence,
+		},
+		literals: literalStyle{backslashEscape: true, bytes: hexBytesLiteral, boolean: keywordBoolLiteral, timeLayout: "2006-01-02 15:04:05.999999"},
+	}
+	// PostgreSQL is the dialect for PostgreSQL.
+	PostgreSQL Dialect = &dialect{
+		name:        "postgres",
+		placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		quoteOpen:   `"`,
+		quoteClose:  `"`,
+		limitOffset: limitOffsetClause,
+		features: []Feature{
+			FeatureRowValues, FeatureWindowFunctions, FeatureRecursiveKeyword, FeatureLockingClause,
+			FeatureFullOuterJoin, FeatureLateralJoin, FeatureSetOperatorPrecedence, FeatureDistinctOn,
+			FeatureNullsLast,
+		},
+		literals: literalStyle{bytes: byteaLiteral, boolean: keywordBoolLiteral, timeLayout: "2006-01-02 15:04:05.999999Z07:00"},
+	}
+	// SQLite is the dialect for SQLite. RIGHT and FULL OUTER JOIN require SQLite 3.39 or later.
+	SQLite Dialect = &dialect{
+		name:        "sqlite",
+		placeholder: questionPlaceholder,
+		quoteOpen:   `"`,
+		quoteClose:  `"`,
+		limitOffset: limitOffsetClause,
+		features: []Feature{
+			FeatureRowValues, FeatureWindowFunctions, FeatureRecursiveKeyword,
+			FeatureFullOuterJoin,
+		},
+		literals: literalStyle{bytes: hexBytesLiteral, boolean: numericBoolLiteral, timeLayout: "2006-01-02 15:04:05.999999999-07:00"},
+	}
+	// SQLServer is the dialect for Microsoft SQL Server.
+	SQLServer Dialect = &dialect{
+		name:        "sqlserver",
+		placeholder: func(n int) string { return "@p" + strconv.Itoa(n) },
+		quoteOpen:   "[",
+		quoteClose:  "]",
+		limitOffset: offsetFetchClause,
+		features: []Feature{
+			FeatureOffsetFetch, FeatureWindowFunctions, FeatureLockingTableHints,
+			FeatureFullOuterJoin, FeatureApplyJoin, FeatureSetOperatorPrecedence,
+		},
+		literals: literalStyle{bytes: binaryLiteral, boolean: numericBoolLiteral, timeLayout: "2006-01-02T15:04:05.9999999Z07:00"},
+	}
+)
+
+// DialectOrDefault returns d, or MySQL, the default dialect, if d is nil.
+func DialectOrDefault(d Dialect) Dialect { //nolint:ireturn
+	if d == nil {
+		return MySQL
+	}
+	return d
+}
+
+func questionPlaceholder(int) string { return "?" }
+
+// limitOffsetClause renders pagination as LIMIT ? [OFFSET ?].
+func limitOffsetClause(limit, offset int64) (string, []any) {
+	if limit <= 0 {
+		return "", nil
+	}
+	if offset <= 0 {
+		return "LIMIT ?", []any{limit}
+	}
+	return "LIMIT ? OFFSET ?", []any{limit, offset}
+}
+
+// offsetFetchClause renders pagination as OFFSET ? ROWS FETCH NEXT ? ROWS ONLY.
+func offsetFetchClause(limit, offset int64) (string, []any) {
+	if limit <= 0 {
+		return "", nil
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return "OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", []any{offset, limit}
+}
+
+// Name implements Dialect.
+func (d *dialect) Name() string { return d.name }
+
+// Placeholder implements Dialect.
+func (d *dialect) Placeholder(n int) string { return d.placeholder(n) }
+
+// QuoteIdentifier implements Dialect.
+func (d *dialect) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" {
+			continue
+		}
+		parts[i] = d.quoteOpen + strings.ReplaceAll(part, d.quoteClose, d.quoteClose+d.quoteClose) + d.quoteClose
+	}
+	return strings.Join(parts, ".")
+}
+
+// LimitOffset implements Dialect.
+func (d *dialect) LimitOffset(limit, offset int64) (string, []any) {
+	return d.limitOffset(limit, offset)
+}
+
+// Supports implements Dialect.
+func (d *dialect) Supports(feature Feature) bool {
+	for _, f := range d.features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Rebind replaces the generic ? placeholders in query with the placeholders of the dialect.
+// Question marks inside quoted strings, quoted identifiers and comments are left untouched.
+// Brackets are treated as quoted identifiers only for dialects quoting identifiers with them like SQL Server,
+// so placeholders in array constructors and subscripts like ARRAY[?] and a[?] are replaced.
+func Rebind(d Dialect, query string) string {
+	d = DialectOrDefault(d)
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	scanPlaceholders(query, bracketQuoted(d), func(s string, placeholder bool) {
+		if placeholder {
+			n++
+			sb.WriteString(d.Placeholder(n))
+		} else {
+			sb.WriteString(s)
+		}
+	})
+	return sb.String()
+}
+
+// bracketQuoted reports whether the dialect quotes identifiers with brackets like [name].
+func bracketQuoted(d Dialect) bool {
+	return strings.HasPrefix(d.QuoteIdentifier("x"), "[")
+}
+
+// scanPlaceholders splits query into plain segments and ? placeholders and passes them to fn in order.
+// brackets tells whether [...] is a quoted identifier. See skipLiteral.
+func scanPlaceholders(query string, brackets bool, fn func(s string, placeholder bool)) {
+	start := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			fn(query[start:i], false)
+			fn("?", true)
+			start = i + 1
+			continue
+		}
+		if end, ok := skipLiteral(query, i, brackets); ok {
+			i = end
+		}
+	}
+	fn(query[start:], false)
+}
+
+// skipLiteral returns the index of the last character of the quoted string, quoted identifier or comment
+// starting at i. It returns false if no such section starts at i.
+// [...] is skipped as a quoted identifier only if brackets is true, because it is an array constructor
+// or a subscript in the other dialects.
+func skipLiteral(query string, i int, brackets bool) (int, bool) {
+	switch c := query[i]; c {
+	case '\'', '"', '`':
+		return skipQuoted(query, i, c), true
+	case '[':
+		if !brackets {
+			return 0, false
+		}
+		return skipQuoted(query, i, ']'), true
+	case '-':
+		if !strings.HasPrefix(query[i:], "--") {
+			return 0, false
+		}
+		end := strings.IndexByte(query[i:], '\n')
+		if end < 0 {
+			return len(query) - 1, true
+		}
+		return i + end, true
+	case '/':
+		if !strings.HasPrefix(query[i:], "/*") {
+			return 0, false
+		}
+		end := strings.Index(query[i+2:], "*/")
+		if end < 0 {
+			return len(query) - 1, true
+		}
+		return i + end + 3, true //nolint:mnd
+	}
+	return 0, false
+}
+
+// skipQuoted returns the index of the character closing the quoted section starting at i.
+// A doubled closing character is treated as an escaped one.
+func skipQuoted(query string, i int, closing byte) int {
+	for j := i + 1; j < len(query); j++ {
+		if query[j] != closing {
+			continue
+		}
+		if j+1 < len(query) && query[j+1] == closing {
+			j++
+			continue
+		}
+		return j
+	}
+	return len(query) - 1
+}
@@ -0,0 +1,310 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDialect_Name(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{dialect: MySQL, want: "mysql"},
+		{dialect: PostgreSQL, want: "postgres"},
+		{dialect: SQLite, want: "sqlite"},
+		{dialect: SQLServer, want: "sqlserver"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.dialect.Name(); got != tt.want {
+				t.Errorf("Name() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialect_Placeholder(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		dialect Dialect
+		n       int
+		want    string
+	}{
+		{dialect: MySQL, n: 1, want: "?"},
+		{dialect: MySQL, n: 3, want: "?"},
+		{dialect: PostgreSQL, n: 1, want: "$1"},
+		{dialect: PostgreSQL, n: 12, want: "$12"},
+		{dialect: SQLite, n: 2, want: "?"},
+		{dialect: SQLServer, n: 1, want: "@p1"},
+		{dialect: SQLServer, n: 5, want: "@p5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name()+" "+tt.want, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.dialect.Placeholder(tt.n); got != tt.want {
+				t.Errorf("Placeholder(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialect_QuoteIdentifier(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		dialect Dialect
+		ident   string
+		want    string
+	}{
+		{name: "MySQL simple", dialect: MySQL, ident: "order", want: "`order`"},
+		{name: "MySQL qualified", dialect: MySQL, ident: "books.title", want: "`books`.`title`"},
+		{name: "MySQL escaped", dialect: MySQL, ident: "we`ird", want: "`we``ird`"},
+		{name: "MySQL asterisk", dialect: MySQL, ident: "books.*", want: "`books`.*"},
+		{name: "PostgreSQL simple", dialect: PostgreSQL, ident: "user", want: `"user"`},
+		{name: "PostgreSQL qualified", dialect: PostgreSQL, ident: "public.users", want: `"public"."users"`},
+		{name: "PostgreSQL escaped", dialect: PostgreSQL, ident: `a"b`, want: `"a""b"`},
+		{name: "SQLite simple", dialect: SQLite, ident: "group", want: `"group"`},
+		{name: "SQLServer simple", dialect: SQLServer, ident: "key", want: "[key]"},
+		{name: "SQLServer escaped", dialect: SQLServer, ident: "a]b", want: "[a]]b]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.dialect.QuoteIdentifier(tt.ident); got != tt.want {
+				t.Errorf("QuoteIdentifier(%q) = %v, want %v", tt.ident, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialect_LimitOffset(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		dialect    Dialect
+		limit      int64
+		offset     int64
+		wantClause string
+		wantValues []any
+	}{
+		{name: "MySQL limit only", dialect: MySQL, limit: 10, offset: 0, wantClause: "LIMIT ?", wantValues: []any{int64(10)}},
+		{name: "MySQL limit and offset", dialect: MySQL, limit: 10, offset: 20, wantClause: "LIMIT ? OFFSET ?", wantValues: []any{int64(10), int64(20)}},
+		{name: "MySQL no limit", dialect: MySQL, limit: 0, offset: 20, wantClause: "", wantValues: nil},
+		{name: "PostgreSQL limit and offset", dialect: PostgreSQL, limit: 5, offset: 15, wantClause: "LIMIT ? OFFSET ?", wantValues: []any{int64(5), int64(15)}},
+		{name: "SQLite limit only", dialect: SQLite, limit: 5, offset: -1, wantClause: "LIMIT ?", wantValues: []any{int64(5)}},
+		{
+			name: "SQLServer limit and offset", dialect: SQLServer, limit: 10, offset: 20,
+			wantClause: "OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", wantValues: []any{int64(20), int64(10)},
+		},
+		{
+			name: "SQLServer limit only", dialect: SQLServer, limit: 10, offset: -5,
+			wantClause: "OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", wantValues: []any{int64(0), int64(10)},
+		},
+		{name: "SQLServer no limit", dialect: SQLServer, limit: 0, offset: 0, wantClause: "", wantValues: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotClause, gotValues := tt.dialect.LimitOffset(tt.limit, tt.offset)
+			if gotClause != tt.wantClause {
+				t.Errorf("LimitOffset() clause = %v, want %v", gotClause, tt.wantClause)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("LimitOffset() values = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestDialect_Supports(t *testing.T) {
+	t.Parallel()
+	if MySQL.Supports(FeatureOffsetFetch) {
+		t.Error("MySQL should not support FeatureOffsetFetch")
+	}
+	if !SQLServer.Supports(FeatureOffsetFetch) {
+		t.Error("SQLServer should support FeatureOffsetFetch")
+	}
+	for _, d := range []Dialect{MySQL, PostgreSQL, SQLite} {
+		if !d.Supports(FeatureRowValues) {
+			t.Errorf("%s should support FeatureRowValues", d.Name())
+		}
+	}
+	if SQLServer.Supports(FeatureRowValues) {
+		t.Error("SQLServer should not support FeatureRowValues")
+	}
+	for _, d := range []Dialect{MySQL, PostgreSQL, SQLite, SQLServer} {
+		if !d.Supports(FeatureWindowFunctions) {
+			t.Errorf("%s should support FeatureWindowFunctions", d.Name())
+		}
+	}
+	for _, d := range []Dialect{MySQL, PostgreSQL, SQLite} {
+		if !d.Supports(FeatureRecursiveKeyword) {
+			t.Errorf("%s should support FeatureRecursiveKeyword", d.Name())
+		}
+	}
+	if SQLServer.Supports(FeatureRecursiveKeyword) {
+		t.Error("SQLServer should not support FeatureRecursiveKeyword")
+	}
+	for _, d := range []Dialect{MySQL, PostgreSQL} {
+		if !d.Supports(FeatureLockingClause) {
+			t.Errorf("%s should support FeatureLockingClause", d.Name())
+		}
+	}
+	for _, d := range []Dialect{SQLite, SQLServer} {
+		if d.Supports(FeatureLockingClause) {
+			t.Errorf("%s should not support FeatureLockingClause", d.Name())
+		}
+	}
+	if !SQLServer.Supports(FeatureLockingTableHints) {
+		t.Error("SQLServer should support FeatureLockingTableHints")
+	}
+	for _, d := range []Dialect{PostgreSQL, SQLite, SQLServer} {
+		if !d.Supports(FeatureFullOuterJoin) {
+			t.Errorf("%s should support FeatureFullOuterJoin", d.Name())
+		}
+	}
+	if MySQL.Supports(FeatureFullOuterJoin) {
+		t.Error("MySQL should not support FeatureFullOuterJoin")
+	}
+	for _, d := range []Dialect{MySQL, PostgreSQL} {
+		if !d.Supports(FeatureLateralJoin) {
+			t.Errorf("%s should support FeatureLateralJoin", d.Name())
+		}
+	}
+	if !SQLServer.Supports(FeatureApplyJoin) {
+		t.Error("SQLServer should support FeatureApplyJoin")
+	}
+	for _, d := range []Dialect{MySQL, PostgreSQL, SQLServer} {
+		if !d.Supports(FeatureSetOperatorPrecedence) {
+			t.Errorf("%s should support FeatureSetOperatorPrecedence", d.Name())
+		}
+	}
+	if SQLite.Supports(FeatureSetOperatorPrecedence) {
+		t.Error("SQLite should not support FeatureSetOperatorPrecedence")
+	}
+	if !PostgreSQL.Supports(FeatureDistinctOn) {
+		t.Error("PostgreSQL should support FeatureDistinctOn")
+	}
+	for _, d := range []Dialect{MySQL, SQLite, SQLServer} {
+		if d.Supports(FeatureDistinctOn) {
+			t.Errorf("%s should not support FeatureDistinctOn", d.Name())
+		}
+	}
+	if !PostgreSQL.Supports(FeatureNullsLast) {
+		t.Error("PostgreSQL should support FeatureNullsLast")
+	}
+	for _, d := range []Dialect{MySQL, SQLite, SQLServer} {
+		if d.Supports(FeatureNullsLast) {
+			t.Errorf("%s should not support FeatureNullsLast", d.Name())
+		}
+	}
+}
+
+func TestDialectOrDefault(t *testing.T) {
+	t.Parallel()
+	if got := DialectOrDefault(nil); got != MySQL {
+		t.Errorf("DialectOrDefault(nil) = %v, want MySQL", got.Name())
+	}
+	if got := DialectOrDefault(PostgreSQL); got != PostgreSQL {
+		t.Errorf("DialectOrDefault(PostgreSQL) = %v, want PostgreSQL", got.Name())
+	}
+}
+
+func TestRebind(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{
+			name:    "nil dialect",
+			dialect: nil,
+			query:   "SELECT * FROM t WHERE a = ?",
+			want:    "SELECT * FROM t WHERE a = ?",
+		},
+		{
+			name:    "MySQL keeps question marks",
+			dialect: MySQL,
+			query:   "SELECT * FROM t WHERE a = ? AND b = ?",
+			want:    "SELECT * FROM t WHERE a = ? AND b = ?",
+		},
+		{
+			name:    "PostgreSQL numbers placeholders",
+			dialect: PostgreSQL,
+			query:   "SELECT * FROM t WHERE a = ? AND b IN (?,?) LIMIT ?",
+			want:    "SELECT * FROM t WHERE a = $1 AND b IN ($2,$3) LIMIT $4",
+		},
+		{
+			name:    "SQLServer numbers placeholders",
+			dialect: SQLServer,
+			query:   "SELECT * FROM t WHERE a = ? ORDER BY a OFFSET ? ROWS FETCH NEXT ? ROWS ONLY",
+			want:    "SELECT * FROM t WHERE a = @p1 ORDER BY a OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY",
+		},
+		{
+			name:    "question marks in literals and identifiers are kept",
+			dialect: PostgreSQL,
+			query:   `SELECT 'what?', "col?", 'it''s ?' FROM t WHERE a = ?`,
+			want:    `SELECT 'what?', "col?", 'it''s ?' FROM t WHERE a = $1`,
+		},
+		{
+			name:    "question marks in brackets and backticks are kept",
+			dialect: SQLServer,
+			query:   "SELECT [a?], `b?` FROM t WHERE a = ?",
+			want:    "SELECT [a?], `b?` FROM t WHERE a = @p1",
+		},
+		{
+			name:    "PostgreSQL numbers placeholders in array constructors",
+			dialect: PostgreSQL,
+			query:   "SELECT * FROM t WHERE tags && ARRAY[?, ?] AND id = ?",
+			want:    "SELECT * FROM t WHERE tags && ARRAY[$1, $2] AND id = $3",
+		},
+		{
+			name:    "PostgreSQL numbers placeholders in subscripts",
+			dialect: PostgreSQL,
+			query:   "SELECT * FROM t WHERE a[?] = ? AND b[?:?] = ?",
+			want:    "SELECT * FROM t WHERE a[$1] = $2 AND b[$3:$4] = $5",
+		},
+		{
+			name:    "question marks in comments are kept",
+			dialect: PostgreSQL,
+			query:   "SELECT a -- why?\nFROM t /* really? */ WHERE a = ? - 1 AND b = ? / 2",
+			want:    "SELECT a -- why?\nFROM t /* really? */ WHERE a = $1 - 1 AND b = $2 / 2",
+		},
+		{
+			name:    "unterminated sections",
+			dialect: PostgreSQL,
+			query:   "SELECT ? -- trailing?",
+			want:    "SELECT $1 -- trailing?",
+		},
+		{
+			name:    "unterminated block comment",
+			dialect: PostgreSQL,
+			query:   "SELECT ? /* open?",
+			want:    "SELECT $1 /* open?",
+		},
+		{
+			name:    "unterminated literal",
+			dialect: PostgreSQL,
+			query:   "SELECT ? 'open?",
+			want:    "SELECT $1 'open?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Rebind(tt.dialect, tt.query); got != tt.want {
+				t.Errorf("Rebind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package statement
+
+import "github.com/tecowl/querybm/expr"
+
+// dialectBuilder is a RawBuilder which is built and validated for the dialect of the statement embedding it.
+// Statement and Compound implement dialectBuilder.
+type dialectBuilder interface {
+	RawBuilder
+	buildRawFor(d Dialect) (string, []any)
+	errFor(d Dialect) error
+}
+
+// builtSubquery is a RawBuilder holding the SQL of an embedded statement built for the embedding statement.
+type builtSubquery struct {
+	query  string
+	values []any
+}
+
+// BuildRaw returns the SQL and the placeholder values which have been built.
+func (b *builtSubquery) BuildRaw() (string, []any) { return b.query, b.values }
+
+// buildEmbedded builds the statement embedded into another statement for the dialect d of the embedding statement.
+// The RawBuilders other than dialectBuilder, and all of them if d is nil, are built with their own dialect.
+func buildEmbedded(sub RawBuilder, d Dialect) *builtSubquery {
+	var query string
+	var values []any
+	if b, ok := sub.(dialectBuilder); ok && d != nil {
+		query, values = b.buildRawFor(d)
+	} else {
+		query, values = sub.BuildRaw()
+	}
+	return &builtSubquery{query: query, values: values}
+}
+
+// embeddedErr returns the error of the statement embedded into another statement rendered with the dialect d.
+// The RawBuilders other than dialectBuilder report the error of their RawErr method.
+func embeddedErr(sub RawBuilder, d Dialect) error {
+	if b, ok := sub.(dialectBuilder); ok {
+		return b.errFor(d)
+	}
+	return expr.SubqueryErr(sub)
+}
@@ -0,0 +1,175 @@
+package statement
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LiteralFormatter is implemented by Dialect which renders values as SQL literals for Interpolate.
+// The built-in dialects implement it.
+type LiteralFormatter interface {
+	// FormatLiteral returns the SQL literal of the value.
+	FormatLiteral(v any) string
+}
+
+var _ LiteralFormatter = (*dialect)(nil)
+
+// literalStyle describes how a dialect renders values as literals.
+type literalStyle struct {
+	// backslashEscape is true if backslashes in string literals are escape characters.
+	backslashEscape bool
+	bytes           func(b []byte) string
+	boolean         func(b bool) string
+	timeLayout      string
+}
+
+// ansiLiterals is the literal style used for the dialects which don't implement LiteralFormatter.
+var ansiLiterals = literalStyle{bytes: hexBytesLiteral, boolean: keywordBoolLiteral, timeLayout: "2006-01-02 15:04:05.999999999Z07:00"}
+
+// hexBytesLiteral renders bytes as X'0102'.
+func hexBytesLiteral(b []byte) string { return "X'" + hex.EncodeToString(b) + "'" }
+
+// byteaLiteral renders bytes as '\x0102' of PostgreSQL.
+func byteaLiteral(b []byte) string { return `'\x` + hex.EncodeToString(b) + "'" }
+
+// binaryLiteral renders bytes as 0x0102 of SQL Server.
+func binaryLiteral(b []byte) string { return "0x" + hex.EncodeToString(b) }
+
+// keywordBoolLiteral renders booleans as TRUE and FALSE.
+func keywordBoolLiteral(b bool) string { return strings.ToUpper(strconv.FormatBool(b)) }
+
+// numericBoolLiteral renders booleans as 1 and 0.
+func numericBoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// FormatLiteral implements LiteralFormatter.
+func (d *dialect) FormatLiteral(v any) string {
+	return d.literals.format(v)
+}
+
+// format returns the SQL literal of the value.
+// driver.Valuer values are rendered with their driver values and nil pointers are rendered as NULL.
+func (s literalStyle) format(v any) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return "NULL"
+		}
+		dv, err := valuer.Value()
+		if err != nil {
+			return s.string(fmt.Sprintf("!ERROR: %v", err))
+		}
+		v = dv
+	}
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return s.string(v)
+	case []byte:
+		if v == nil {
+			return "NULL"
+		}
+		return s.bytes(v)
+	case bool:
+		return s.boolean(v)
+	case time.Time:
+		return "'" + v.Format(s.timeLayout) + "'"
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "NULL"
+		}
+		return s.format(rv.Elem().Interface())
+	case reflect.String:
+		return s.string(rv.String())
+	case reflect.Bool:
+		return s.boolean(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+	default:
+		return s.string(fmt.Sprint(v))
+	}
+}
+
+// string returns the quoted string literal.
+func (s literalStyle) string(v string) string {
+	if s.backslashEscape {
+		v = strings.ReplaceAll(v, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+// formatLiteral returns the SQL literal of the value for the dialect.
+func formatLiteral(d Dialect, v any) string {
+	if f, ok := d.(LiteralFormatter); ok {
+		return f.FormatLiteral(v)
+	}
+	return ansiLiterals.format(v)
+}
+
+// Interpolate returns query with its placeholders replaced by the literals of args for the dialect d,
+// where query is built for d like the result of Statement.Build. MySQL is used when d is nil.
+// Placeholders without arguments are left as they are.
+//
+// It is for debugging only, such as logging the SQL to run it by hand.
+// Never execute the result: the literals are not guaranteed to be safe against SQL injection
+// nor to be interpreted as the arguments bound by the driver.
+func Interpolate(d Dialect, query string, args []any) string {
+	d = DialectOrDefault(d)
+	prefix := strings.TrimSuffix(d.Placeholder(1), "1")
+	numbered := prefix != d.Placeholder(1)
+
+	brackets := bracketQuoted(d)
+
+	var sb strings.Builder
+	seq := 0
+	start := 0
+	for i := 0; i < len(query); i++ {
+		if end, ok := skipLiteral(query, i, brackets); ok {
+			i = end
+			continue
+		}
+		if !strings.HasPrefix(query[i:], prefix) {
+			continue
+		}
+		end := i + len(prefix)
+		n := seq + 1
+		if numbered {
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			if end == i+len(prefix) {
+				continue
+			}
+			n, _ = strconv.Atoi(query[i+len(prefix) : end])
+		}
+		seq = n
+		if n > len(args) {
+			continue
+		}
+		sb.WriteString(query[start:i])
+		sb.WriteString(formatLiteral(d, args[n-1]))
+		start = end
+		i = end - 1
+	}
+	sb.WriteString(query[start:])
+	return sb.String()
+}
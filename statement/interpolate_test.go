@@ -0,0 +1,166 @@
+package statement
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tecowl/querybm/expr"
+)
+
+// testLiteralDialect is a dialect which doesn't implement LiteralFormatter.
+type testLiteralDialect struct {
+	Dialect
+}
+
+// errValuer is a driver.Valuer which fails.
+type errValuer struct{}
+
+func (errValuer) Value() (driver.Value, error) { return nil, errors.New("invalid value") }
+
+type testStatus string
+
+type testFlag bool
+
+type testPoint struct{ X, Y int }
+
+func TestDialect_FormatLiteral(t *testing.T) {
+	t.Parallel()
+	jst := time.FixedZone("JST", 9*60*60)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 600000000, jst)
+	var nilString *string
+	str := "it's"
+	tests := []struct {
+		name    string
+		dialect Dialect
+		value   any
+		want    string
+	}{
+		{name: "nil", dialect: MySQL, value: nil, want: "NULL"},
+		{name: "int", dialect: MySQL, value: 42, want: "42"},
+		{name: "int64", dialect: MySQL, value: int64(-7), want: "-7"},
+		{name: "uint8", dialect: MySQL, value: uint8(7), want: "7"},
+		{name: "float32", dialect: MySQL, value: float32(1.5), want: "1.5"},
+		{name: "float64", dialect: MySQL, value: 0.1, want: "0.1"},
+		{name: "MySQL string", dialect: MySQL, value: `it's a \ test`, want: `'it''s a \\ test'`},
+		{name: "PostgreSQL string", dialect: PostgreSQL, value: `it's a \ test`, want: `'it''s a \ test'`},
+		{name: "named string", dialect: PostgreSQL, value: testStatus("active"), want: "'active'"},
+		{name: "pointer", dialect: PostgreSQL, value: &str, want: "'it''s'"},
+		{name: "nil pointer", dialect: PostgreSQL, value: nilString, want: "NULL"},
+		{name: "struct", dialect: PostgreSQL, value: testPoint{1, 2}, want: "'{1 2}'"},
+		{name: "MySQL bool", dialect: MySQL, value: true, want: "TRUE"},
+		{name: "SQLite bool", dialect: SQLite, value: false, want: "0"},
+		{name: "named bool", dialect: PostgreSQL, value: testFlag(true), want: "TRUE"},
+		{name: "SQL Server bool", dialect: SQLServer, value: true, want: "1"},
+		{name: "MySQL bytes", dialect: MySQL, value: []byte{0x01, 0xab}, want: "X'01ab'"},
+		{name: "PostgreSQL bytes", dialect: PostgreSQL, value: []byte{0x01, 0xab}, want: `'\x01ab'`},
+		{name: "SQLite bytes", dialect: SQLite, value: []byte{0x01, 0xab}, want: "X'01ab'"},
+		{name: "SQL Server bytes", dialect: SQLServer, value: []byte{0x01, 0xab}, want: "0x01ab"},
+		{name: "nil bytes", dialect: SQLServer, value: []byte(nil), want: "NULL"},
+		{name: "MySQL time", dialect: MySQL, value: at, want: "'2024-01-02 03:04:05.6'"},
+		{name: "PostgreSQL time", dialect: PostgreSQL, value: at, want: "'2024-01-02 03:04:05.6+09:00'"},
+		{name: "SQLite time", dialect: SQLite, value: at, want: "'2024-01-02 03:04:05.6+09:00'"},
+		{name: "SQL Server time", dialect: SQLServer, value: at.UTC(), want: "'2024-01-01T18:04:05.6Z'"},
+		{name: "valid valuer", dialect: MySQL, value: sql.NullString{String: "foo", Valid: true}, want: "'foo'"},
+		{name: "null valuer", dialect: MySQL, value: sql.NullInt64{}, want: "NULL"},
+		{name: "nil valuer pointer", dialect: MySQL, value: (*sql.NullString)(nil), want: "NULL"},
+		{name: "failing valuer", dialect: MySQL, value: errValuer{}, want: "'!ERROR: invalid value'"},
+		{name: "custom dialect string", dialect: testLiteralDialect{PostgreSQL}, value: `a\b`, want: `'a\b'`},
+		{name: "custom dialect time", dialect: testLiteralDialect{PostgreSQL}, value: at, want: "'2024-01-02 03:04:05.6+09:00'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := formatLiteral(tt.dialect, tt.value); got != tt.want {
+				t.Errorf("formatLiteral() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		args    []any
+		want    string
+	}{
+		{
+			name:    "MySQL",
+			dialect: MySQL,
+			query:   "SELECT id FROM books WHERE title = ? AND note = '?' AND `a?` IS NULL AND yr IN (?, ?) LIMIT ?",
+			args:    []any{"Go's", 2000, 2001, int64(10)},
+			want:    "SELECT id FROM books WHERE title = 'Go''s' AND note = '?' AND `a?` IS NULL AND yr IN (2000, 2001) LIMIT 10",
+		},
+		{
+			name:    "nil dialect",
+			dialect: nil,
+			query:   "SELECT id FROM books WHERE title = ?",
+			args:    []any{`a\b`},
+			want:    `SELECT id FROM books WHERE title = 'a\\b'`,
+		},
+		{
+			name:    "PostgreSQL arrays",
+			dialect: PostgreSQL,
+			query:   "SELECT id FROM books WHERE tags && ARRAY[$1, $2] AND scores[$3] > $4",
+			args:    []any{"go", "sql", 1, 80},
+			want:    "SELECT id FROM books WHERE tags && ARRAY['go', 'sql'] AND scores[1] > 80",
+		},
+		{
+			name:    "PostgreSQL",
+			dialect: PostgreSQL,
+			query:   "SELECT id FROM books WHERE title = $1 AND price$ > $2 AND data = $10 -- $1\nLIMIT $3",
+			args:    []any{"foo", 1.5, nil, 4, 5, 6, 7, 8, 9, []byte("x")},
+			want:    "SELECT id FROM books WHERE title = 'foo' AND price$ > 1.5 AND data = '\\x78' -- $1\nLIMIT NULL",
+		},
+		{
+			name:    "SQL Server",
+			dialect: SQLServer,
+			query:   "SELECT id FROM [books] WHERE [title] = @p1 AND x = @y ORDER BY (SELECT NULL) OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY",
+			args:    []any{"foo", int64(0), int64(10)},
+			want:    "SELECT id FROM [books] WHERE [title] = 'foo' AND x = @y ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+		{
+			name:    "SQL Server bracket-quoted identifiers",
+			dialect: SQLServer,
+			query:   "SELECT [a@p1] FROM books WHERE title = @p1",
+			args:    []any{"foo"},
+			want:    "SELECT [a@p1] FROM books WHERE title = 'foo'",
+		},
+		{
+			name:    "missing arguments",
+			dialect: PostgreSQL,
+			query:   "SELECT id FROM books WHERE title = $1 AND yr = $2",
+			args:    []any{"foo"},
+			want:    "SELECT id FROM books WHERE title = 'foo' AND yr = $2",
+		},
+		{
+			name:    "built statement",
+			dialect: PostgreSQL,
+			query: func() string {
+				st := New("books", NewSimpleFields("id"))
+				st.Dialect = PostgreSQL
+				st.Where.Add(expr.Field("title", expr.Eq("a")))
+				st.Where.Add(expr.Field("yr", expr.Gt(2000)))
+				s, _ := st.Build()
+				return s
+			}(),
+			args: []any{"a", 2000},
+			want: "SELECT id FROM books WHERE title = 'a' AND yr > 2000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Interpolate(tt.dialect, tt.query, tt.args); got != tt.want {
+				t.Errorf("Interpolate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownJoin is returned when a required join is not defined in the JoinRegistry.
+	ErrUnknownJoin = errors.New("unknown join")
+	// ErrJoinCycle is returned when the prerequisites of the required joins form a cycle.
+	ErrJoinCycle = errors.New("join dependency cycle")
+)
+
+// JoinDef is the definition of a join in a JoinRegistry.
+type JoinDef struct {
+	// Type is the type of the join. JoinInner is used if it is empty.
+	Type JoinType
+	// Table is the table name with its alias like "authors a".
+	Table string
+	// Condition is the ON condition of the join.
+	Condition string
+	// Values are the placeholder values of the condition.
+	Values []any
+	// Requires are the names of the joins which must precede this join,
+	// such as "authors" for the join of publishers on authors.publisher_id.
+	Requires []string
+}
+
+// JoinRegistry holds joins declared by name with their prerequisites.
+// Builders call Statement.Require with the names, and the statement emits the required joins
+// and their prerequisites in dependency order when it is built.
+// Define all joins before sharing the registry between goroutines.
+type JoinRegistry struct {
+	defs map[string]*JoinDef
+}
+
+// NewJoinRegistry creates a new empty JoinRegistry.
+func NewJoinRegistry() *JoinRegistry {
+	return &JoinRegistry{defs: map[string]*JoinDef{}}
+}
+
+// Define declares the join named name. It replaces the join with the same name.
+func (r *JoinRegistry) Define(name string, def JoinDef) {
+	r.defs[name] = &def
+}
+
+// resolve returns the join items of the names and their prerequisites in dependency order.
+// Each join is emitted once, after all of its prerequisites.
+func (r *JoinRegistry) resolve(names []string) ([]*joinItem, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	states := map[string]int{}
+	items := []*joinItem{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch states[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %v", ErrJoinCycle, append(path, name))
+		}
+		def, ok := r.defs[name]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownJoin, name)
+		}
+		states[name] = visiting
+		for _, req := range def.Requires {
+			if err := visit(req, append(path, name)); err != nil {
+				return err
+			}
+		}
+		states[name] = visited
+		items = append(items, def.joinItem())
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (d *JoinDef) joinItem() *joinItem {
+	joinType := d.Type
+	if joinType == "" {
+		joinType = JoinInner
+	}
+	condition, values := d.Condition, d.Values
+	if joinType == JoinCross {
+		condition, values = "", nil
+	}
+	return &joinItem{
+		joinType:  joinType,
+		tableName: *parseTableName(d.Table),
+		Condition: condition,
+		Args:      values,
+	}
+}
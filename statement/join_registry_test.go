@@ -0,0 +1,150 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tecowl/querybm/expr"
+)
+
+func newTestJoinRegistry() *JoinRegistry {
+	r := NewJoinRegistry()
+	r.Define("publishers", JoinDef{
+		Table:     "publishers p",
+		Condition: "p.id = a.publisher_id AND p.country = ?",
+		Values:    []any{"JP"},
+		Requires:  []string{"authors"},
+	})
+	r.Define("authors", JoinDef{Table: "authors a", Condition: "a.id = b.author_id"})
+	r.Define("series", JoinDef{Type: JoinLeftOuter, Table: "series s", Condition: "s.id = b.series_id"})
+	r.Define("formats", JoinDef{Type: JoinCross, Table: "formats f", Condition: "ignored"})
+	r.Define("awards", JoinDef{Table: "awards aw", Condition: "aw.publisher_id = p.id", Requires: []string{"publishers", "series"}})
+	return r
+}
+
+func TestStatement_Require(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		setup      func(s *Statement)
+		wantSQL    string
+		wantValues []any
+	}{
+		{
+			name:       "no joins required",
+			setup:      func(*Statement) {},
+			wantSQL:    "SELECT b.id FROM books b",
+			wantValues: []any{},
+		},
+		{
+			name:       "single join",
+			setup:      func(s *Statement) { s.Require("authors") },
+			wantSQL:    "SELECT b.id FROM books b INNER JOIN authors a ON a.id = b.author_id",
+			wantValues: []any{},
+		},
+		{
+			name:       "prerequisites precede",
+			setup:      func(s *Statement) { s.Require("publishers") },
+			wantSQL:    "SELECT b.id FROM books b INNER JOIN authors a ON a.id = b.author_id INNER JOIN publishers p ON p.id = a.publisher_id AND p.country = ?",
+			wantValues: []any{"JP"},
+		},
+		{
+			name: "each join once in dependency order",
+			setup: func(s *Statement) {
+				s.Require("awards", "authors")
+				s.Require("publishers")
+				s.Where.Add(expr.Field("b.yr", expr.Gte(2000)))
+			},
+			wantSQL: "SELECT b.id FROM books b INNER JOIN authors a ON a.id = b.author_id " +
+				"INNER JOIN publishers p ON p.id = a.publisher_id AND p.country = ? " +
+				"LEFT OUTER JOIN series s ON s.id = b.series_id " +
+				"INNER JOIN awards aw ON aw.publisher_id = p.id WHERE b.yr >= ?",
+			wantValues: []any{"JP", 2000},
+		},
+		{
+			name:       "cross join",
+			setup:      func(s *Statement) { s.Require("formats") },
+			wantSQL:    "SELECT b.id FROM books b CROSS JOIN formats f",
+			wantValues: []any{},
+		},
+		{
+			name: "required joins precede direct joins",
+			setup: func(s *Statement) {
+				s.Table.InnerJoin("prizes pr", "pr.publisher_id = p.id")
+				s.Require("publishers")
+			},
+			wantSQL:    "SELECT b.id FROM books b INNER JOIN authors a ON a.id = b.author_id INNER JOIN publishers p ON p.id = a.publisher_id AND p.country = ? INNER JOIN prizes pr ON pr.publisher_id = p.id",
+			wantValues: []any{"JP"},
+		},
+		{
+			name: "direct INNER JOIN upgrades required LEFT OUTER JOIN",
+			setup: func(s *Statement) {
+				s.Table.InnerJoin("series s", "s.id = b.series_id")
+				s.Require("series")
+			},
+			wantSQL:    "SELECT b.id FROM books b INNER JOIN series s ON s.id = b.series_id",
+			wantValues: []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := New("books b", NewSimpleFields("b.id"))
+			s.Joins = newTestJoinRegistry()
+			tt.setup(s)
+			if err := s.Err(); err != nil {
+				t.Fatalf("Err() = %v", err)
+			}
+			for range 2 {
+				gotSQL, gotValues := s.Build()
+				if gotSQL != tt.wantSQL {
+					t.Errorf("Build() SQL = %v, want %v", gotSQL, tt.wantSQL)
+				}
+				if !reflect.DeepEqual(gotValues, tt.wantValues) {
+					t.Errorf("Build() values = %v, want %v", gotValues, tt.wantValues)
+				}
+			}
+		})
+	}
+}
+
+func TestStatement_RequireError(t *testing.T) {
+	t.Parallel()
+	cyclic := NewJoinRegistry()
+	cyclic.Define("a", JoinDef{Table: "a", Condition: "a.id = b.a_id", Requires: []string{"b"}})
+	cyclic.Define("b", JoinDef{Table: "b", Condition: "b.id = a.b_id", Requires: []string{"a"}})
+
+	tests := []struct {
+		name     string
+		registry *JoinRegistry
+		setup    func(s *Statement)
+		wantErr  error
+	}{
+		{name: "without registry", registry: nil, setup: func(s *Statement) { s.Require("authors") }, wantErr: ErrUnknownJoin},
+		{name: "unknown join", registry: newTestJoinRegistry(), setup: func(s *Statement) { s.Require("editors") }, wantErr: ErrUnknownJoin},
+		{name: "cycle", registry: cyclic, setup: func(s *Statement) { s.Require("a") }, wantErr: ErrJoinCycle},
+		{
+			name:     "conflict with direct join",
+			registry: newTestJoinRegistry(),
+			setup: func(s *Statement) {
+				s.Table.InnerJoin("authors a", "a.id = b.editor_id")
+				s.Require("authors")
+			},
+			wantErr: ErrJoinConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := New("books b", NewSimpleFields("b.id"))
+			s.Joins = tt.registry
+			tt.setup(s)
+			if err := s.Err(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
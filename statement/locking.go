@@ -0,0 +1,119 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+)
+
+// LockStrength is the strength of the row locks taken by a locking clause.
+type LockStrength string
+
+// Lock strengths for LockingBlock.
+const (
+	// LockForUpdate locks the rows exclusively like SELECT ... FOR UPDATE.
+	LockForUpdate LockStrength = "UPDATE"
+	// LockForShare locks the rows in shared mode like SELECT ... FOR SHARE.
+	LockForShare LockStrength = "SHARE"
+)
+
+var (
+	// ErrLockingUnsupported is returned when the dialect can't render the locking clause.
+	ErrLockingUnsupported = errors.New("locking clause is not supported by the dialect")
+	// ErrLockingConflict is returned when both NoWait and SkipLocked are set.
+	ErrLockingConflict = errors.New("locking clause can't have both NOWAIT and SKIP LOCKED")
+)
+
+// LockingBlock represents the row locking clause of a SQL statement such as FOR UPDATE SKIP LOCKED.
+// It is rendered as a trailing clause for MySQL and PostgreSQL and as table hints for SQL Server.
+type LockingBlock struct {
+	// Strength is the strength of the locks. The block is empty if it is "".
+	Strength LockStrength
+	// Of is the tables whose rows are locked. All tables are locked if it is empty.
+	Of []string
+	// NoWait makes the statement fail immediately instead of waiting for locked rows.
+	NoWait bool
+	// SkipLocked makes the statement skip locked rows instead of waiting for them.
+	SkipLocked bool
+}
+
+// NewLockingBlock creates a new empty LockingBlock.
+func NewLockingBlock() *LockingBlock {
+	return &LockingBlock{}
+}
+
+// ForUpdate locks the selected rows of the tables exclusively.
+// If no tables are given, the rows of all tables are locked.
+func (b *LockingBlock) ForUpdate(of ...string) {
+	b.Strength = LockForUpdate
+	b.Of = of
+}
+
+// ForShare locks the selected rows of the tables in shared mode.
+// If no tables are given, the rows of all tables are locked.
+func (b *LockingBlock) ForShare(of ...string) {
+	b.Strength = LockForShare
+	b.Of = of
+}
+
+// IsEmpty returns true if the block doesn't lock rows.
+func (b *LockingBlock) IsEmpty() bool {
+	return b.Strength == ""
+}
+
+// Validate checks that the dialect can render the locking clause.
+func (b *LockingBlock) Validate(d Dialect) error {
+	if b.IsEmpty() {
+		return nil
+	}
+	if b.NoWait && b.SkipLocked {
+		return ErrLockingConflict
+	}
+	switch {
+	case d.Supports(FeatureLockingClause):
+		return nil
+	case d.Supports(FeatureLockingTableHints) && len(b.Of) == 0:
+		return nil
+	default:
+		return ErrLockingUnsupported
+	}
+}
+
+// Build constructs the trailing locking clause like FOR UPDATE OF books NOWAIT.
+func (b *LockingBlock) Build() string {
+	if b.IsEmpty() {
+		return ""
+	}
+	parts := []string{"FOR " + string(b.Strength)}
+	if len(b.Of) > 0 {
+		parts = append(parts, "OF "+strings.Join(b.Of, ", "))
+	}
+	switch {
+	case b.NoWait:
+		parts = append(parts, "NOWAIT")
+	case b.SkipLocked:
+		parts = append(parts, "SKIP LOCKED")
+	}
+	return strings.Join(parts, " ")
+}
+
+// tableHint constructs the SQL Server table hint like WITH (UPDLOCK, ROWLOCK, READPAST).
+func (b *LockingBlock) tableHint() string {
+	if b.IsEmpty() {
+		return ""
+	}
+	hints := []string{}
+	switch b.Strength {
+	case LockForUpdate:
+		hints = append(hints, "UPDLOCK")
+	case LockForShare:
+		hints = append(hints, "HOLDLOCK")
+	}
+	hints = append(hints, "ROWLOCK")
+	switch {
+	case b.NoWait:
+		hints = append(hints, "NOWAIT")
+	case b.SkipLocked:
+		hints = append(hints, "READPAST")
+	}
+	return "WITH (" + strings.Join(hints, ", ") + ")"
+}
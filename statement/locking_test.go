@@ -0,0 +1,95 @@
+package statement
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockingBlock_Build(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		setup    func(b *LockingBlock)
+		want     string
+		wantHint string
+	}{
+		{
+			name:     "empty",
+			setup:    func(*LockingBlock) {},
+			want:     "",
+			wantHint: "",
+		},
+		{
+			name:     "FOR UPDATE",
+			setup:    func(b *LockingBlock) { b.ForUpdate() },
+			want:     "FOR UPDATE",
+			wantHint: "WITH (UPDLOCK, ROWLOCK)",
+		},
+		{
+			name:     "FOR SHARE",
+			setup:    func(b *LockingBlock) { b.ForShare() },
+			want:     "FOR SHARE",
+			wantHint: "WITH (HOLDLOCK, ROWLOCK)",
+		},
+		{
+			name:     "FOR UPDATE OF tables NOWAIT",
+			setup:    func(b *LockingBlock) { b.ForUpdate("jobs", "workers"); b.NoWait = true },
+			want:     "FOR UPDATE OF jobs, workers NOWAIT",
+			wantHint: "WITH (UPDLOCK, ROWLOCK, NOWAIT)",
+		},
+		{
+			name:     "FOR UPDATE SKIP LOCKED",
+			setup:    func(b *LockingBlock) { b.ForUpdate(); b.SkipLocked = true },
+			want:     "FOR UPDATE SKIP LOCKED",
+			wantHint: "WITH (UPDLOCK, ROWLOCK, READPAST)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewLockingBlock()
+			tt.setup(b)
+			if got := b.Build(); got != tt.want {
+				t.Errorf("Build() = %v, want %v", got, tt.want)
+			}
+			if got := b.tableHint(); got != tt.wantHint {
+				t.Errorf("tableHint() = %v, want %v", got, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestLockingBlock_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		setup   func(b *LockingBlock)
+		dialect Dialect
+		wantErr error
+	}{
+		{name: "empty on SQLite", setup: func(*LockingBlock) {}, dialect: SQLite, wantErr: nil},
+		{name: "MySQL", setup: func(b *LockingBlock) { b.ForUpdate("jobs") }, dialect: MySQL, wantErr: nil},
+		{name: "PostgreSQL", setup: func(b *LockingBlock) { b.ForShare() }, dialect: PostgreSQL, wantErr: nil},
+		{name: "SQLServer", setup: func(b *LockingBlock) { b.ForUpdate() }, dialect: SQLServer, wantErr: nil},
+		{name: "SQLServer with OF", setup: func(b *LockingBlock) { b.ForUpdate("jobs") }, dialect: SQLServer, wantErr: ErrLockingUnsupported},
+		{name: "SQLite", setup: func(b *LockingBlock) { b.ForUpdate() }, dialect: SQLite, wantErr: ErrLockingUnsupported},
+		{
+			name:    "NOWAIT and SKIP LOCKED",
+			setup:   func(b *LockingBlock) { b.ForUpdate(); b.NoWait = true; b.SkipLocked = true },
+			dialect: PostgreSQL,
+			wantErr: ErrLockingConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewLockingBlock()
+			tt.setup(b)
+			if err := b.Validate(tt.dialect); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
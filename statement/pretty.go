@@ -0,0 +1,139 @@
+package statement
+
+import (
+	"strings"
+)
+
+// prettyClauseKeywords are the keywords starting a line in Pretty.
+var prettyClauseKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true,
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "FOR": true, "WITH": true,
+	"UNION": true, "INTERSECT": true, "EXCEPT": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true, "OUTER": true,
+}
+
+// prettyJoinModifiers are the keywords followed by JOIN or OUTER on the same line.
+var prettyJoinModifiers = map[string]bool{
+	"INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true, "OUTER": true, "NATURAL": true,
+}
+
+// prettyFunctions are the keywords which don't start a clause if they are followed by a parenthesis,
+// like LEFT(name, 1) and WITH (UPDLOCK).
+var prettyFunctions = map[string]bool{"LEFT": true, "RIGHT": true, "WITH": true}
+
+// prettyIndent is the indentation of a subquery and a continued condition.
+const prettyIndent = "  "
+
+// prettyParen is an open parenthesis in Pretty.
+type prettyParen struct {
+	// subquery is true if the parenthesis encloses a subquery.
+	subquery bool
+	// indent is the indentation of the line where the parenthesis is opened.
+	indent string
+}
+
+// Pretty formats query in multiple lines for humans. Each clause such as FROM and WHERE starts a line,
+// each AND and OR of the conditions starts an indented line, and subqueries are indented in their parentheses.
+// Whitespace is collapsed, while quoted strings, quoted identifiers and comments are kept as they are.
+// Like Interpolate, it is for debugging only.
+func Pretty(query string) string {
+	var sb strings.Builder
+	var parens []prettyParen
+	base := ""   // indentation of the clauses in the current subquery
+	indent := "" // indentation of the current line
+	space := false
+	lineStart := false
+	between := false
+	prev := ""
+
+	breakable := func() bool {
+		return len(parens) == 0 || parens[len(parens)-1].subquery
+	}
+	newline := func(s string) {
+		if sb.Len() > 0 {
+			sb.WriteString("\n" + s)
+		}
+		indent = s
+		space = false
+		lineStart = true
+	}
+	write := func(s string) {
+		if space && sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		space = false
+		lineStart = false
+		sb.WriteString(s)
+	}
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		// Brackets are kept as they are, whether they quote identifiers or not.
+		if end, ok := skipLiteral(query, i, true); ok {
+			write(query[i : end+1])
+			prev = ""
+			i = end
+			continue
+		}
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+		case c == '(':
+			write("(")
+			rest := strings.ToUpper(strings.TrimLeft(query[i+1:], " \t\r\n"))
+			// ORDER BY (SELECT NULL) for OFFSET ... FETCH is kept in a line.
+			sub := (strings.HasPrefix(rest, "SELECT") || strings.HasPrefix(rest, "WITH")) && !strings.HasPrefix(rest, "SELECT NULL)")
+			p := prettyParen{subquery: sub, indent: indent}
+			parens = append(parens, p)
+			if p.subquery {
+				base = indent + prettyIndent
+				newline(base)
+			}
+			prev = ""
+		case c == ')':
+			if len(parens) > 0 {
+				p := parens[len(parens)-1]
+				parens = parens[:len(parens)-1]
+				if p.subquery {
+					newline(p.indent)
+					base = ""
+					for j := len(parens) - 1; j >= 0; j-- {
+						if parens[j].subquery {
+							base = parens[j].indent + prettyIndent
+							break
+						}
+					}
+				}
+			}
+			space = false
+			write(")")
+			prev = ""
+		case isIdentifierStart(c):
+			word, end := scanIdentifier(query, i)
+			raw := query[i:end]
+			upper := strings.ToUpper(word)
+			switch {
+			case !breakable() || word != raw:
+			case lineStart:
+			case upper == "AND" && between:
+				between = false
+			case upper == "AND" || upper == "OR":
+				newline(base + prettyIndent)
+			case prettyJoinModifiers[prev] && (upper == "JOIN" || upper == "OUTER"):
+			case prettyFunctions[upper] && strings.HasPrefix(strings.TrimLeft(query[end:], " \t\r\n"), "("):
+			case prettyClauseKeywords[upper]:
+				newline(base)
+			}
+			if upper == "BETWEEN" {
+				between = true
+			}
+			write(raw)
+			prev = upper
+			i = end - 1
+		default:
+			write(string(c))
+			prev = ""
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package statement
+
+import "testing"
+
+func TestPretty(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "empty",
+			query: "",
+			want:  "",
+		},
+		{
+			name:  "clauses",
+			query: "SELECT  DISTINCT id, LEFT(name, 3), COUNT(*) OVER() AS c\n FROM books AS b LEFT OUTER JOIN authors AS a ON a.id = b.author_id INNER JOIN x ON x.id = b.id JOIN y ON y.id = b.id WHERE status = 'a  AND b' AND yr BETWEEN ? AND ? OR (a > ? OR (a = ? AND b > ?)) GROUP BY id HAVING COUNT(*) > ? ORDER BY id DESC LIMIT ? OFFSET ? FOR UPDATE SKIP LOCKED",
+			want: `SELECT DISTINCT id, LEFT(name, 3), COUNT(*) OVER() AS c
+FROM books AS b
+LEFT OUTER JOIN authors AS a ON a.id = b.author_id
+INNER JOIN x ON x.id = b.id
+JOIN y ON y.id = b.id
+WHERE status = 'a  AND b'
+  AND yr BETWEEN ? AND ?
+  OR (a > ? OR (a = ? AND b > ?))
+GROUP BY id
+HAVING COUNT(*) > ?
+ORDER BY id DESC
+LIMIT ?
+OFFSET ?
+FOR UPDATE SKIP LOCKED`,
+		},
+		{
+			name:  "subqueries",
+			query: `WITH RECURSIVE t AS (SELECT id FROM a WHERE x = ? UNION ALL SELECT id FROM b) SELECT id FROM t WHERE id IN (SELECT book_id FROM reviews WHERE stars >= ? AND "select" IN (SELECT 1)) AND id > ?`,
+			want: `WITH RECURSIVE t AS (
+  SELECT id
+  FROM a
+  WHERE x = ?
+  UNION ALL
+  SELECT id
+  FROM b
+)
+SELECT id
+FROM t
+WHERE id IN (
+  SELECT book_id
+  FROM reviews
+  WHERE stars >= ?
+    AND "select" IN (
+      SELECT 1
+    )
+)
+  AND id > ?`,
+		},
+		{
+			name:  "SQL Server",
+			query: "SELECT id FROM [jobs] WITH (UPDLOCK, ROWLOCK) CROSS APPLY (SELECT TOP 1 x FROM y) AS z ORDER BY (SELECT NULL) OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY /* comment\nFROM */",
+			want: `SELECT id
+FROM [jobs] WITH (UPDLOCK, ROWLOCK)
+CROSS APPLY (
+  SELECT TOP 1 x
+  FROM y
+) AS z
+ORDER BY (SELECT NULL)
+OFFSET @p1 ROWS
+FETCH NEXT @p2 ROWS ONLY /* comment
+FROM */`,
+		},
+		{
+			name:  "unbalanced parentheses",
+			query: "SELECT id) FROM (SELECT 1",
+			want:  "SELECT id)\nFROM (\n  SELECT 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Pretty(tt.query); got != tt.want {
+				t.Errorf("Pretty() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,62 +1,299 @@
 // Package statement provides SQL statement building components and utilities.
 package statement
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tecowl/querybm/expr"
+)
+
+// ErrDistinctOnUnsupported is returned when the dialect doesn't support SELECT DISTINCT ON.
+var ErrDistinctOnUnsupported = errors.New("DISTINCT ON is not supported by the dialect")
 
 // Statement represents a SQL SELECT statement with its various clauses.
 type Statement struct {
+	// With is the WITH clause block defining common table expressions.
+	With *WithBlock
+	// Distinct makes the statement SELECT DISTINCT to remove duplicated rows.
+	Distinct bool
+	// DistinctOn is the column list of SELECT DISTINCT ON (...) which keeps the first row of each set of rows
+	// with the same values. It is supported by PostgreSQL only, and Err reports ErrDistinctOnUnsupported for the others.
+	DistinctOn *Block
 	// Fields defines the columns to select.
 	Fields Fields
 	// Table is the FROM clause block.
 	Table *TableBlock
+	// Joins is the registry of the joins required by Require.
+	Joins *JoinRegistry
 	// Where is the WHERE clause block.
 	Where *WhereBlock
-	// GroupBy *Block
-	// Having *Block
+	// GroupBy is the GROUP BY clause block.
+	GroupBy *Block
+	// Having is the HAVING clause block.
+	Having *WhereBlock
 	// Sort is the ORDER BY clause block.
 	Sort *Block
 	// LimitOffset holds LIMIT and OFFSET clauses.
+	// Use SetLimitOffset for the clauses following the dialect of the statement embedding this statement.
 	LimitOffset *Block
+	// Locking is the row locking clause block such as FOR UPDATE.
+	Locking *LockingBlock
+	// Dialect is the SQL dialect used to render the statement.
+	Dialect Dialect
+
+	required   []string
+	pagination *pagination
+}
+
+// pagination is the LIMIT and OFFSET set by SetLimitOffset.
+type pagination struct {
+	limit  int64
+	offset int64
 }
 
 // New creates a new Statement with the specified table name and fields.
 func New(table string, fields Fields) *Statement {
 	return &Statement{
-		Fields: fields,
-		Table:  NewTableBlock(table),
-		// GroupBy:   NewBlock(),
-		// Having:    NewBlock(),
+		With:        NewWithBlock(),
+		DistinctOn:  NewBlock(", "),
+		Fields:      fields,
+		Table:       NewTableBlock(table),
 		Where:       newWhere(" AND "),
+		GroupBy:     NewBlock(", "),
+		Having:      newWhere(" AND "),
 		Sort:        NewBlock(", "),
 		LimitOffset: NewBlock(" "),
+		Locking:     NewLockingBlock(),
+		Dialect:     MySQL,
+	}
+}
+
+// NewFromSubquery creates a new Statement selecting the fields from the subquery as a derived table named alias.
+func NewFromSubquery(sub RawBuilder, alias string, fields Fields) *Statement {
+	s := New(alias, fields)
+	s.Table = NewSubqueryTableBlock(sub, alias)
+	return s
+}
+
+// IsGrouped returns true if the statement has a GROUP BY or HAVING clause.
+func (s *Statement) IsGrouped() bool {
+	return !s.GroupBy.IsEmpty() || !s.Having.IsEmpty()
+}
+
+// Require requires the joins defined in Joins by name.
+// The required joins and their prerequisites are added to the FROM clause in dependency order
+// when the statement is built, before the joins added to Table directly.
+func (s *Statement) Require(names ...string) {
+	for _, name := range names {
+		if !slices.Contains(s.required, name) {
+			s.required = append(s.required, name)
+		}
+	}
+}
+
+// table returns the table block with the required joins.
+func (s *Statement) table() (*TableBlock, error) {
+	if len(s.required) == 0 {
+		return s.Table, nil
+	}
+	if s.Joins == nil {
+		return s.Table, fmt.Errorf("%w: %q", ErrUnknownJoin, s.required[0])
+	}
+	items, err := s.Joins.resolve(s.required)
+	if err != nil {
+		return s.Table, err
+	}
+	return s.Table.withJoins(items), nil
+}
+
+// Err returns the error of the statement which can't be rendered correctly,
+// such as the errors reported by RawErr, or DISTINCT ON, a join or a locking clause unsupported by the dialect.
+// The embedded statements are validated with the dialect of this statement.
+func (s *Statement) Err() error {
+	if err := s.RawErr(); err != nil {
+		return err
+	}
+	d := DialectOrDefault(s.Dialect)
+	if !s.DistinctOn.IsEmpty() && !d.Supports(FeatureDistinctOn) {
+		return ErrDistinctOnUnsupported
+	}
+	// Errors of the required joins are reported by RawErr.
+	table, _ := s.table()
+	if err := table.Validate(d); err != nil {
+		return err
+	}
+	if err := s.Locking.Validate(d); err != nil {
+		return err
+	}
+	var errs []error
+	for _, sub := range s.subqueries() {
+		errs = append(errs, embeddedErr(sub, d))
+	}
+	return errors.Join(errs...)
+}
+
+// RawErr returns the error of the statement which doesn't depend on the dialect, such as conflicting
+// common table expressions or joins, an unknown required join, or the errors of the embedded subqueries.
+// The statements embedding this statement with BuildRaw report it from their Err.
+func (s *Statement) RawErr() error {
+	if err := s.With.Err(); err != nil {
+		return err
+	}
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	if err := table.Err(); err != nil {
+		return err
+	}
+	var errs []error
+	for _, sub := range s.subqueries() {
+		errs = append(errs, expr.SubqueryErr(sub))
+	}
+	return errors.Join(errs...)
+}
+
+// subqueries returns the statements embedded into the WITH clause, the FROM clause and the conditions of the statement.
+func (s *Statement) subqueries() []RawBuilder {
+	subs := s.With.subqueries()
+	subs = append(subs, s.Table.subqueries()...)
+	subs = append(subs, s.Where.subqueries()...)
+	return append(subs, s.Having.subqueries()...)
+}
+
+// withDialect returns a shallow copy of the statement rendered with the dialect d.
+func (s *Statement) withDialect(d Dialect) *Statement {
+	r := *s
+	r.Dialect = d
+	return &r
+}
+
+// buildRawFor implements dialectBuilder.
+func (s *Statement) buildRawFor(d Dialect) (string, []any) {
+	return s.withDialect(d).BuildRaw()
+}
+
+// errFor implements dialectBuilder.
+func (s *Statement) errFor(d Dialect) error {
+	return s.withDialect(d).Err()
+}
+
+// SetLimitOffset sets the LIMIT and OFFSET rendered by the dialect of the statement when it is built.
+// Unlike the clauses added to LimitOffset, they follow the dialect of the statement embedding this statement.
+// If limit is <= 0, no clause is rendered.
+func (s *Statement) SetLimitOffset(limit, offset int64) {
+	s.pagination = &pagination{limit: limit, offset: offset}
+}
+
+// limitOffset returns the clauses of LimitOffset followed by the clause set by SetLimitOffset rendered for the dialect.
+func (s *Statement) limitOffset(d Dialect) (string, []any) {
+	return s.pagination.build(s.LimitOffset, d)
+}
+
+// build returns the clauses of the block followed by the clause of the pagination rendered for the dialect.
+// The receiver can be nil.
+func (p *pagination) build(block *Block, d Dialect) (string, []any) {
+	if p == nil {
+		return block.content, block.values
+	}
+	clause, values := d.LimitOffset(p.limit, p.offset)
+	switch {
+	case clause == "":
+		return block.content, block.values
+	case block.IsEmpty():
+		return clause, values
 	}
+	return block.content + " " + clause, append(slices.Clone(block.values), values...)
+}
+
+// IsDistinct returns true if the statement removes duplicated rows with DISTINCT or DISTINCT ON.
+func (s *Statement) IsDistinct() bool {
+	return s.Distinct || !s.DistinctOn.IsEmpty()
+}
+
+// Quote quotes the identifier with the dialect of the statement.
+func (s *Statement) Quote(name string) string {
+	return DialectOrDefault(s.Dialect).QuoteIdentifier(name)
 }
 
 // Build constructs the complete SQL query string and returns it along with the placeholder values.
+// The placeholders are rendered for the dialect of the statement.
 func (s *Statement) Build() (string, []any) {
-	queryParts := []string{"SELECT", strings.Join(s.Fields.Fields(), ", ")}
+	query, args := s.BuildRaw()
+	return Rebind(DialectOrDefault(s.Dialect), query), args
+}
+
+// BuildRaw constructs the SQL query string with generic ? placeholders and returns it along with the placeholder values.
+// It is used to embed the statement into another statement.
+// The statements embedded into this statement are built with the dialect of this statement.
+func (s *Statement) BuildRaw() (string, []any) {
+	d := DialectOrDefault(s.Dialect)
+	queryParts := []string{}
 	args := make([]any, 0)
 
+	if !s.With.IsEmpty() {
+		content, values := s.With.build(s.With.Recursive && d.Supports(FeatureRecursiveKeyword), d)
+		queryParts = append(queryParts, content)
+		args = append(args, values...)
+	}
+
+	queryParts = append(queryParts, "SELECT")
+	if !s.DistinctOn.IsEmpty() {
+		queryParts = append(queryParts, "DISTINCT ON ("+s.DistinctOn.content+")")
+		args = append(args, s.DistinctOn.values...)
+	} else if s.Distinct {
+		queryParts = append(queryParts, "DISTINCT")
+	}
+	queryParts = append(queryParts, strings.Join(s.Fields.Fields(), ", "))
+
 	{
-		s, values := s.Table.Build()
+		var hint string
+		if d.Supports(FeatureLockingTableHints) {
+			hint = s.Locking.tableHint()
+		}
+		// Errors of the required joins are reported by Err and RawErr.
+		table, _ := s.table()
+		s, values := table.build(hint, d)
 		queryParts = append(queryParts, "FROM", s)
 		args = append(args, values...)
 	}
 
 	if !s.Where.IsEmpty() {
-		content, values := s.Where.Build()
+		content, values := s.Where.build(d)
 		queryParts = append(queryParts, "WHERE "+content)
 		args = append(args, values...)
 	}
 
+	if !s.GroupBy.IsEmpty() {
+		queryParts = append(queryParts, "GROUP BY "+s.GroupBy.content)
+		args = append(args, s.GroupBy.values...)
+	}
+
+	if !s.Having.IsEmpty() {
+		content, values := s.Having.build(d)
+		queryParts = append(queryParts, "HAVING "+content)
+		args = append(args, values...)
+	}
+
+	limitOffset, limitOffsetValues := s.limitOffset(d)
 	if !s.Sort.IsEmpty() {
 		queryParts = append(queryParts, "ORDER BY "+s.Sort.content)
 		args = append(args, s.Sort.values...)
+	} else if limitOffset != "" && d.Supports(FeatureOffsetFetch) {
+		// OFFSET ... FETCH is not allowed without ORDER BY.
+		queryParts = append(queryParts, "ORDER BY (SELECT NULL)")
+	}
+
+	if limitOffset != "" {
+		queryParts = append(queryParts, limitOffset)
+		args = append(args, limitOffsetValues...)
 	}
 
-	if !s.LimitOffset.IsEmpty() {
-		queryParts = append(queryParts, s.LimitOffset.content)
-		args = append(args, s.LimitOffset.values...)
+	if !s.Locking.IsEmpty() && d.Supports(FeatureLockingClause) {
+		queryParts = append(queryParts, s.Locking.Build())
 	}
 
 	return strings.Join(queryParts, " "), args
@@ -1,6 +1,7 @@
 package statement
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -34,6 +35,21 @@ func TestNewStatement(t *testing.T) {
 	if s.Sort == nil {
 		t.Errorf("NewStatement() Sort should not be nil")
 	}
+	if s.With == nil {
+		t.Errorf("NewStatement() With should not be nil")
+	}
+	if s.DistinctOn == nil {
+		t.Errorf("NewStatement() DistinctOn should not be nil")
+	}
+	if s.Locking == nil {
+		t.Errorf("NewStatement() Locking should not be nil")
+	}
+	if s.GroupBy == nil {
+		t.Errorf("NewStatement() GroupBy should not be nil")
+	}
+	if s.Having == nil {
+		t.Errorf("NewStatement() Having should not be nil")
+	}
 	if s.LimitOffset == nil {
 		t.Errorf("NewStatement() LimitOffset should not be nil")
 	}
@@ -292,6 +308,60 @@ func TestStatement_Build_Complex(t *testing.T) {
 			wantSQL:    "SELECT id, name, price, category_id FROM products WHERE price < ? AND category_id IN (?,?,?) AND deleted_at IS NULL ORDER BY category_id, price ASC LIMIT ? OFFSET ?",
 			wantValues: []any{1000, 1, 2, 3, 20, 100},
 		},
+		{
+			name: "SELECT with GROUP BY and HAVING",
+			setup: func() *Statement {
+				s := New("books", NewSimpleFields("author_id", "COUNT(*) AS books"))
+				s.Where.Add(expr.Field("status", expr.Eq("published")))
+				s.GroupBy.Add("author_id")
+				s.Having.Add(expr.Field("COUNT(*)", expr.Gte(2)))
+				s.Having.Add(expr.Field("MAX(yr)", expr.Lt(2000)))
+				s.Sort.Add("books DESC")
+				s.LimitOffset.Add("LIMIT ?", 10)
+				return s
+			},
+			wantSQL:    "SELECT author_id, COUNT(*) AS books FROM books WHERE status = ? GROUP BY author_id HAVING COUNT(*) >= ? AND MAX(yr) < ? ORDER BY books DESC LIMIT ?",
+			wantValues: []any{"published", 2, 2000, 10},
+		},
+		{
+			name: "SELECT DISTINCT",
+			setup: func() *Statement {
+				s := New("authors a", NewSimpleFields("a.id", "a.name"))
+				s.Distinct = true
+				s.Table.InnerJoin("books b", "b.author_id = a.id")
+				s.Where.Add(expr.Field("b.yr", expr.Gte(2000)))
+				return s
+			},
+			wantSQL:    "SELECT DISTINCT a.id, a.name FROM authors a INNER JOIN books b ON b.author_id = a.id WHERE b.yr >= ?",
+			wantValues: []any{2000},
+		},
+		{
+			name: "SELECT DISTINCT ON",
+			setup: func() *Statement {
+				s := New("books", NewSimpleFields("author_id", "title", "yr"))
+				s.Distinct = true
+				s.DistinctOn.Add("author_id")
+				s.Where.Add(expr.Field("yr", expr.Gte(2000)))
+				s.Sort.Add("author_id")
+				s.Sort.Add("yr DESC")
+				return s
+			},
+			wantSQL:    "SELECT DISTINCT ON (author_id) author_id, title, yr FROM books WHERE yr >= ? ORDER BY author_id, yr DESC",
+			wantValues: []any{2000},
+		},
+		{
+			name: "SELECT from subquery",
+			setup: func() *Statement {
+				sub := New("books", NewSimpleFields("book_type"))
+				sub.Where.Add(expr.Field("yr", expr.Gte(2000)))
+				sub.GroupBy.Add("book_type")
+				s := NewFromSubquery(sub, "t", NewSimpleFields("COUNT(*)"))
+				s.Where.Add(expr.Field("book_type", expr.NotEq("novel")))
+				return s
+			},
+			wantSQL:    "SELECT COUNT(*) FROM (SELECT book_type FROM books WHERE yr >= ? GROUP BY book_type) AS t WHERE book_type <> ?",
+			wantValues: []any{2000, "novel"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -308,3 +378,533 @@ func TestStatement_Build_Complex(t *testing.T) {
 		})
 	}
 }
+
+func TestStatement_Build_WithDialect(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		setup      func() *Statement
+		wantSQL    string
+		wantValues []any
+	}{
+		{
+			name: "PostgreSQL placeholders",
+			setup: func() *Statement {
+				s := New("users", NewSimpleFields("id", "name"))
+				s.Dialect = PostgreSQL
+				s.Where.Add(expr.Field("status", expr.Eq("active")))
+				s.Where.Add(expr.Field("role", expr.In("admin", "owner")))
+				s.Sort.Add("name ASC")
+				clause, values := PostgreSQL.LimitOffset(10, 20)
+				s.LimitOffset.Add(clause, values...)
+				return s
+			},
+			wantSQL:    "SELECT id, name FROM users WHERE status = $1 AND role IN ($2,$3) ORDER BY name ASC LIMIT $4 OFFSET $5",
+			wantValues: []any{"active", "admin", "owner", int64(10), int64(20)},
+		},
+		{
+			name: "SQLServer pagination without sort",
+			setup: func() *Statement {
+				s := New("users", NewSimpleFields("id"))
+				s.Dialect = SQLServer
+				s.Where.Add(expr.Field("status", expr.Eq("active")))
+				clause, values := SQLServer.LimitOffset(10, 20)
+				s.LimitOffset.Add(clause, values...)
+				return s
+			},
+			wantSQL:    "SELECT id FROM users WHERE status = @p1 ORDER BY (SELECT NULL) OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY",
+			wantValues: []any{"active", int64(20), int64(10)},
+		},
+		{
+			name: "SQLServer pagination with sort",
+			setup: func() *Statement {
+				s := New("users", NewSimpleFields("id"))
+				s.Dialect = SQLServer
+				s.Sort.Add("id DESC")
+				clause, values := SQLServer.LimitOffset(10, 0)
+				s.LimitOffset.Add(clause, values...)
+				return s
+			},
+			wantSQL:    "SELECT id FROM users ORDER BY id DESC OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY",
+			wantValues: []any{int64(0), int64(10)},
+		},
+		{
+			name: "PostgreSQL placeholders with subqueries",
+			setup: func() *Statement {
+				books := New("books", NewSimpleFields("1"))
+				books.Where.Add(expr.Field("books.author_id", expr.Eq(1)))
+				books.Where.Add(expr.Field("books.yr", expr.Gte(2000)))
+				countries := New("countries", NewSimpleFields("id"))
+				countries.Where.Add(expr.Field("region", expr.Eq("asia")))
+
+				s := New("authors", NewSimpleFields("id", "name"))
+				s.Dialect = PostgreSQL
+				s.Where.Add(expr.Field("status", expr.Eq("active")))
+				s.Where.Add(expr.Exists(books))
+				s.Where.Add(expr.Field("country_id", expr.NotInSubquery(countries)))
+				s.Sort.Add("name ASC")
+				clause, values := PostgreSQL.LimitOffset(10, 0)
+				s.LimitOffset.Add(clause, values...)
+				return s
+			},
+			wantSQL:    "SELECT id, name FROM authors WHERE status = $1 AND EXISTS (SELECT 1 FROM books WHERE books.author_id = $2 AND books.yr >= $3) AND country_id NOT IN (SELECT id FROM countries WHERE region = $4) ORDER BY name ASC LIMIT $5",
+			wantValues: []any{"active", 1, 2000, "asia", int64(10)},
+		},
+		{
+			name: "SQLServer subqueries built with the dialect",
+			setup: func() *Statement {
+				books := New("books", NewSimpleFields("1"))
+				books.Where.Add(expr.Field("books.yr", expr.Gte(2000)))
+				books.SetLimitOffset(1, 0)
+
+				s := New("authors", NewSimpleFields("id"))
+				s.Dialect = SQLServer
+				s.Where.Add(expr.Exists(books))
+				return s
+			},
+			wantSQL:    "SELECT id FROM authors WHERE EXISTS (SELECT 1 FROM books WHERE books.yr >= @p1 ORDER BY (SELECT NULL) OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY)",
+			wantValues: []any{2000, int64(0), int64(1)},
+		},
+		{
+			name: "SQLServer WITH clause statement built with the dialect",
+			setup: func() *Statement {
+				recent := New("books", NewSimpleFields("id"))
+				recent.Sort.Add("id DESC")
+				recent.SetLimitOffset(10, 0)
+				s := New("recent", NewSimpleFields("id"))
+				s.Dialect = SQLServer
+				s.With.Add("recent", recent)
+				return s
+			},
+			wantSQL:    "WITH recent AS (SELECT id FROM books ORDER BY id DESC OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY) SELECT id FROM recent",
+			wantValues: []any{int64(0), int64(10)},
+		},
+		{
+			name: "SQLServer derived tables built with the dialect",
+			setup: func() *Statement {
+				latest := New("reviews", NewSimpleFields("book_id", "MAX(created_at) AS created_at"))
+				latest.GroupBy.Add("book_id")
+				top := New("books", NewSimpleFields("id", "title"))
+				top.Sort.Add("id DESC")
+				top.SetLimitOffset(10, 0)
+				s := NewFromSubquery(top, "b", NewSimpleFields("b.id", "b.title", "r.created_at"))
+				s.Dialect = SQLServer
+				s.Table.JoinSubquery(JoinLeftOuter, latest, "r", "r.book_id = b.id")
+				return s
+			},
+			wantSQL: "SELECT b.id, b.title, r.created_at FROM (SELECT id, title FROM books ORDER BY id DESC OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY) AS b " +
+				"LEFT OUTER JOIN (SELECT book_id, MAX(created_at) AS created_at FROM reviews GROUP BY book_id) AS r ON r.book_id = b.id",
+			wantValues: []any{int64(0), int64(10)},
+		},
+		{
+			name: "PostgreSQL SetLimitOffset",
+			setup: func() *Statement {
+				s := New("users", NewSimpleFields("id"))
+				s.Dialect = PostgreSQL
+				s.Sort.Add("id")
+				s.SetLimitOffset(10, 20)
+				return s
+			},
+			wantSQL:    "SELECT id FROM users ORDER BY id LIMIT $1 OFFSET $2",
+			wantValues: []any{int64(10), int64(20)},
+		},
+		{
+			name: "SetLimitOffset following LimitOffset",
+			setup: func() *Statement {
+				s := New("users", NewSimpleFields("id"))
+				s.LimitOffset.Add("/* page */")
+				s.SetLimitOffset(10, 0)
+				return s
+			},
+			wantSQL:    "SELECT id FROM users /* page */ LIMIT ?",
+			wantValues: []any{int64(10)},
+		},
+		{
+			name: "SetLimitOffset without limit",
+			setup: func() *Statement {
+				s := New("users", NewSimpleFields("id"))
+				s.Dialect = SQLServer
+				s.SetLimitOffset(0, 10)
+				return s
+			},
+			wantSQL:    "SELECT id FROM users",
+			wantValues: []any{},
+		},
+		{
+			name: "PostgreSQL placeholders with recursive WITH clause",
+			setup: func() *Statement {
+				s := New("tree", NewSimpleFields("id", "name"))
+				s.Dialect = PostgreSQL
+				s.With.Recursive = true
+				s.With.AddRaw("tree(id, name, parent_id)",
+					"SELECT id, name, parent_id FROM categories WHERE id = ? UNION ALL SELECT c.id, c.name, c.parent_id FROM categories c INNER JOIN tree t ON c.parent_id = t.id", 1)
+				s.Where.Add(expr.Field("name", expr.NotEq("hidden")))
+				clause, values := PostgreSQL.LimitOffset(10, 20)
+				s.LimitOffset.Add(clause, values...)
+				return s
+			},
+			wantSQL:    "WITH RECURSIVE tree(id, name, parent_id) AS (SELECT id, name, parent_id FROM categories WHERE id = $1 UNION ALL SELECT c.id, c.name, c.parent_id FROM categories c INNER JOIN tree t ON c.parent_id = t.id) SELECT id, name FROM tree WHERE name <> $2 LIMIT $3 OFFSET $4",
+			wantValues: []any{1, "hidden", int64(10), int64(20)},
+		},
+		{
+			name: "SQLServer omits RECURSIVE keyword",
+			setup: func() *Statement {
+				s := New("tree", NewSimpleFields("id"))
+				s.Dialect = SQLServer
+				s.With.Recursive = true
+				s.With.AddRaw("tree(id)", "SELECT id FROM categories WHERE id = ? UNION ALL SELECT c.id FROM categories c INNER JOIN tree t ON c.parent_id = t.id", 1)
+				return s
+			},
+			wantSQL:    "WITH tree(id) AS (SELECT id FROM categories WHERE id = @p1 UNION ALL SELECT c.id FROM categories c INNER JOIN tree t ON c.parent_id = t.id) SELECT id FROM tree",
+			wantValues: []any{1},
+		},
+		{
+			name: "PostgreSQL locking clause",
+			setup: func() *Statement {
+				s := New("jobs", NewSimpleFields("id", "payload"))
+				s.Dialect = PostgreSQL
+				s.Where.Add(expr.Field("status", expr.Eq("queued")))
+				s.Sort.Add("id")
+				clause, values := PostgreSQL.LimitOffset(10, 0)
+				s.LimitOffset.Add(clause, values...)
+				s.Locking.ForUpdate("jobs")
+				s.Locking.SkipLocked = true
+				return s
+			},
+			wantSQL:    "SELECT id, payload FROM jobs WHERE status = $1 ORDER BY id LIMIT $2 FOR UPDATE OF jobs SKIP LOCKED",
+			wantValues: []any{"queued", int64(10)},
+		},
+		{
+			name: "SQLServer locking table hints",
+			setup: func() *Statement {
+				s := New("jobs j", NewSimpleFields("j.id"))
+				s.Dialect = SQLServer
+				s.Table.InnerJoin("queues q", "q.id = j.queue_id")
+				s.Where.Add(expr.Field("j.status", expr.Eq("queued")))
+				s.Locking.ForUpdate()
+				s.Locking.SkipLocked = true
+				return s
+			},
+			wantSQL:    "SELECT j.id FROM jobs j WITH (UPDLOCK, ROWLOCK, READPAST) INNER JOIN queues q ON q.id = j.queue_id WHERE j.status = @p1",
+			wantValues: []any{"queued"},
+		},
+		{
+			name: "SQLServer lateral joins",
+			setup: func() *Statement {
+				top := New("reviews r", NewSimpleFields("TOP 1 r.body"))
+				top.Where.Add(expr.Field("r.rating", expr.Gte(4)))
+				s := New("books b", NewSimpleFields("b.id", "top.body", "last.body"))
+				s.Dialect = SQLServer
+				s.Table.CrossJoinLateral(top, "top")
+				s.Table.LeftOuterJoinLateral(New("comments", NewSimpleFields("TOP 1 body")), "last")
+				s.Where.Add(expr.Field("b.yr", expr.Gte(2000)))
+				return s
+			},
+			wantSQL:    "SELECT b.id, top.body, last.body FROM books b CROSS APPLY (SELECT TOP 1 r.body FROM reviews r WHERE r.rating >= @p1) AS top OUTER APPLY (SELECT TOP 1 body FROM comments) AS last WHERE b.yr >= @p2",
+			wantValues: []any{4, 2000},
+		},
+		{
+			name: "SQLServer LEFT OUTER JOIN LATERAL upgraded to INNER JOIN LATERAL",
+			setup: func() *Statement {
+				last := New("comments c", NewSimpleFields("TOP 1 c.body"))
+				last.Where.Add(expr.Field("c.book_id", expr.Eq(1)))
+				s := New("books b", NewSimpleFields("b.id", "last.body"))
+				s.Dialect = SQLServer
+				s.Table.LeftOuterJoinLateral(last, "last")
+				s.Table.InnerJoinLateral(last, "last")
+				return s
+			},
+			wantSQL:    "SELECT b.id, last.body FROM books b CROSS APPLY (SELECT TOP 1 c.body FROM comments c WHERE c.book_id = @p1) AS last",
+			wantValues: []any{1},
+		},
+		{
+			name: "nil dialect falls back to MySQL",
+			setup: func() *Statement {
+				s := New("users", NewSimpleFields("id"))
+				s.Dialect = nil
+				s.Where.Add(expr.Field("status", expr.Eq("active")))
+				return s
+			},
+			wantSQL:    "SELECT id FROM users WHERE status = ?",
+			wantValues: []any{"active"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := tt.setup()
+			gotSQL, gotValues := s.Build()
+			if gotSQL != tt.wantSQL {
+				t.Errorf("Build() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("Build() values = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestStatement_BuildRaw(t *testing.T) {
+	t.Parallel()
+	s := New("users", NewSimpleFields("id"))
+	s.Dialect = PostgreSQL
+	s.Where.Add(expr.Field("status", expr.Eq("active")))
+
+	gotSQL, gotValues := s.BuildRaw()
+	if want := "SELECT id FROM users WHERE status = ?"; gotSQL != want {
+		t.Errorf("BuildRaw() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{"active"}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("BuildRaw() values = %v, want %v", gotValues, want)
+	}
+}
+
+func TestStatement_IsGrouped(t *testing.T) {
+	t.Parallel()
+	s := New("books", NewSimpleFields("book_type"))
+	if s.IsGrouped() {
+		t.Errorf("IsGrouped() = true, want false")
+	}
+	s.GroupBy.Add("book_type")
+	if !s.IsGrouped() {
+		t.Errorf("IsGrouped() = false, want true")
+	}
+
+	s = New("books", NewSimpleFields("COUNT(*)"))
+	s.Having.Add(expr.Field("COUNT(*)", expr.Gt(0)))
+	if !s.IsGrouped() {
+		t.Errorf("IsGrouped() = false, want true")
+	}
+}
+
+func TestStatement_IsDistinct(t *testing.T) {
+	t.Parallel()
+	s := New("books", NewSimpleFields("author_id"))
+	if s.IsDistinct() {
+		t.Errorf("IsDistinct() = true, want false")
+	}
+	s.Distinct = true
+	if !s.IsDistinct() {
+		t.Errorf("IsDistinct() = false, want true")
+	}
+
+	s = New("books", NewSimpleFields("author_id", "title"))
+	s.DistinctOn.Add("author_id")
+	if !s.IsDistinct() {
+		t.Errorf("IsDistinct() = false, want true")
+	}
+}
+
+func TestStatement_Err(t *testing.T) {
+	t.Parallel()
+	s := New("jobs", NewSimpleFields("id"))
+	s.Dialect = SQLite
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+	s.Locking.ForUpdate()
+	if err := s.Err(); !errors.Is(err, ErrLockingUnsupported) {
+		t.Errorf("Err() = %v, want %v", err, ErrLockingUnsupported)
+	}
+
+	s = New("books b", NewSimpleFields("b.id"))
+	s.Table.FullOuterJoin("authors a", "a.id = b.author_id")
+	if err := s.Err(); !errors.Is(err, ErrFullOuterJoinUnsupported) {
+		t.Errorf("Err() = %v, want %v", err, ErrFullOuterJoinUnsupported)
+	}
+
+	s = New("books", NewSimpleFields("author_id", "title"))
+	s.DistinctOn.Add("author_id")
+	if err := s.Err(); !errors.Is(err, ErrDistinctOnUnsupported) {
+		t.Errorf("Err() = %v, want %v", err, ErrDistinctOnUnsupported)
+	}
+	s.Dialect = PostgreSQL
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+
+	s = New("authors", NewSimpleFields("id"))
+	s.Where.Add(expr.Exists(&builtSubquery{query: "SELECT 1 FROM books"}))
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestStatement_Err_Subquery(t *testing.T) {
+	t.Parallel()
+	// unknownJoin returns a subquery requiring a join which isn't defined.
+	unknownJoin := func() *Statement {
+		sub := New("books b", NewSimpleFields("b.author_id"))
+		sub.Require("publishers")
+		return sub
+	}
+	tests := []struct {
+		name  string
+		setup func() *Statement
+	}{
+		{
+			name: "Exists",
+			setup: func() *Statement {
+				s := New("authors", NewSimpleFields("id"))
+				s.Where.Add(expr.Exists(unknownJoin()))
+				return s
+			},
+		},
+		{
+			name: "InSubquery in HAVING",
+			setup: func() *Statement {
+				s := New("books", NewSimpleFields("author_id", "COUNT(*)"))
+				s.GroupBy.Add("author_id")
+				s.Having.Add(expr.Field("author_id", expr.InSubquery(unknownJoin())))
+				return s
+			},
+		},
+		{
+			name: "WithBlock.Add",
+			setup: func() *Statement {
+				s := New("recent", NewSimpleFields("author_id"))
+				s.With.Add("recent", unknownJoin())
+				return s
+			},
+		},
+		{
+			name: "JoinSubquery",
+			setup: func() *Statement {
+				s := New("authors a", NewSimpleFields("a.id"))
+				s.Table.JoinSubquery(JoinInner, unknownJoin(), "t", "t.author_id = a.id")
+				return s
+			},
+		},
+		{
+			name: "CrossJoinLateral",
+			setup: func() *Statement {
+				s := New("authors a", NewSimpleFields("a.id"))
+				s.Table.CrossJoinLateral(unknownJoin(), "t")
+				return s
+			},
+		},
+		{
+			name: "NewFromSubquery",
+			setup: func() *Statement {
+				return NewFromSubquery(unknownJoin(), "t", NewSimpleFields("COUNT(*)"))
+			},
+		},
+		{
+			name: "Compound in NewFromSubquery",
+			setup: func() *Statement {
+				c := NewCompound(UnionAll, New("authors", NewSimpleFields("id")), unknownJoin())
+				return NewFromSubquery(c, "t", NewSimpleFields("COUNT(*)"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := tt.setup()
+			if err := s.RawErr(); !errors.Is(err, ErrUnknownJoin) {
+				t.Errorf("RawErr() = %v, want %v", err, ErrUnknownJoin)
+			}
+			if err := s.Err(); !errors.Is(err, ErrUnknownJoin) {
+				t.Errorf("Err() = %v, want %v", err, ErrUnknownJoin)
+			}
+		})
+	}
+}
+
+func TestStatement_Err_SubqueryDialect(t *testing.T) {
+	t.Parallel()
+	// fullOuterJoin returns a subquery which is valid for its own dialect but not for MySQL.
+	fullOuterJoin := func() *Statement {
+		sub := New("books b", NewSimpleFields("b.author_id"))
+		sub.Dialect = PostgreSQL
+		sub.Table.FullOuterJoin("authors a", "a.id = b.author_id")
+		return sub
+	}
+	tests := []struct {
+		name  string
+		setup func() *Statement
+	}{
+		{
+			name: "Exists",
+			setup: func() *Statement {
+				s := New("authors", NewSimpleFields("id"))
+				s.Where.Add(expr.Exists(fullOuterJoin()))
+				return s
+			},
+		},
+		{
+			name: "InSubquery in HAVING",
+			setup: func() *Statement {
+				s := New("books", NewSimpleFields("author_id", "COUNT(*)"))
+				s.GroupBy.Add("author_id")
+				s.Having.Add(expr.Field("author_id", expr.InSubquery(fullOuterJoin())))
+				return s
+			},
+		},
+		{
+			name: "WithBlock.Add",
+			setup: func() *Statement {
+				s := New("recent", NewSimpleFields("author_id"))
+				s.With.Add("recent", fullOuterJoin())
+				return s
+			},
+		},
+		{
+			name: "JoinSubquery",
+			setup: func() *Statement {
+				s := New("authors a", NewSimpleFields("a.id"))
+				s.Table.JoinSubquery(JoinInner, fullOuterJoin(), "t", "t.author_id = a.id")
+				return s
+			},
+		},
+		{
+			name: "CrossJoinLateral",
+			setup: func() *Statement {
+				s := New("authors a", NewSimpleFields("a.id"))
+				s.Table.CrossJoinLateral(fullOuterJoin(), "t")
+				return s
+			},
+		},
+		{
+			name: "NewFromSubquery",
+			setup: func() *Statement {
+				return NewFromSubquery(fullOuterJoin(), "t", NewSimpleFields("COUNT(*)"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := tt.setup()
+			if err := s.RawErr(); err != nil {
+				t.Errorf("RawErr() = %v, want nil", err)
+			}
+			if err := s.Err(); !errors.Is(err, ErrFullOuterJoinUnsupported) {
+				t.Errorf("Err() = %v, want %v", err, ErrFullOuterJoinUnsupported)
+			}
+			s.Dialect = PostgreSQL
+			if err := s.Err(); err != nil {
+				t.Errorf("Err() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestStatement_Quote(t *testing.T) {
+	t.Parallel()
+	s := New("users", NewSimpleFields("id"))
+	if got, want := s.Quote("users.order"), "`users`.`order`"; got != want {
+		t.Errorf("Quote() = %v, want %v", got, want)
+	}
+	s.Dialect = PostgreSQL
+	if got, want := s.Quote("users.order"), `"users"."order"`; got != want {
+		t.Errorf("Quote() = %v, want %v", got, want)
+	}
+	s.Dialect = nil
+	if got, want := s.Quote("order"), "`order`"; got != want {
+		t.Errorf("Quote() = %v, want %v", got, want)
+	}
+}
@@ -1,9 +1,12 @@
 package statement
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 
-	"github.com/tecowl/querybm/helpers/slices"
+	"github.com/tecowl/querybm/expr"
 )
 
 type tableName struct {
@@ -53,47 +56,131 @@ func (t *tableName) MatchAliasOrName(s string) bool {
 	return strings.EqualFold(t.Name, s)
 }
 
+// JoinType is the type of a JOIN clause.
+type JoinType string
+
+// Join types for TableBlock.
+const (
+	JoinInner      JoinType = "INNER JOIN"
+	JoinLeftOuter  JoinType = "LEFT OUTER JOIN"
+	JoinRightOuter JoinType = "RIGHT OUTER JOIN"
+	JoinFullOuter  JoinType = "FULL OUTER JOIN"
+	JoinCross      JoinType = "CROSS JOIN"
+)
+
+var (
+	// ErrFullOuterJoinUnsupported is returned when the dialect doesn't support FULL OUTER JOIN.
+	ErrFullOuterJoinUnsupported = errors.New("FULL OUTER JOIN is not supported by the dialect")
+	// ErrLateralJoinUnsupported is returned when the dialect doesn't support LATERAL joins.
+	ErrLateralJoinUnsupported = errors.New("LATERAL join is not supported by the dialect")
+	// ErrJoinConflict is returned when a join is added with the alias or name of another join
+	// which has a different table, type, condition or values.
+	ErrJoinConflict = errors.New("conflicting join")
+)
+
 type joinItem struct {
 	tableName
-	joinType  string
+	// sub is the subquery joined as a derived table named Alias. It is built when the table block is built.
+	sub       RawBuilder
+	joinType  JoinType
+	lateral   bool
 	Condition string
 	Args      []any
 }
 
+// target returns the table name with the subquery built for the dialect d, followed by the values of the
+// subquery and the condition. If d is nil, the subquery is built with its own dialect.
+func (j *joinItem) target(d Dialect) (tableName, []any) {
+	if j.sub == nil {
+		return j.tableName, j.Args
+	}
+	return subqueryTableName(j.sub, j.Alias, d, j.Args)
+}
+
+// subqueryTableName returns the table name of the subquery built for the dialect d as a derived table named alias,
+// and the values of the subquery followed by values.
+func subqueryTableName(sub RawBuilder, alias string, d Dialect, values []any) (tableName, []any) {
+	built := buildEmbedded(sub, d)
+	if len(built.values) > 0 {
+		values = append(built.values[:len(built.values):len(built.values)], values...)
+	}
+	return tableName{Name: "(" + built.query + ")", Alias: alias, useAs: true}, values
+}
+
+// sameTarget returns true if both join the same table or subquery with the same condition and values.
+func (j *joinItem) sameTarget(other *joinItem) bool {
+	name, args := j.target(nil)
+	otherName, otherArgs := other.target(nil)
+	return name.Name == otherName.Name &&
+		strings.EqualFold(j.Alias, other.Alias) &&
+		j.lateral == other.lateral &&
+		strings.Join(strings.Fields(j.Condition), " ") == strings.Join(strings.Fields(other.Condition), " ") &&
+		(len(args) == 0 && len(otherArgs) == 0 || reflect.DeepEqual(args, otherArgs))
+}
+
 func (j *joinItem) Build() (string, []any) {
-	r := j.joinType + " " + j.tableName.String()
+	return j.build(nil)
+}
+
+// build constructs the JOIN clause with the subquery built for the dialect d. If the dialect supports
+// FeatureApplyJoin, LATERAL joins are rendered as CROSS APPLY for CROSS and INNER joins,
+// and OUTER APPLY for LEFT OUTER joins. If d is nil, the subquery is built with its own dialect.
+func (j *joinItem) build(d Dialect) (string, []any) {
+	name, args := j.target(d)
+	apply := d != nil && d.Supports(FeatureApplyJoin)
+	var r string
+	switch {
+	case j.lateral && apply && (j.joinType == JoinCross || j.joinType == JoinInner):
+		return "CROSS APPLY " + name.String(), args
+	case j.lateral && apply:
+		return "OUTER APPLY " + name.String(), args
+	case j.lateral:
+		r = string(j.joinType) + " LATERAL " + name.String()
+	default:
+		r = string(j.joinType) + " " + name.String()
+	}
 	if j.Condition != "" {
 		r += " ON " + j.Condition
 	}
-	return r, j.Args
+	return r, args
 }
 
 type joinItems []*joinItem
 
-func (s joinItems) Build() (string, []any) {
+func (s joinItems) build(d Dialect) (string, []any) {
 	var sb strings.Builder
 	var args []any
 	for i, item := range s {
 		if i > 0 {
 			sb.WriteString(" ")
 		}
-		part, partArgs := item.Build()
+		part, partArgs := item.build(d)
 		sb.WriteString(part)
 		args = append(args, partArgs...)
 	}
 	return sb.String(), args
 }
 
-func (s joinItems) MatchAliasOrName(v string) bool {
-	return slices.Any(s, func(item *joinItem) bool {
-		return item.MatchAliasOrName(v)
-	})
+func (s joinItems) find(v string) *joinItem {
+	for _, item := range s {
+		if item.MatchAliasOrName(v) {
+			return item
+		}
+	}
+	return nil
 }
 
+// RawBuilder builds SQL with generic ? placeholders to be embedded into another statement.
+// Statement implements RawBuilder.
+type RawBuilder = expr.Subquery
+
 // TableBlock represents the FROM clause of a SQL statement, including JOIN operations.
 type TableBlock struct {
 	tableName tableName
-	items     joinItems
+	// sub is the subquery selected from as a derived table. It is built when the table block is built.
+	sub   RawBuilder
+	items joinItems
+	err   error
 }
 
 // NewTableBlock creates a new TableBlock with the specified table name.
@@ -104,39 +191,203 @@ func NewTableBlock(table string) *TableBlock {
 	}
 }
 
+// NewSubqueryTableBlock creates a new TableBlock selecting from the subquery as a derived table named alias.
+// The subquery is built with the dialect of the statement when the statement is built.
+func NewSubqueryTableBlock(sub RawBuilder, alias string) *TableBlock {
+	return &TableBlock{
+		tableName: tableName{Alias: alias, useAs: true},
+		sub:       sub,
+		items:     joinItems{},
+	}
+}
+
 // Build constructs the FROM clause string and returns it with placeholder values.
+// The subqueries are built with their own dialect.
 func (b *TableBlock) Build() (string, []any) {
-	content := []string{b.tableName.String()}
-	joinContent, joinArgs := b.items.Build()
+	return b.build("", nil)
+}
+
+// build constructs the FROM clause string with the table hint following the table name,
+// and the subqueries built for the dialect d. If the dialect supports FeatureApplyJoin,
+// LATERAL joins are rendered as CROSS APPLY or OUTER APPLY.
+func (b *TableBlock) build(hint string, d Dialect) (string, []any) {
+	name, args := b.tableName, []any(nil)
+	if b.sub != nil {
+		name, args = subqueryTableName(b.sub, b.tableName.Alias, d, nil)
+	}
+	content := []string{name.String()}
+	if hint != "" {
+		content = append(content, hint)
+	}
+	joinContent, joinArgs := b.items.build(d)
 	if joinContent != "" {
 		content = append(content, joinContent)
 	}
 	r := strings.Join(content, " ")
-	return r, joinArgs
+	return r, append(args, joinArgs...)
 }
 
-func (b *TableBlock) add(joinType, table string, condition string, values ...any) {
-	tableName := parseTableName(table)
-	if b.items.MatchAliasOrName(tableName.AliasOrName()) {
-		// If the table is already included, skip adding it again
+// subqueries returns the subqueries selected from or joined.
+func (b *TableBlock) subqueries() []RawBuilder {
+	var subs []RawBuilder
+	if b.sub != nil {
+		subs = append(subs, b.sub)
+	}
+	for _, item := range b.items {
+		if item.sub != nil {
+			subs = append(subs, item.sub)
+		}
+	}
+	return subs
+}
+
+// withJoins returns a copy of the table block with the joins preceding the joins of the table block.
+// The table block itself is not modified.
+func (b *TableBlock) withJoins(items []*joinItem) *TableBlock {
+	r := &TableBlock{tableName: b.tableName, sub: b.sub, items: joinItems{}, err: b.err}
+	for _, item := range items {
+		r.add(item)
+	}
+	for _, item := range b.items {
+		clone := *item
+		r.add(&clone)
+	}
+	return r
+}
+
+// Err returns the errors of the conflicting joins added to the table block.
+func (b *TableBlock) Err() error {
+	return b.err
+}
+
+// Validate checks that the joins of the table block don't conflict and the dialect supports them.
+func (b *TableBlock) Validate(d Dialect) error {
+	if b.err != nil {
+		return b.err
+	}
+	for _, item := range b.items {
+		if item.joinType == JoinFullOuter && !d.Supports(FeatureFullOuterJoin) {
+			return ErrFullOuterJoinUnsupported
+		}
+		if item.lateral && !d.Supports(FeatureLateralJoin) && !d.Supports(FeatureApplyJoin) {
+			return ErrLateralJoinUnsupported
+		}
+	}
+	return nil
+}
+
+// add adds the join item unless a join with the same alias or name already exists.
+// If the existing join is the same, the new one is skipped. If the existing join is a LEFT OUTER JOIN
+// and the new one is an INNER JOIN of the same target, the existing one is upgraded to INNER JOIN,
+// and vice versa the INNER JOIN is kept. Otherwise the conflict is recorded and reported by Err and Validate.
+func (b *TableBlock) add(item *joinItem) {
+	existing := b.items.find(item.AliasOrName())
+	if existing == nil {
+		b.items = append(b.items, item)
 		return
 	}
+	if existing.sameTarget(item) {
+		switch {
+		case existing.joinType == item.joinType:
+			return
+		case existing.joinType == JoinLeftOuter && item.joinType == JoinInner:
+			existing.joinType = JoinInner
+			return
+		case existing.joinType == JoinInner && item.joinType == JoinLeftOuter:
+			return
+		}
+	}
+	existingSQL, _ := existing.Build()
+	itemSQL, _ := item.Build()
+	b.err = errors.Join(b.err, fmt.Errorf("%w %q: %s and %s", ErrJoinConflict, item.AliasOrName(), existingSQL, itemSQL))
+}
+
+// Join adds a JOIN clause of the join type to the table block.
+// The condition is ignored for JoinCross.
+func (b *TableBlock) Join(joinType JoinType, table string, condition string, values ...any) {
+	if joinType == JoinCross {
+		condition, values = "", nil
+	}
 	// Parse the table name and create a join item
-	item := &joinItem{
+	b.add(&joinItem{
 		joinType:  joinType,
-		tableName: *tableName,
+		tableName: *parseTableName(table),
 		Condition: condition,
 		Args:      values,
-	}
-	b.items = append(b.items, item)
+	})
 }
 
 // InnerJoin adds an INNER JOIN clause to the table block.
 func (b *TableBlock) InnerJoin(table string, condition string, values ...any) {
-	b.add("INNER JOIN", table, condition, values...)
+	b.Join(JoinInner, table, condition, values...)
 }
 
 // LeftOuterJoin adds a LEFT OUTER JOIN clause to the table block.
 func (b *TableBlock) LeftOuterJoin(table string, condition string, values ...any) {
-	b.add("LEFT OUTER JOIN", table, condition, values...)
+	b.Join(JoinLeftOuter, table, condition, values...)
+}
+
+// RightOuterJoin adds a RIGHT OUTER JOIN clause to the table block.
+func (b *TableBlock) RightOuterJoin(table string, condition string, values ...any) {
+	b.Join(JoinRightOuter, table, condition, values...)
+}
+
+// FullOuterJoin adds a FULL OUTER JOIN clause to the table block.
+// It is not supported by MySQL.
+func (b *TableBlock) FullOuterJoin(table string, condition string, values ...any) {
+	b.Join(JoinFullOuter, table, condition, values...)
+}
+
+// CrossJoin adds a CROSS JOIN clause to the table block.
+func (b *TableBlock) CrossJoin(table string) {
+	b.Join(JoinCross, table, "")
+}
+
+// JoinSubquery adds a JOIN clause of the join type with the subquery as a derived table named alias
+// like `LEFT OUTER JOIN (SELECT ...) AS latest ON latest.book_id = books.id`.
+// The subquery is built with the dialect of the statement when the statement is built,
+// and its values are followed by the values of the condition.
+// The condition is ignored for JoinCross.
+func (b *TableBlock) JoinSubquery(joinType JoinType, sub RawBuilder, alias string, condition string, values ...any) {
+	if joinType == JoinCross {
+		condition, values = "", nil
+	}
+	b.add(&joinItem{
+		joinType:  joinType,
+		tableName: tableName{Alias: alias, useAs: true},
+		sub:       sub,
+		Condition: condition,
+		Args:      values,
+	})
+}
+
+// CrossJoinLateral adds a CROSS JOIN LATERAL clause with the subquery named alias.
+// The subquery can refer to the columns of the preceding tables.
+// It is rendered as CROSS APPLY for SQL Server.
+func (b *TableBlock) CrossJoinLateral(sub RawBuilder, alias string) {
+	b.joinLateral(JoinCross, sub, alias, "")
+}
+
+// InnerJoinLateral adds an INNER JOIN LATERAL clause with the subquery named alias joined ON TRUE.
+// The subquery can refer to the columns of the preceding tables, so the join condition should be in the subquery.
+// It upgrades the LEFT OUTER JOIN LATERAL of the same subquery and alias, and is rendered as CROSS APPLY for SQL Server.
+func (b *TableBlock) InnerJoinLateral(sub RawBuilder, alias string) {
+	b.joinLateral(JoinInner, sub, alias, "TRUE")
+}
+
+// LeftOuterJoinLateral adds a LEFT OUTER JOIN LATERAL clause with the subquery named alias joined ON TRUE.
+// The subquery can refer to the columns of the preceding tables, so the join condition should be in the subquery.
+// It is rendered as OUTER APPLY for SQL Server.
+func (b *TableBlock) LeftOuterJoinLateral(sub RawBuilder, alias string) {
+	b.joinLateral(JoinLeftOuter, sub, alias, "TRUE")
+}
+
+func (b *TableBlock) joinLateral(joinType JoinType, sub RawBuilder, alias string, condition string) {
+	b.add(&joinItem{
+		joinType:  joinType,
+		lateral:   true,
+		tableName: tableName{Alias: alias, useAs: true},
+		sub:       sub,
+		Condition: condition,
+	})
 }
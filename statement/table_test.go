@@ -1,8 +1,11 @@
 package statement
 
 import (
+	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/tecowl/querybm/expr"
 )
 
 func TestNewTableBlock(t *testing.T) {
@@ -98,6 +101,7 @@ func TestTableBlock_InnerJoin(t *testing.T) {
 		}
 		wantContent string
 		wantValues  []any
+		wantErr     error
 	}{
 		{
 			name:    "Single INNER JOIN",
@@ -148,12 +152,13 @@ func TestTableBlock_InnerJoin(t *testing.T) {
 				},
 				{
 					table:     "customers c",
-					condition: "o.customer_id = c.id AND c.status = ? AND c.value IS NULL", // This will be ignored
-					values:    []any{"inactive"},                                           // This will be ignored
+					condition: "o.customer_id = c.id AND c.status = ? AND c.value IS NULL", // This conflicts and is not added
+					values:    []any{"inactive"},                                           // This conflicts and is not added
 				},
 			},
 			wantContent: "orders o INNER JOIN customers c ON o.customer_id = c.id AND c.status = ?",
 			wantValues:  []any{"active"},
+			wantErr:     ErrJoinConflict,
 		},
 		{
 			name:    "INNER JOIN duplicated table name",
@@ -170,12 +175,35 @@ func TestTableBlock_InnerJoin(t *testing.T) {
 				},
 				{
 					table:     "customers",
-					condition: "orders.customer_id = customers.id AND customers.status = ? AND customers.value IS NULL", // This will be ignored
-					values:    []any{"inactive"},                                                                        // This will be ignored
+					condition: "orders.customer_id = customers.id AND customers.status = ? AND customers.value IS NULL", // This conflicts and is not added
+					values:    []any{"inactive"},                                                                        // This conflicts and is not added
 				},
 			},
 			wantContent: "orders INNER JOIN customers ON orders.customer_id = customers.id AND customers.status = ?",
 			wantValues:  []any{"active"},
+			wantErr:     ErrJoinConflict,
+		},
+		{
+			name:    "INNER JOIN same join twice",
+			initial: "orders o",
+			joins: []struct {
+				table     string
+				condition string
+				values    []any
+			}{
+				{
+					table:     "customers c",
+					condition: "o.customer_id = c.id AND c.status = ?",
+					values:    []any{"active"},
+				},
+				{
+					table:     "customers c",
+					condition: "o.customer_id = c.id  AND c.status = ?",
+					values:    []any{"active"},
+				},
+			},
+			wantContent: "orders o INNER JOIN customers c ON o.customer_id = c.id AND c.status = ?",
+			wantValues:  []any{"active"},
 		},
 		{
 			name:    "Multiple INNER JOINs",
@@ -239,6 +267,9 @@ func TestTableBlock_InnerJoin(t *testing.T) {
 					t.Errorf("InnerJoin() values = %v, want %v", args, tt.wantValues)
 				}
 			}
+			if err := tb.Err(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
 		})
 	}
 }
@@ -358,3 +389,227 @@ func TestTableBlock_MixedJoins(t *testing.T) {
 		t.Errorf("Mixed joins values = %v, want %v", args, wantValues)
 	}
 }
+
+func TestNewSubqueryTableBlock(t *testing.T) {
+	t.Parallel()
+	sub := New("books", NewSimpleFields("author_id"))
+	sub.Where.Add(expr.Field("yr", expr.Gte(2000)))
+	b := NewSubqueryTableBlock(sub, "t")
+	b.InnerJoin("authors a", "a.id = t.author_id AND a.status = ?", "active")
+
+	gotSQL, gotValues := b.Build()
+	if want := "(SELECT author_id FROM books WHERE yr >= ?) AS t INNER JOIN authors a ON a.id = t.author_id AND a.status = ?"; gotSQL != want {
+		t.Errorf("Build() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{2000, "active"}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("Build() values = %v, want %v", gotValues, want)
+	}
+}
+
+func TestTableBlock_Joins(t *testing.T) {
+	t.Parallel()
+	latestReview := func() *Statement {
+		s := New("reviews r", NewSimpleFields("r.book_id", "MAX(r.created_at) AS reviewed_at"))
+		s.Where.Add(expr.Field("r.rating", expr.Gte(4)))
+		s.GroupBy.Add("r.book_id")
+		return s
+	}
+	topReview := func() *Statement {
+		s := New("reviews r", NewSimpleFields("r.body"))
+		s.Where.Add(expr.Field("r.rating", expr.Gte(4)))
+		s.Sort.Add("r.created_at DESC")
+		s.LimitOffset.Add("LIMIT ?", 1)
+		return s
+	}
+	tests := []struct {
+		name       string
+		setup      func(b *TableBlock)
+		wantSQL    string
+		wantValues []any
+	}{
+		{
+			name:       "RIGHT OUTER JOIN",
+			setup:      func(b *TableBlock) { b.RightOuterJoin("authors a", "a.id = b.author_id AND a.status = ?", "active") },
+			wantSQL:    "books b RIGHT OUTER JOIN authors a ON a.id = b.author_id AND a.status = ?",
+			wantValues: []any{"active"},
+		},
+		{
+			name:       "FULL OUTER JOIN",
+			setup:      func(b *TableBlock) { b.FullOuterJoin("authors a", "a.id = b.author_id") },
+			wantSQL:    "books b FULL OUTER JOIN authors a ON a.id = b.author_id",
+			wantValues: nil,
+		},
+		{
+			name:       "CROSS JOIN",
+			setup:      func(b *TableBlock) { b.CrossJoin("formats f") },
+			wantSQL:    "books b CROSS JOIN formats f",
+			wantValues: nil,
+		},
+		{
+			name:       "Join with CROSS JOIN ignores condition",
+			setup:      func(b *TableBlock) { b.Join(JoinCross, "formats f", "f.id = ?", 1) },
+			wantSQL:    "books b CROSS JOIN formats f",
+			wantValues: nil,
+		},
+		{
+			name: "JoinSubquery",
+			setup: func(b *TableBlock) {
+				b.InnerJoin("authors a", "a.id = b.author_id AND a.status = ?", "active")
+				b.JoinSubquery(JoinLeftOuter, latestReview(), "latest", "latest.book_id = b.id AND latest.reviewed_at >= ?", "2024-01-01")
+				b.InnerJoin("publishers p", "p.id = b.publisher_id AND p.country = ?", "JP")
+			},
+			wantSQL: "books b INNER JOIN authors a ON a.id = b.author_id AND a.status = ? " +
+				"LEFT OUTER JOIN (SELECT r.book_id, MAX(r.created_at) AS reviewed_at FROM reviews r WHERE r.rating >= ? GROUP BY r.book_id) AS latest ON latest.book_id = b.id AND latest.reviewed_at >= ? " +
+				"INNER JOIN publishers p ON p.id = b.publisher_id AND p.country = ?",
+			wantValues: []any{"active", 4, "2024-01-01", "JP"},
+		},
+		{
+			name:       "JoinSubquery with CROSS JOIN",
+			setup:      func(b *TableBlock) { b.JoinSubquery(JoinCross, latestReview(), "latest", "ignored = ?", 1) },
+			wantSQL:    "books b CROSS JOIN (SELECT r.book_id, MAX(r.created_at) AS reviewed_at FROM reviews r WHERE r.rating >= ? GROUP BY r.book_id) AS latest",
+			wantValues: []any{4},
+		},
+		{
+			name: "JoinSubquery with duplicated alias is not added",
+			setup: func(b *TableBlock) {
+				b.InnerJoin("latest", "latest.id = b.id")
+				b.JoinSubquery(JoinInner, latestReview(), "latest", "")
+			},
+			wantSQL:    "books b INNER JOIN latest ON latest.id = b.id",
+			wantValues: nil,
+		},
+		{
+			name:       "CrossJoinLateral",
+			setup:      func(b *TableBlock) { b.CrossJoinLateral(topReview(), "top") },
+			wantSQL:    "books b CROSS JOIN LATERAL (SELECT r.body FROM reviews r WHERE r.rating >= ? ORDER BY r.created_at DESC LIMIT ?) AS top",
+			wantValues: []any{4, 1},
+		},
+		{
+			name:       "LeftOuterJoinLateral",
+			setup:      func(b *TableBlock) { b.LeftOuterJoinLateral(topReview(), "top") },
+			wantSQL:    "books b LEFT OUTER JOIN LATERAL (SELECT r.body FROM reviews r WHERE r.rating >= ? ORDER BY r.created_at DESC LIMIT ?) AS top ON TRUE",
+			wantValues: []any{4, 1},
+		},
+		{
+			name:       "InnerJoinLateral",
+			setup:      func(b *TableBlock) { b.InnerJoinLateral(topReview(), "top") },
+			wantSQL:    "books b INNER JOIN LATERAL (SELECT r.body FROM reviews r WHERE r.rating >= ? ORDER BY r.created_at DESC LIMIT ?) AS top ON TRUE",
+			wantValues: []any{4, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewTableBlock("books b")
+			tt.setup(b)
+			gotSQL, gotValues := b.Build()
+			if gotSQL != tt.wantSQL {
+				t.Errorf("Build() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("Build() values = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestTableBlock_Validate(t *testing.T) {
+	t.Parallel()
+	sub := New("reviews", NewSimpleFields("body"))
+	tests := []struct {
+		name    string
+		setup   func(b *TableBlock)
+		dialect Dialect
+		wantErr error
+	}{
+		{name: "FULL OUTER JOIN on PostgreSQL", setup: func(b *TableBlock) { b.FullOuterJoin("authors a", "") }, dialect: PostgreSQL, wantErr: nil},
+		{name: "FULL OUTER JOIN on MySQL", setup: func(b *TableBlock) { b.FullOuterJoin("authors a", "") }, dialect: MySQL, wantErr: ErrFullOuterJoinUnsupported},
+		{name: "LATERAL on MySQL", setup: func(b *TableBlock) { b.CrossJoinLateral(sub, "r") }, dialect: MySQL, wantErr: nil},
+		{name: "LATERAL on SQLServer", setup: func(b *TableBlock) { b.CrossJoinLateral(sub, "r") }, dialect: SQLServer, wantErr: nil},
+		{name: "LATERAL on SQLite", setup: func(b *TableBlock) { b.LeftOuterJoinLateral(sub, "r") }, dialect: SQLite, wantErr: ErrLateralJoinUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewTableBlock("books b")
+			tt.setup(b)
+			if err := b.Validate(tt.dialect); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTableBlock_JoinConflict(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		setup       func(b *TableBlock)
+		wantContent string
+		wantErr     error
+	}{
+		{
+			name: "LEFT OUTER JOIN upgraded to INNER JOIN",
+			setup: func(b *TableBlock) {
+				b.LeftOuterJoin("authors a", "a.id = b.author_id")
+				b.InnerJoin("publishers p", "p.id = b.publisher_id")
+				b.InnerJoin("authors a", "a.id = b.author_id")
+			},
+			wantContent: "books b INNER JOIN authors a ON a.id = b.author_id INNER JOIN publishers p ON p.id = b.publisher_id",
+		},
+		{
+			name: "INNER JOIN kept for LEFT OUTER JOIN",
+			setup: func(b *TableBlock) {
+				b.InnerJoin("authors a", "a.id = b.author_id AND a.status = ?", "active")
+				b.LeftOuterJoin("authors a", "a.id = b.author_id AND a.status = ?", "active")
+			},
+			wantContent: "books b INNER JOIN authors a ON a.id = b.author_id AND a.status = ?",
+		},
+		{
+			name: "different join type",
+			setup: func(b *TableBlock) {
+				b.InnerJoin("authors a", "a.id = b.author_id")
+				b.RightOuterJoin("authors a", "a.id = b.author_id")
+			},
+			wantContent: "books b INNER JOIN authors a ON a.id = b.author_id",
+			wantErr:     ErrJoinConflict,
+		},
+		{
+			name: "different table with the same alias",
+			setup: func(b *TableBlock) {
+				b.InnerJoin("authors a", "a.id = b.author_id")
+				b.InnerJoin("accounts a", "a.id = b.author_id")
+			},
+			wantContent: "books b INNER JOIN authors a ON a.id = b.author_id",
+			wantErr:     ErrJoinConflict,
+		},
+		{
+			name: "different values",
+			setup: func(b *TableBlock) {
+				b.LeftOuterJoin("authors a", "a.id = b.author_id AND a.status = ?", "active")
+				b.InnerJoin("authors a", "a.id = b.author_id AND a.status = ?", "inactive")
+			},
+			wantContent: "books b LEFT OUTER JOIN authors a ON a.id = b.author_id AND a.status = ?",
+			wantErr:     ErrJoinConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewTableBlock("books b")
+			tt.setup(b)
+			if got, _ := b.Build(); got != tt.wantContent {
+				t.Errorf("Build() content = %v, want %v", got, tt.wantContent)
+			}
+			if err := b.Err(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
+			if err := b.Validate(PostgreSQL); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -26,8 +26,26 @@ func (b *WhereBlock) IsEmpty() bool {
 	return len(b.conditions) == 0
 }
 
+// subqueries returns the subqueries embedded in the conditions.
+func (b *WhereBlock) subqueries() []RawBuilder {
+	var subs []RawBuilder
+	for _, condition := range b.conditions {
+		subs = append(subs, expr.Subqueries(condition)...)
+	}
+	return subs
+}
+
 // Build constructs the WHERE clause string and returns it with placeholder values.
+// The subqueries embedded in the conditions are built with their own dialect.
 func (b *WhereBlock) Build() (string, []any) {
 	conditions := expr.NewConditions(b.Connector, b.conditions...)
 	return conditions.String(), conditions.Values()
 }
+
+// build constructs the WHERE clause string with the subqueries built once for the dialect.
+func (b *WhereBlock) build(d Dialect) (string, []any) {
+	conditions := expr.MapSubqueries(expr.NewConditions(b.Connector, b.conditions...), func(sub expr.Subquery) expr.Subquery {
+		return buildEmbedded(sub, d)
+	})
+	return conditions.String(), conditions.Values()
+}
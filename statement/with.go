@@ -0,0 +1,140 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrCTEConflict is returned when a common table expression is added with the name of another expression
+// which has a different query or values.
+var ErrCTEConflict = errors.New("conflicting common table expression")
+
+// commonTableExpr is a named subquery in the WITH clause.
+type commonTableExpr struct {
+	name string
+	// sub is the statement added by Add, which is built when the block is built.
+	sub    RawBuilder
+	query  string
+	values []any
+}
+
+// WithBlock represents the WITH clause of a SQL statement which defines common table expressions.
+type WithBlock struct {
+	// Recursive makes the clause WITH RECURSIVE so that the expressions can refer to themselves.
+	// The RECURSIVE keyword is omitted for dialects which don't use it such as SQL Server.
+	Recursive bool
+	items     []*commonTableExpr
+}
+
+// NewWithBlock creates a new empty WithBlock.
+func NewWithBlock() *WithBlock {
+	return &WithBlock{items: []*commonTableExpr{}}
+}
+
+// IsEmpty returns true if the block has no common table expressions.
+func (b *WithBlock) IsEmpty() bool {
+	return len(b.items) == 0
+}
+
+// Add adds the statement as a common table expression named name.
+// name can have a column list like "tree(id, parent_id)".
+// The statement is built with the dialect of the statement embedding the block when it is built.
+// See AddRaw for the expressions with the same name.
+func (b *WithBlock) Add(name string, sub RawBuilder) {
+	b.items = append(b.items, &commonTableExpr{name: name, sub: sub})
+}
+
+// AddRaw adds the SQL with ? placeholders as a common table expression named name.
+// It is useful for recursive expressions which refer to themselves with UNION ALL.
+// If the same expression already exists, it is skipped. If an expression with the same name but
+// a different column list, query or values exists, the conflict is reported by Err.
+func (b *WithBlock) AddRaw(name string, query string, values ...any) {
+	b.items = append(b.items, &commonTableExpr{name: name, query: query, values: values})
+}
+
+// Err returns the errors of the conflicting expressions added to the block.
+func (b *WithBlock) Err() error {
+	_, err := b.resolve(nil)
+	return err
+}
+
+// subqueries returns the statements added by Add.
+func (b *WithBlock) subqueries() []RawBuilder {
+	var subs []RawBuilder
+	for _, item := range b.items {
+		if item.sub != nil {
+			subs = append(subs, item.sub)
+		}
+	}
+	return subs
+}
+
+// resolve returns the expressions built for the dialect d without the duplicated ones,
+// and the errors of the conflicting ones. If d is nil, the statements are built with their own dialect.
+func (b *WithBlock) resolve(d Dialect) ([]*commonTableExpr, error) {
+	items := make([]*commonTableExpr, 0, len(b.items))
+	var errs []error
+	for _, item := range b.items {
+		if item.sub != nil {
+			built := buildEmbedded(item.sub, d)
+			item = &commonTableExpr{name: item.name, query: built.query, values: built.values}
+		}
+		idx := slices.IndexFunc(items, func(existing *commonTableExpr) bool {
+			return strings.EqualFold(cteName(existing.name), cteName(item.name))
+		})
+		switch {
+		case idx < 0:
+			items = append(items, item)
+		case !items[idx].same(item):
+			errs = append(errs, fmt.Errorf("%w %q: %s AS (%s) and %s AS (%s)",
+				ErrCTEConflict, cteName(item.name), items[idx].name, items[idx].query, item.name, item.query))
+		}
+	}
+	return items, errors.Join(errs...)
+}
+
+// same returns true if the expressions have the same name, query and values.
+func (e *commonTableExpr) same(other *commonTableExpr) bool {
+	return strings.EqualFold(e.name, other.name) && e.query == other.query &&
+		(len(e.values) == 0 && len(other.values) == 0 || reflect.DeepEqual(e.values, other.values))
+}
+
+// cteName returns the name of the expression without the column list.
+func cteName(name string) string {
+	if idx := strings.Index(name, "("); idx >= 0 {
+		name = name[:idx]
+	}
+	return strings.TrimSpace(name)
+}
+
+// Build constructs the WITH clause string and returns it with placeholder values.
+// The statements added by Add are built with their own dialect.
+func (b *WithBlock) Build() (string, []any) {
+	return b.build(b.Recursive, nil)
+}
+
+// build constructs the WITH clause string with the statements built for the dialect d.
+func (b *WithBlock) build(recursive bool, d Dialect) (string, []any) {
+	if b.IsEmpty() {
+		return "", []any{}
+	}
+	// Conflicting expressions are reported by Err.
+	items, _ := b.resolve(d)
+	var sb strings.Builder
+	args := []any{}
+	sb.WriteString("WITH ")
+	if recursive {
+		sb.WriteString("RECURSIVE ")
+	}
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(item.name + " AS (" + item.query + ")")
+		args = append(args, item.values...)
+	}
+	return sb.String(), args
+}
@@ -0,0 +1,197 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tecowl/querybm/expr"
+)
+
+func TestNewWithBlock(t *testing.T) {
+	t.Parallel()
+	b := NewWithBlock()
+	if !b.IsEmpty() {
+		t.Errorf("NewWithBlock() should be empty")
+	}
+	gotSQL, gotValues := b.Build()
+	if gotSQL != "" {
+		t.Errorf("Build() SQL = %v, want empty", gotSQL)
+	}
+	if !reflect.DeepEqual(gotValues, []any{}) {
+		t.Errorf("Build() values = %v, want empty slice", gotValues)
+	}
+}
+
+func TestWithBlock_Build(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		setup      func() *WithBlock
+		wantSQL    string
+		wantValues []any
+	}{
+		{
+			name: "statement",
+			setup: func() *WithBlock {
+				sub := New("books", NewSimpleFields("id", "author_id"))
+				sub.Where.Add(expr.Field("yr", expr.Gte(2000)))
+				b := NewWithBlock()
+				b.Add("recent_books", sub)
+				return b
+			},
+			wantSQL:    "WITH recent_books AS (SELECT id, author_id FROM books WHERE yr >= ?)",
+			wantValues: []any{2000},
+		},
+		{
+			name: "multiple expressions",
+			setup: func() *WithBlock {
+				b := NewWithBlock()
+				b.AddRaw("a", "SELECT id FROM authors WHERE country = ?", "JP")
+				b.AddRaw("b(id)", "SELECT author_id FROM books WHERE yr >= ?", 2000)
+				return b
+			},
+			wantSQL:    "WITH a AS (SELECT id FROM authors WHERE country = ?), b(id) AS (SELECT author_id FROM books WHERE yr >= ?)",
+			wantValues: []any{"JP", 2000},
+		},
+		{
+			name: "duplicated expression is skipped",
+			setup: func() *WithBlock {
+				b := NewWithBlock()
+				b.AddRaw("a", "SELECT id FROM authors WHERE country = ?", "JP")
+				b.AddRaw("A", "SELECT id FROM authors WHERE country = ?", "JP")
+				return b
+			},
+			wantSQL:    "WITH a AS (SELECT id FROM authors WHERE country = ?)",
+			wantValues: []any{"JP"},
+		},
+		{
+			name: "recursive",
+			setup: func() *WithBlock {
+				b := NewWithBlock()
+				b.Recursive = true
+				b.AddRaw("tree(id, parent_id)",
+					"SELECT id, parent_id FROM categories WHERE id = ? UNION ALL SELECT c.id, c.parent_id FROM categories c INNER JOIN tree t ON c.parent_id = t.id", 1)
+				return b
+			},
+			wantSQL:    "WITH RECURSIVE tree(id, parent_id) AS (SELECT id, parent_id FROM categories WHERE id = ? UNION ALL SELECT c.id, c.parent_id FROM categories c INNER JOIN tree t ON c.parent_id = t.id)",
+			wantValues: []any{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotSQL, gotValues := tt.setup().Build()
+			if gotSQL != tt.wantSQL {
+				t.Errorf("Build() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotValues, tt.wantValues) {
+				t.Errorf("Build() values = %v, want %v", gotValues, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestWithBlock_Err(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		setup   func(b *WithBlock)
+		wantErr error
+		wantSQL string
+	}{
+		{
+			name: "same expression",
+			setup: func(b *WithBlock) {
+				b.AddRaw("a(id)", "SELECT id FROM authors WHERE country = ?", "JP")
+				b.AddRaw("a(id)", "SELECT id FROM authors WHERE country = ?", "JP")
+			},
+			wantSQL: "WITH a(id) AS (SELECT id FROM authors WHERE country = ?)",
+		},
+		{
+			name: "different query",
+			setup: func(b *WithBlock) {
+				b.AddRaw("a", "SELECT id FROM authors")
+				b.AddRaw("a", "SELECT id FROM editors")
+			},
+			wantErr: ErrCTEConflict,
+			wantSQL: "WITH a AS (SELECT id FROM authors)",
+		},
+		{
+			name: "different values",
+			setup: func(b *WithBlock) {
+				b.AddRaw("a", "SELECT id FROM authors WHERE country = ?", "JP")
+				b.AddRaw("A", "SELECT id FROM authors WHERE country = ?", "US")
+			},
+			wantErr: ErrCTEConflict,
+			wantSQL: "WITH a AS (SELECT id FROM authors WHERE country = ?)",
+		},
+		{
+			name: "different column list",
+			setup: func(b *WithBlock) {
+				b.AddRaw("a(id)", "SELECT id FROM authors")
+				b.AddRaw("a(author_id)", "SELECT id FROM authors")
+			},
+			wantErr: ErrCTEConflict,
+			wantSQL: "WITH a(id) AS (SELECT id FROM authors)",
+		},
+		{
+			name: "same statement",
+			setup: func(b *WithBlock) {
+				b.Add("a", New("authors", NewSimpleFields("id")))
+				b.Add("a", New("authors", NewSimpleFields("id")))
+			},
+			wantSQL: "WITH a AS (SELECT id FROM authors)",
+		},
+		{
+			name: "different statement",
+			setup: func(b *WithBlock) {
+				b.Add("a", New("authors", NewSimpleFields("id")))
+				b.Add("a", New("editors", NewSimpleFields("id")))
+			},
+			wantErr: ErrCTEConflict,
+			wantSQL: "WITH a AS (SELECT id FROM authors)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := NewWithBlock()
+			tt.setup(b)
+			if err := b.Err(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", err, tt.wantErr)
+			}
+			if gotSQL, _ := b.Build(); gotSQL != tt.wantSQL {
+				t.Errorf("Build() SQL = %v, want %v", gotSQL, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestWithBlock_Add(t *testing.T) {
+	t.Parallel()
+	sub := New("books", NewSimpleFields("id"))
+	b := NewWithBlock()
+	b.Add("recent", sub)
+	// The statement is built when the block is built.
+	sub.Where.Add(expr.Field("yr", expr.Gte(2000)))
+	gotSQL, gotValues := b.Build()
+	if want := "WITH recent AS (SELECT id FROM books WHERE yr >= ?)"; gotSQL != want {
+		t.Errorf("Build() SQL = %v, want %v", gotSQL, want)
+	}
+	if want := []any{2000}; !reflect.DeepEqual(gotValues, want) {
+		t.Errorf("Build() values = %v, want %v", gotValues, want)
+	}
+}
+
+func TestStatement_Err_CTEConflict(t *testing.T) {
+	t.Parallel()
+	st := New("books", NewSimpleFields("id"))
+	st.With.AddRaw("recent", "SELECT id FROM books WHERE yr >= ?", 2000)
+	st.With.AddRaw("recent", "SELECT id FROM books WHERE yr >= ?", 2010)
+	if err := st.Err(); !errors.Is(err, ErrCTEConflict) {
+		t.Errorf("Err() = %v, want %v", err, ErrCTEConflict)
+	}
+}
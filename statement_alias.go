@@ -7,3 +7,14 @@ import "github.com/tecowl/querybm/statement"
 type (
 	Statement = statement.Statement
 )
+
+// Dialect is a type alias for statement.Dialect.
+type Dialect = statement.Dialect
+
+// Built-in dialects re-exported from the statement package.
+var (
+	MySQL      = statement.MySQL
+	PostgreSQL = statement.PostgreSQL
+	SQLite     = statement.SQLite
+	SQLServer  = statement.SQLServer
+)
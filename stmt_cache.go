@@ -0,0 +1,219 @@
+package querybm
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// DefaultStmtCacheSize is the max size of a StmtCache created with a non-positive size.
+const DefaultStmtCacheSize = 100
+
+// StmtCache is a concurrency-safe LRU cache of prepared statements keyed by the DB and SQL text.
+// It reuses prepared statements across query executions instead of preparing and closing them every time.
+// When the cache is full, the least recently used statement is evicted and closed
+// once all executions using it have finished.
+//
+// Use it with WithStmtCache, or attach it to a DB with NewCachedDB. The statements are cached per DB such as *sql.DB or *sql.Conn,
+// so the queries rebound with Query.WithDB don't reuse the statements of another DB.
+// The statements aren't cached in transactions because they are closed when the transactions end.
+type StmtCache struct {
+	mu        sync.Mutex
+	maxSize   int
+	lru       *list.List // *stmtCacheEntry, the front is the most recently used one.
+	entries   map[stmtCacheKey]*list.Element
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// StmtCacheStats is the statistics of a StmtCache.
+type StmtCacheStats struct {
+	// Hits is the number of executions which reused a cached statement.
+	Hits int64
+	// Misses is the number of executions which prepared a statement.
+	Misses int64
+	// Evictions is the number of statements evicted from the cache.
+	Evictions int64
+	// Size is the number of cached statements.
+	Size int
+	// MaxSize is the max number of cached statements.
+	MaxSize int
+}
+
+// stmtCacheKey is the key of a cached statement.
+type stmtCacheKey struct {
+	db    any
+	query string
+}
+
+type stmtCacheEntry struct {
+	key     stmtCacheKey
+	stmt    Stmt
+	refs    int
+	evicted bool
+}
+
+// NewStmtCache creates a new StmtCache holding up to maxSize statements.
+// If maxSize is <= 0, DefaultStmtCacheSize is used.
+func NewStmtCache(maxSize int) *StmtCache {
+	if maxSize <= 0 {
+		maxSize = DefaultStmtCacheSize
+	}
+	return &StmtCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: map[stmtCacheKey]*list.Element{},
+	}
+}
+
+// Stats returns the statistics of the cache.
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return StmtCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.lru.Len(),
+		MaxSize:   c.maxSize,
+	}
+}
+
+// Close removes all statements from the cache and closes them.
+// Statements used by running executions are closed when the executions finish.
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	var stmts []Stmt
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if stmt := c.remove(el); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+		el = next
+	}
+	c.mu.Unlock()
+
+	errs := make([]error, 0, len(stmts))
+	for _, stmt := range stmts {
+		errs = append(errs, stmt.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// stmtCacheDB returns the DB identifying the cached statements. It unwraps *DBWrapper because
+// Query.WithDB wraps the same DBTX in a new *DBWrapper every time. It returns nil if the DB can't be a map key.
+func stmtCacheDB(db DB) any {
+	var key any = db
+	if w, ok := db.(*DBWrapper); ok {
+		key = w.db
+	}
+	if key == nil || !reflect.TypeOf(key).Comparable() {
+		return nil
+	}
+	return key
+}
+
+// prepare returns the cached statement for the query on the DB, or prepares it on the DB and caches it.
+// The returned statement must be closed to release it.
+func (c *StmtCache) prepare(ctx context.Context, db DB, query string) (Stmt, error) { // nolint:ireturn
+	keyDB := stmtCacheDB(db)
+	if keyDB == nil {
+		return db.PrepareContext(ctx, query)
+	}
+	key := stmtCacheKey{db: keyDB, query: query}
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.hits++
+		entry := c.acquire(el)
+		c.mu.Unlock()
+		return &cachedStmt{Stmt: entry.stmt, cache: c, entry: entry}, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		// The same query was prepared concurrently. Use the cached one.
+		entry := c.acquire(el)
+		c.mu.Unlock()
+		_ = stmt.Close()
+		return &cachedStmt{Stmt: entry.stmt, cache: c, entry: entry}, nil
+	}
+	entry := &stmtCacheEntry{key: key, stmt: stmt, refs: 1}
+	c.entries[key] = c.lru.PushFront(entry)
+	var evicted []Stmt
+	for c.lru.Len() > c.maxSize {
+		c.evictions++
+		if stmt := c.remove(c.lru.Back()); stmt != nil {
+			evicted = append(evicted, stmt)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, stmt := range evicted {
+		_ = stmt.Close()
+	}
+	return &cachedStmt{Stmt: entry.stmt, cache: c, entry: entry}, nil
+}
+
+// acquire marks the entry as used. c.mu must be locked.
+func (c *StmtCache) acquire(el *list.Element) *stmtCacheEntry {
+	c.lru.MoveToFront(el)
+	entry := el.Value.(*stmtCacheEntry) //nolint:forcetypeassert
+	entry.refs++
+	return entry
+}
+
+// remove removes the element from the cache. c.mu must be locked.
+// It returns the statement to be closed if it is not used, otherwise it is closed by release.
+func (c *StmtCache) remove(el *list.Element) Stmt { // nolint:ireturn
+	entry := el.Value.(*stmtCacheEntry) //nolint:forcetypeassert
+	c.lru.Remove(el)
+	delete(c.entries, entry.key)
+	entry.evicted = true
+	if entry.refs > 0 {
+		return nil
+	}
+	return entry.stmt
+}
+
+// release marks the entry as unused and closes the statement if it has been evicted.
+func (c *StmtCache) release(entry *stmtCacheEntry) error {
+	c.mu.Lock()
+	entry.refs--
+	closing := entry.evicted && entry.refs == 0
+	c.mu.Unlock()
+	if closing {
+		return entry.stmt.Close()
+	}
+	return nil
+}
+
+// cachedStmt is a Stmt borrowed from a StmtCache.
+// Close releases it to the cache instead of closing the prepared statement.
+type cachedStmt struct {
+	Stmt
+	cache  *StmtCache
+	entry  *stmtCacheEntry
+	closed bool
+}
+
+var _ Stmt = (*cachedStmt)(nil)
+
+// Close implements Stmt.
+func (s *cachedStmt) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.cache.release(s.entry)
+}
@@ -0,0 +1,212 @@
+package querybm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestNewStmtCache(t *testing.T) {
+	t.Parallel()
+	if got := NewStmtCache(0).Stats().MaxSize; got != DefaultStmtCacheSize {
+		t.Errorf("NewStmtCache(0) MaxSize = %v, want %v", got, DefaultStmtCacheSize)
+	}
+	if got := NewStmtCache(3).Stats().MaxSize; got != 3 {
+		t.Errorf("NewStmtCache(3) MaxSize = %v, want %v", got, 3)
+	}
+}
+
+func TestStmtCache(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	db := &RecordingDB{}
+	cache := NewStmtCache(2)
+
+	use := func(query string) {
+		t.Helper()
+		stmt, err := cache.prepare(ctx, db, query)
+		if err != nil {
+			t.Fatalf("prepare(%q) error = %v", query, err)
+		}
+		if err := stmt.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	use("q1")
+	use("q2")
+	use("q1")
+	use("q3") // evicts q2
+	use("q1")
+
+	if want := map[string]int{"q1": 1, "q2": 1, "q3": 1}; !reflect.DeepEqual(countQueries(db.prepared), want) {
+		t.Errorf("prepared = %v, want %v", db.prepared, want)
+	}
+	if want := map[string]int{"q2": 1}; !reflect.DeepEqual(countQueries(db.closed), want) {
+		t.Errorf("closed = %v, want %v", db.closed, want)
+	}
+	want := StmtCacheStats{Hits: 2, Misses: 3, Evictions: 1, Size: 2, MaxSize: 2}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if want := map[string]int{"q1": 1, "q2": 1, "q3": 1}; !reflect.DeepEqual(countQueries(db.closed), want) {
+		t.Errorf("closed = %v, want %v", db.closed, want)
+	}
+	if got := cache.Stats().Size; got != 0 {
+		t.Errorf("Stats().Size = %v, want 0", got)
+	}
+}
+
+func TestStmtCache_EvictInUse(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	db := &RecordingDB{}
+	cache := NewStmtCache(1)
+
+	inUse, err := cache.prepare(ctx, db, "q1")
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	q2, err := cache.prepare(ctx, db, "q2")
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	if len(db.closed) != 0 {
+		t.Errorf("closed = %v, want none while in use", db.closed)
+	}
+
+	if err := inUse.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := inUse.Close(); err != nil {
+		t.Fatalf("Close() twice error = %v", err)
+	}
+	if want := map[string]int{"q1": 1}; !reflect.DeepEqual(countQueries(db.closed), want) {
+		t.Errorf("closed = %v, want %v", db.closed, want)
+	}
+
+	// Close of the cache defers closing the statement in use.
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if want := map[string]int{"q1": 1}; !reflect.DeepEqual(countQueries(db.closed), want) {
+		t.Errorf("closed = %v, want %v", db.closed, want)
+	}
+	if err := q2.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if want := map[string]int{"q1": 1, "q2": 1}; !reflect.DeepEqual(countQueries(db.closed), want) {
+		t.Errorf("closed = %v, want %v", db.closed, want)
+	}
+}
+
+func TestStmtCache_PrepareError(t *testing.T) {
+	t.Parallel()
+	db := &MockDB{PrepareContextFunc: func(context.Context, string) (Stmt, error) { return nil, errConditionError }}
+	cache := NewStmtCache(1)
+	if _, err := cache.prepare(t.Context(), db, "q1"); !errors.Is(err, errConditionError) {
+		t.Errorf("prepare() error = %v, want %v", err, errConditionError)
+	}
+	if got := cache.Stats(); got.Size != 0 || got.Misses != 1 {
+		t.Errorf("Stats() = %+v, want no cached statement and 1 miss", got)
+	}
+}
+
+func TestStmtCache_Concurrent(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	db := &RecordingDB{}
+	cache := NewStmtCache(2)
+	queries := []string{"q1", "q2", "q3"}
+
+	var wg sync.WaitGroup
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stmt, err := cache.prepare(ctx, db, queries[i%len(queries)])
+			if err != nil {
+				t.Errorf("prepare() error = %v", err)
+				return
+			}
+			_ = stmt.Close()
+		}()
+	}
+	wg.Wait()
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// Every prepared statement is closed exactly once.
+	if got, want := countQueries(db.closed), countQueries(db.prepared); !reflect.DeepEqual(got, want) {
+		t.Errorf("closed = %v, want %v", got, want)
+	}
+	if got := cache.Stats(); got.Hits+got.Misses != 30 {
+		t.Errorf("Stats() = %+v, want 30 executions", got)
+	}
+}
+
+// stmtCacheTestDBTX is a DBTX counting PrepareContext calls.
+type stmtCacheTestDBTX struct {
+	DBTX
+	prepared int
+}
+
+func (db *stmtCacheTestDBTX) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	db.prepared++
+	return nil, nil
+}
+
+func TestStmtCache_DB(t *testing.T) {
+	t.Parallel()
+	cache := NewStmtCache(10)
+	db := &stmtCacheTestDBTX{}
+	conn := &stmtCacheTestDBTX{}
+	// Each DBWrapper of the same DBTX shares the statements like the queries rebound with WithDB.
+	for _, w := range []DB{newDBWrapper(db), newDBWrapper(db), newDBWrapper(conn)} {
+		stmt, err := cache.prepare(t.Context(), w, "SELECT 1")
+		if err != nil {
+			t.Fatalf("prepare() error = %v", err)
+		}
+		if err := stmt.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+	if db.prepared != 1 || conn.prepared != 1 {
+		t.Errorf("prepared = %v and %v, want 1 on each DB", db.prepared, conn.prepared)
+	}
+	if got := cache.Stats(); got.Hits != 1 || got.Misses != 2 || got.Size != 2 {
+		t.Errorf("Stats() = %+v, want 1 hit, 2 misses and 2 statements", got)
+	}
+}
+
+// uncomparableStmtCacheTestDB is a DB which can't be a map key.
+type uncomparableStmtCacheTestDB struct {
+	prepared []string
+}
+
+func (db uncomparableStmtCacheTestDB) PrepareContext(context.Context, string) (Stmt, error) { // nolint:ireturn
+	return &MockStmt{}, nil
+}
+
+func TestStmtCache_UncomparableDB(t *testing.T) {
+	t.Parallel()
+	cache := NewStmtCache(10)
+	stmt, err := cache.prepare(t.Context(), uncomparableStmtCacheTestDB{}, "SELECT 1")
+	if err != nil {
+		t.Fatalf("prepare() error = %v", err)
+	}
+	if _, ok := stmt.(*cachedStmt); ok {
+		t.Errorf("prepare() = %T, want the statement not cached", stmt)
+	}
+	if got := cache.Stats(); got.Size != 0 {
+		t.Errorf("Stats() = %+v, want no cached statement", got)
+	}
+}
@@ -1,8 +1,6 @@
 package authors
 
 import (
-	"database/sql"
-
 	"github.com/tecowl/querybm"
 	. "github.com/tecowl/querybm/expr"
 
@@ -21,8 +19,8 @@ func (c *Condition) Build(s *querybm.Statement) {
 	}
 }
 
-func New(db *sql.DB, condition *Condition) *querybm.Query[models.Author] {
-	return querybm.New(
+func New(db querybm.DB, condition *Condition) *querybm.Query[models.Author] {
+	return querybm.NewWithDB(
 		db,
 		"authors",
 		querybm.NewFields(
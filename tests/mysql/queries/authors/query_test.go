@@ -2,6 +2,7 @@ package authors
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/tecowl/querybm"
+	"github.com/tecowl/querybm/querybmtest"
 
 	"mysql-test/fixtures"
 	"mysql-test/models"
@@ -18,13 +20,20 @@ import (
 func TestQuery(t *testing.T) {
 	ctx := context.Background()
 
-	db, teardown := testdb.Setup(t, ctx)
-	defer teardown(t)
-
-	var authors []*models.Author
+	authors := []*models.Author{
+		{AuthorID: 1, Name: "Martin Fowler"},
+		{AuthorID: 2, Name: "Kent Beck"},
+		{AuthorID: 3, Name: "Robert C. Martin"},
+		{AuthorID: 4, Name: "Uncle Bob"},
+		{AuthorID: 5, Name: "CMP Technology"},
+	}
 
-	t.Run("Setup records", func(t *testing.T) {
-		authors = fixtures.SetupAuthors(t, ctx, db)
+	// The queries are replayed from the fixture, which is recorded on the database with the -querybmtest.record flag.
+	db := querybmtest.UseFixture(t, filepath.Join("testdata", "query.json"), func() querybm.DB {
+		db, teardown := testdb.Setup(t, ctx)
+		t.Cleanup(func() { teardown(t) })
+		require.Equal(t, authors, fixtures.SetupAuthors(t, ctx, db))
+		return querybm.NewDBWrapper(db)
 	})
 
 	testCases := []struct {
@@ -64,3 +73,27 @@ func TestQuery(t *testing.T) {
 		})
 	}
 }
+
+// TestExplain runs on the database because the plans depend on the server.
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+
+	db, teardown := testdb.Setup(t, ctx)
+	defer teardown(t)
+
+	fixtures.SetupAuthors(t, ctx, db)
+
+	query := New(querybm.NewDBWrapper(db), &Condition{Name: "Beck"})
+
+	plan, err := query.Explain(ctx)
+	require.NoError(t, err)
+	table, ok := plan.Table("authors")
+	require.True(t, ok, plan.Raw)
+	assert.NotEmpty(t, table.AccessType)
+	assert.False(t, plan.Analyzed)
+
+	plan, err = query.ExplainAnalyze(ctx, querybm.ExplainCount())
+	require.NoError(t, err)
+	require.NotEmpty(t, plan.Tables, plan.Raw)
+	assert.True(t, plan.Analyzed)
+}